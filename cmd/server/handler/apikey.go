@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/apikey"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+type apiKeyHandler struct {
+	s   apikey.Service
+	loc *time.Location
+	log *slog.Logger
+}
+
+// NewAPIKeyHandler builds the API key handlers; expiry timestamps without a
+// UTC offset are read in loc, the clinic timezone.
+func NewAPIKeyHandler(s apikey.Service, loc *time.Location, log *slog.Logger) *apiKeyHandler {
+	return &apiKeyHandler{
+		s:   s,
+		loc: loc,
+		log: log,
+	}
+}
+
+// apiKeyRequest is the body of a key creation; expires_at is an optional
+// ISO-8601 timestamp.
+type apiKeyRequest struct {
+	Name      string              `json:"name"`
+	Scopes    []domain.Permission `json:"scopes"`
+	ExpiresAt string              `json:"expires_at"`
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Tags API keys
+// @Description list the API keys, without their secret
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} web.response
+// @Failure 403 {object} web.errorResponse
+// @Router /api-keys [get]
+func (h *apiKeyHandler) GetAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := h.s.GetAll(c.Request.Context())
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 200, keys)
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Tags API keys
+// @Description create an API key with the given scopes; the key is only shown in this response
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param key body apiKeyRequest true "Key to create"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 403 {object} web.errorResponse
+// @Router /api-keys [post]
+func (h *apiKeyHandler) Post() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req apiKeyRequest
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+			web.Failure(c, 400, errors.New("invalid json"))
+			return
+		}
+		var expiresAt time.Time
+		if req.ExpiresAt != "" {
+			expiresAt, err = parseTimestamp("expires_at", req.ExpiresAt, h.loc)
+			if err != nil {
+				web.Error(c, err)
+				return
+			}
+		}
+		key, err := h.s.Create(c.Request.Context(), req.Name, req.Scopes, expiresAt)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 201, key)
+	}
+}
+
+// RotateAPIKey godoc
+// @Summary Rotate API key
+// @Tags API keys
+// @Description replace the secret of an API key; the old one stops working at once
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "API key ID"
+// @Success 200 {object} web.response
+// @Failure 403 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /api-keys/{id}/rotate [post]
+func (h *apiKeyHandler) Rotate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		key, err := h.s.Rotate(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 200, key)
+	}
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API key
+// @Tags API keys
+// @Description delete an API key
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 403 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /api-keys/{id} [delete]
+func (h *apiKeyHandler) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		err = h.s.Revoke(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 204, nil)
+	}
+}
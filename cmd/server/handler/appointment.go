@@ -2,60 +2,104 @@ package handler
 
 import (
 	"errors"
-	"fmt"
-	"os"
+	"log/slog"
 	"strconv"
+	"time"
 
 	"github.com/JulietaAlfie/backendGo.git/internal/appointment"
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/middleware"
 	"github.com/JulietaAlfie/backendGo.git/pkg/web"
 	"github.com/gin-gonic/gin"
 )
 
 type appointmentHandler struct {
-	s appointment.Service
+	s   appointment.Service
+	loc *time.Location
+	log *slog.Logger
 }
 
-func NewAppointmentHandler(s appointment.Service) *appointmentHandler {
+// NewAppointmentHandler builds the appointment handlers; timestamps without a
+// UTC offset are read in loc, the clinic timezone.
+func NewAppointmentHandler(s appointment.Service, loc *time.Location, log *slog.Logger) *appointmentHandler {
 	return &appointmentHandler{
-		s: s,
+		s:   s,
+		loc: loc,
+		log: log,
 	}
 }
 
+type reference struct {
+	Id int `json:"id"`
+}
+
+// appointmentRequest is the body of the appointment writes: patient and dentist
+// are referenced by id, date is an ISO-8601 timestamp and the duration defaults
+// to the one of the treatment.
+type appointmentRequest struct {
+	Patient     reference `json:"patient"`
+	Dentist     reference `json:"dentist"`
+	Date        string    `json:"date"`
+	Duration    int       `json:"duration_minutes"`
+	Treatment   string    `json:"treatment"`
+	Description string    `json:"description"`
+}
+
+func (r appointmentRequest) toAppointment(loc *time.Location) (domain.Appointment, error) {
+	appointment := domain.Appointment{
+		Patient:     domain.Patient{Id: r.Patient.Id},
+		Dentist:     domain.Dentist{Id: r.Dentist.Id},
+		Duration:    r.Duration,
+		Treatment:   r.Treatment,
+		Description: r.Description,
+	}
+	if r.Date != "" {
+		date, err := parseTimestamp("date", r.Date, loc)
+		if err != nil {
+			return domain.Appointment{}, err
+		}
+		appointment.Date = date
+	}
+	return appointment, nil
+}
+
 // StoreDentist godoc
 // @Summary Store appointment
 // @Tags Appointments
 // @Description store appointment
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param appointment body domain.Appointment true "Appointment to store"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.response
+// @Failure 409 {object} web.errorResponse
+// @Failure 422 {object} web.errorResponse
 // @Router /appointments [post]
 func (h *appointmentHandler) Post() gin.HandlerFunc {
-	type Request struct {
-		PatientId   int    `json:"patient" binding:"required"`
-		DentistId   int    `json:"dentist" binding:"required"`
-		Date        string `json:"date" binding:"required"`
-		Time        string `json:"time" binding:"required"`
-		Description string `json:"description" binding:"required"`
-	}
 	return func(c *gin.Context) {
-		var appointment domain.Appointment
-		err := c.ShouldBindJSON(&appointment)
+		var req appointmentRequest
+		err := c.ShouldBindJSON(&req)
 		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		valid, err := validateEmptysAppointment(&appointment)
-		if !valid {
+		appointment, err := req.toAppointment(h.loc)
+		if err != nil {
 			web.Failure(c, 400, err)
 			return
 		}
-		app, err := h.s.Create(appointment)
+		app, err := h.s.Create(c.Request.Context(), appointment)
 		if err != nil {
-			web.Failure(c, 400, err)
+			web.Error(c, err)
+			return
+		}
+		// Read it back so the body has the patient and dentist like GET does.
+		app, err = h.s.GetByID(c.Request.Context(), app.Id)
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 201, app)
@@ -66,8 +110,11 @@ func (h *appointmentHandler) Post() gin.HandlerFunc {
 // @Tags Appointments
 // @Description get appointment
 // @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Appointment ID"
 // @Success 200 {object} web.response
+// @Failure 403 {object} web.errorResponse
 // @Failure 404 {object} web.response
 // @Router /appointments/{id} [get]
 func (h *appointmentHandler) GetByID() gin.HandlerFunc {
@@ -75,12 +122,15 @@ func (h *appointmentHandler) GetByID() gin.HandlerFunc {
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		appointment, err := h.s.GetByID(id)
+		appointment, err := h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("appointment not found"))
+			web.Error(c, err)
+			return
+		}
+		if !canAccess(c, appointment) {
 			return
 		}
 		web.Success(c, 200, appointment)
@@ -92,53 +142,50 @@ func (h *appointmentHandler) GetByID() gin.HandlerFunc {
 // @Description modify appointment
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param appointment body domain.Appointment true "Appointment to store"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.response
 // @Failure 401 {object} web.response
 // @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
 // @Router /appointments/{id} [put]
 func (h *appointmentHandler) Put() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
-			return
-		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
-			return
-		}
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		_, err = h.s.GetByID(id)
+		_, err = h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("appointment not found"))
+			web.Error(c, err)
 			return
 		}
+		var req appointmentRequest
+		err = c.ShouldBindJSON(&req)
 		if err != nil {
-			web.Failure(c, 409, err)
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		var appointment domain.Appointment
-		err = c.ShouldBindJSON(&appointment)
+		appointment, err := req.toAppointment(h.loc)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid json"))
+			web.Failure(c, 400, err)
 			return
 		}
-		valid, err := validateEmptysAppointment(&appointment)
-		if !valid {
-			web.Failure(c, 400, err)
+		// A replacement must be a whole appointment on its own; the service
+		// checks that a new date is in the future.
+		err = appointment.Validate()
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
-		app, err := h.s.Update(id, appointment)
+		app, err := h.s.Update(c.Request.Context(), id, appointment)
 		if err != nil {
-			web.Failure(c, 409, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, app)
@@ -150,54 +197,50 @@ func (h *appointmentHandler) Put() gin.HandlerFunc {
 // @Description modify appointment
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param appointment body domain.Appointment true "Appointment to store"
 // @Success 200 {object} web.response
+// @Failure 403 {object} web.errorResponse
 // @Router /appointments/{id} [patch]
 func (h *appointmentHandler) Patch() gin.HandlerFunc {
-	type Request struct {
-		Patient     domain.Patient `json:"patient,omitempty"`
-		Dentist     domain.Dentist `json:"dentist,omitempty"`
-		Date        string         `json:"date,omitempty"`
-		Time        string         `json:"time,omitempty"`
-		Description string         `json:"description,omitempty"`
-	}
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
-			return
-		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
-			return
-		}
-		var req Request
+		var req appointmentRequest
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		_, err = h.s.GetByID(id)
+		current, err := h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("appointment not found"))
+			web.Error(c, err)
 			return
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		update := domain.Appointment{
-			Patient:     req.Patient,
-			Dentist:     req.Dentist,
-			Date:        req.Date,
-			Time:        req.Time,
-			Description: req.Description,
+		principal, _ := middleware.CurrentPrincipal(c)
+		if !principal.Can(domain.WriteAppointments) {
+			// Annotating only changes the description of an own appointment.
+			if !canAccess(c, current) {
+				return
+			}
+			if req != (appointmentRequest{Description: req.Description}) {
+				web.Failure(c, 403, errors.New("only the description of the appointment can be changed"))
+				return
+			}
+		}
+		update, err := req.toAppointment(h.loc)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
 		}
-		app, err := h.s.Update(id, update)
+		app, err := h.s.Update(c.Request.Context(), id, update)
 		if err != nil {
-			web.Failure(c, 409, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, app)
@@ -206,8 +249,9 @@ func (h *appointmentHandler) Patch() gin.HandlerFunc {
 // DeleteAppointment godoc
 // @Summary Delete appointment
 // @Tags Appointments
-// @Description delete appointment
-// @Param token header string true "token"
+// @Description cancel appointment; it can be restored while the slot is free
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Appointment ID"
 // @Success 204 {object} web.response
 // @Failure 400 {object} web.response
@@ -216,119 +260,375 @@ func (h *appointmentHandler) Patch() gin.HandlerFunc {
 // @Router /appointments/{id} [delete]
 func (h *appointmentHandler) Delete() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		err = h.s.Delete(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 204, nil)
+	}
+}
+
+// RestoreAppointment godoc
+// @Summary Restore appointment
+// @Tags Appointments
+// @Description book a cancelled appointment again, unless its patient or dentist is deleted or the slot was taken
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Appointment ID"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.response
+// @Failure 401 {object} web.response
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /appointments/{id}/restore [post]
+func (h *appointmentHandler) Restore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
+		restored, err := h.s.Restore(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
-		idParam := c.Param("id")
-		id, err := strconv.Atoi(idParam)
+		web.Success(c, 200, restored)
+	}
+}
+
+// PurgeAppointment godoc
+// @Summary Purge appointment
+// @Tags Appointments
+// @Description remove a cancelled appointment for good
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Appointment ID"
+// @Success 204
+// @Failure 400 {object} web.response
+// @Failure 401 {object} web.response
+// @Failure 403 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /appointments/{id}/purge [delete]
+func (h *appointmentHandler) Purge() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		err = h.s.Delete(id)
+		err = h.s.Purge(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 204, nil)
 	}
 }
+
 // StoreDentistByDniAndLicence godoc
 // @Summary Store appointment with dni & license
 // @Tags Appointments
 // @Description store appointment with dni & license
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param dni path int true "Patient DNI"
+// @Param license path string true "Dentist license"
 // @Param appointment body domain.Appointment true "Appointment to store"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.response
-// @Router /appointments [post]
+// @Failure 409 {object} web.errorResponse
+// @Failure 422 {object} web.errorResponse
+// @Router /appointments/dni/{dni}/{license} [post]
 func (h *appointmentHandler) PostByDniAndLicence() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type Request struct {
-			Date        string `json:"date" binding:"required"`
-			Time        string `json:"time" binding:"required"`
-			Description string `json:"description" binding:"required"`
+			Date        string `json:"date"`
+			Duration    int    `json:"duration_minutes"`
+			Treatment   string `json:"treatment"`
+			Description string `json:"description"`
+		}
+		dniParam, err := strconv.Atoi(c.Param("dni"))
+		if err != nil {
+			web.Error(c, domain.Invalid("dni", "invalid dni"))
+			return
 		}
-		dniParam, _ := strconv.Atoi(c.Param("dni"))
 		licenseParam := c.Param("license")
 		var req Request
 		if err := c.ShouldBindJSON(&req); err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		tur, err := h.s.CreateByDniAndLicence(dniParam, licenseParam, req.Date, req.Time, req.Description)
+		appointment := domain.Appointment{
+			Duration:    req.Duration,
+			Treatment:   req.Treatment,
+			Description: req.Description,
+		}
+		if req.Date != "" {
+			date, err := parseTimestamp("date", req.Date, h.loc)
+			if err != nil {
+				web.Error(c, err)
+				return
+			}
+			appointment.Date = date
+		}
+		tur, err := h.s.CreateByDniAndLicence(c.Request.Context(), dniParam, licenseParam, appointment)
 		if err != nil {
-			web.Failure(c, 400, err)
+			web.Error(c, err)
+			return
+		}
+		tur, err = h.s.GetByID(c.Request.Context(), tur.Id)
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 201, tur)
 	}
 }
-// Appointment godoc
-// @Summary appointment
+// AppointmentHistory godoc
+// @Summary Patient appointments
 // @Tags Appointments
-// @Description get appointment
+// @Description get a page of the appointments of the patient with the dni, past and upcoming, sorted by date unless told otherwise
 // @Produce  json
-// @Param id path int true "Appointment DNI"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param dni path int true "Patient DNI"
+// @Param status query string false "upcoming or past"
+// @Param dentist_id query int false "Dentist ID"
+// @Param from query string false "First date or timestamp, included"
+// @Param to query string false "Last date, included, or timestamp, excluded"
+// @Param sort query string false "date or id; after a - to sort descending"
+// @Param limit query int false "Most appointments to return, 50 by default and 500 at most"
+// @Param offset query int false "Appointments to skip"
 // @Success 200 {object} web.response
-// @Failure 404 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 422 {object} web.errorResponse
 // @Router /appointments/dni/{dni} [get]
 func (h *appointmentHandler) GetByDni() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		dniParam := c.Param("dni")
 		dni, err := strconv.Atoi(dniParam)
 		if err != nil {
-			fmt.Println(err)
-			web.Failure(c, 400, errors.New("invalid dni"))
+			web.Error(c, domain.Invalid("dni", "invalid dni"))
 			return
 		}
-		appointment, err := h.s.GetByDNI(dni)
+		query, err := h.parseQuery(c)
 		if err != nil {
-			fmt.Println(err)
-			web.Failure(c, 404, errors.New("appointment not found"))
+			web.Failure(c, 400, err)
 			return
 		}
-		web.Success(c, 200, appointment)
+		principal, _ := middleware.CurrentPrincipal(c)
+		if !principal.Restrict(&query) {
+			web.Paginated(c, []domain.Appointment{}, 0, query.Limit, query.Offset)
+			return
+		}
+		appointments, total, err := h.s.History(c.Request.Context(), dni, query)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Paginated(c, appointments, total, query.Limit, query.Offset)
+	}
+}
+
+// DentistAgenda godoc
+// @Summary Dentist agenda
+// @Tags Dentists
+// @Description get the appointments of a dentist on a day or in the week, from monday, of a day; dentists and patients only get their own
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Dentist ID"
+// @Param date query string false "Day of the agenda, today by default"
+// @Param period query string false "day or week, day by default"
+// @Param sort query string false "date or id; after a - to sort descending"
+// @Param limit query int false "Most appointments to return, 50 by default and 500 at most"
+// @Param offset query int false "Appointments to skip"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 422 {object} web.errorResponse
+// @Router /dentists/{id}/appointments [get]
+func (h *appointmentHandler) GetAgenda() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		page, err := parsePage(c, domain.AppointmentSortKeys)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		query := domain.AppointmentQuery{Page: page}
+		day := time.Now().In(h.loc)
+		if date := c.Query("date"); date != "" {
+			day, err = time.ParseInLocation(domain.DateLayout, date, h.loc)
+			if err != nil {
+				web.Error(c, domain.Invalid("date", "date must be an ISO-8601 date such as 2020-03-20"))
+				return
+			}
+		}
+		query.From = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, h.loc)
+		switch c.DefaultQuery("period", "day") {
+		case "day":
+			query.To = query.From.AddDate(0, 0, 1)
+		case "week":
+			query.From = query.From.AddDate(0, 0, -(int(query.From.Weekday())+6)%7)
+			query.To = query.From.AddDate(0, 0, 7)
+		default:
+			web.Error(c, domain.Invalid("period", "period must be day or week"))
+			return
+		}
+		principal, _ := middleware.CurrentPrincipal(c)
+		if !principal.Restrict(&query) {
+			web.Paginated(c, []domain.Appointment{}, 0, page.Limit, page.Offset)
+			return
+		}
+		appointments, total, err := h.s.Agenda(c.Request.Context(), id, query)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Paginated(c, appointments, total, page.Limit, page.Offset)
 	}
 }
 // ListAppointments godoc
 // @Summary List appointments
 // @Tags Appointments
-// @Description get appointments
+// @Description get a page of appointments, sorted by date unless told otherwise; dentists and patients only get their own
 // @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param dentist_id query int false "Dentist ID"
+// @Param patient_id query int false "Patient ID"
+// @Param from query string false "First date or timestamp, included"
+// @Param to query string false "Last date, included, or timestamp, excluded"
+// @Param status query string false "upcoming or past"
+// @Param sort query string false "date or id; after a - to sort descending"
+// @Param limit query int false "Most appointments to return, 50 by default and 500 at most"
+// @Param offset query int false "Appointments to skip"
 // @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
 // @Failure 422 {object} web.errorResponse
 // @Router /appointments [get]
 func (h *appointmentHandler) GetAll() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		appointments, err := h.s.GetAll()
+		query, err := h.parseQuery(c)
 		if err != nil {
-			web.Failure(c, 422, errors.New("appointments could not be brought"))
+			web.Failure(c, 400, err)
+			return
+		}
+		principal, _ := middleware.CurrentPrincipal(c)
+		if !principal.Restrict(&query) {
+			web.Paginated(c, []domain.Appointment{}, 0, query.Limit, query.Offset)
+			return
+		}
+		appointments, total, err := h.s.Search(c.Request.Context(), query)
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
-		web.Success(c, 200, appointments)
+		web.Paginated(c, appointments, total, query.Limit, query.Offset)
 	}
 }
-func validateEmptysAppointment(appointment *domain.Appointment) (bool, error) {
-	switch {
-	case appointment.Patient == domain.Patient{}:
-		return false, errors.New("patient was empty")
-	case appointment.Dentist == domain.Dentist{}:
-		return false, errors.New("dentist was empty")
-	case appointment.Date == "":
-		return false, errors.New("date was empty")
-	case appointment.Time == "":
-		return false, errors.New("time was empty")
-	case appointment.Description == "":
-		return false, errors.New("description was empty")
+// DentistAvailability godoc
+// @Summary Dentist availability
+// @Tags Dentists
+// @Description list the free slots of a dentist between two dates (yyyy-mm-dd, both included)
+// @Produce  json
+// @Param id path int true "Dentist ID"
+// @Param from query string true "First day"
+// @Param to query string true "Last day"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /dentists/{id}/availability [get]
+func (h *appointmentHandler) GetAvailability() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		from, err := time.ParseInLocation(domain.DateLayout, c.Query("from"), h.loc)
+		if err != nil {
+			web.Error(c, domain.Invalid("from", "from must be an ISO-8601 date such as 2020-03-20"))
+			return
+		}
+		to, err := time.ParseInLocation(domain.DateLayout, c.Query("to"), h.loc)
+		if err != nil {
+			web.Error(c, domain.Invalid("to", "to must be an ISO-8601 date such as 2020-03-20"))
+			return
+		}
+		slots, err := h.s.Availability(c.Request.Context(), id, from, to)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 200, slots)
+	}
+}
+
+// parseQuery reads the page and the dentist_id, patient_id, from, to and
+// status filters of an appointment list.
+func (h *appointmentHandler) parseQuery(c *gin.Context) (domain.AppointmentQuery, error) {
+	page, err := parsePage(c, domain.AppointmentSortKeys)
+	if err != nil {
+		return domain.AppointmentQuery{}, err
+	}
+	query := domain.AppointmentQuery{Page: page}
+	query.DentistId, err = queryId(c, "dentist_id")
+	if err != nil {
+		return domain.AppointmentQuery{}, err
+	}
+	query.PatientId, err = queryId(c, "patient_id")
+	if err != nil {
+		return domain.AppointmentQuery{}, err
+	}
+	if from := c.Query("from"); from != "" {
+		query.From, err = parseDate("from", from, h.loc)
+		if err != nil {
+			return domain.AppointmentQuery{}, err
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		query.To, err = parseEnd("to", to, h.loc)
+		if err != nil {
+			return domain.AppointmentQuery{}, err
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		err = query.WithStatus(status, time.Now())
+		if err != nil {
+			return domain.AppointmentQuery{}, err
+		}
+	}
+	return query, nil
+}
+
+// canAccess answers 403 and returns false when the principal of the request
+// may not see the appointment.
+func canAccess(c *gin.Context, appointment domain.Appointment) bool {
+	principal, _ := middleware.CurrentPrincipal(c)
+	if !principal.CanAccess(appointment) {
+		web.Failure(c, 403, errors.New("the appointment belongs to another dentist or patient"))
+		return false
 	}
-	return true, nil
+	return true
 }
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/appointment"
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/internal/dentist"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/internal/patient"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/gin-gonic/gin"
+)
+
+// TestPostByDniAndLicence checks the booking by DNI and license next to the
+// other POST routes under /appointments, as main.go registers them.
+func TestPostByDniAndLicence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	db := store.NewMemoryDB()
+	dentists, patients := store.NewMemoryStoreDentist(db), store.NewMemoryStorePatient(db)
+	// A license saved before they were validated can be any word.
+	for _, license := range []string{"0009-1111", "restore"} {
+		_, err := dentists.Create(ctx, domain.Dentist{Name: "Ana", Lastname: "Perez", License: license})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := patients.Create(ctx, domain.Patient{Name: "Jose", Lastname: "Gomez", Residence: "Mitre 1", DNI: 30123456})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auditor := audit.NewService(audit.NewRepository(store.NewMemoryStoreAudit(db), discard), discard)
+	s := appointment.NewService(
+		appointment.NewRepository(store.NewMemoryStoreAppointment(db), discard),
+		dentist.NewRepository(dentists, discard),
+		patient.NewRepository(patients, discard),
+		auditor, discard,
+	)
+	h := NewAppointmentHandler(s, time.UTC, discard)
+	r := gin.New()
+	r.POST("/appointments/dni/:dni/:license", h.PostByDniAndLicence())
+	r.POST("/appointments/:id/restore", h.Restore())
+
+	date := time.Now().AddDate(0, 0, 7).UTC().Truncate(time.Hour)
+	body := func(hours int) string {
+		return `{"date":"` + date.Add(time.Duration(hours)*time.Hour).Format(time.RFC3339) + `","duration_minutes":30,"description":"control"}`
+	}
+	for _, test := range []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"booking", "/appointments/dni/30123456/0009-1111", body(0), http.StatusCreated, ""},
+		{"license restore", "/appointments/dni/30123456/restore", body(1), http.StatusCreated, ""},
+		{"dni not a number", "/appointments/dni/gomez/0009-1111", body(2), http.StatusBadRequest, "validation_failed"},
+		{"unknown dni", "/appointments/dni/1/0009-1111", body(2), http.StatusNotFound, "not_found"},
+		{"restore", "/appointments/1/restore", "", http.StatusNotFound, "not_deleted"},
+	} {
+		w := serve(r, "POST", test.path, test.body)
+		if w.Code != test.status {
+			t.Errorf("%s: got status %d %s, want %d", test.name, w.Code, w.Body, test.status)
+			continue
+		}
+		if test.code != "" {
+			if p := decodeProblem(t, w); p.Code != test.code {
+				t.Errorf("%s: got code %q, want %q", test.name, p.Code, test.code)
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+type auditHandler struct {
+	s   audit.Service
+	loc *time.Location
+	log *slog.Logger
+}
+
+// NewAuditHandler builds the audit log handlers; from and to are read in loc,
+// the clinic timezone, when they have no UTC offset.
+func NewAuditHandler(s audit.Service, loc *time.Location, log *slog.Logger) *auditHandler {
+	return &auditHandler{
+		s:   s,
+		loc: loc,
+		log: log,
+	}
+}
+
+// SearchAudit godoc
+// @Summary Search audit log
+// @Tags Audit
+// @Description list who created, changed or deleted records, newest first
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param entity query string false "dentist, schedule, patient or appointment"
+// @Param id query int false "Id of the entity"
+// @Param actor query string false "Username, or api-key:<id>"
+// @Param from query string false "First date or timestamp, included"
+// @Param to query string false "Last date, included, or timestamp, excluded"
+// @Param limit query int false "Most entries to return, 100 by default and 1000 at most"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 403 {object} web.errorResponse
+// @Router /audit [get]
+func (h *auditHandler) Search() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := domain.AuditQuery{
+			Entity: c.Query("entity"),
+			Actor:  c.Query("actor"),
+		}
+		var err error
+		if id := c.Query("id"); id != "" {
+			query.EntityId, err = strconv.Atoi(id)
+			if err != nil {
+				web.Error(c, domain.Invalid("id", "invalid id"))
+				return
+			}
+		}
+		if limit := c.Query("limit"); limit != "" {
+			query.Limit, err = strconv.Atoi(limit)
+			if err != nil || query.Limit < 1 {
+				web.Error(c, domain.Invalid("limit", "limit must be a positive number"))
+				return
+			}
+		}
+		if from := c.Query("from"); from != "" {
+			query.From, err = parseDate("from", from, h.loc)
+			if err != nil {
+				web.Error(c, err)
+				return
+			}
+		}
+		if to := c.Query("to"); to != "" {
+			query.To, err = parseEnd("to", to, h.loc)
+			if err != nil {
+				web.Error(c, err)
+				return
+			}
+		}
+		entries, err := h.s.Search(c.Request.Context(), query)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 200, entries)
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuditSearchValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := audit.NewService(audit.NewRepository(store.NewMemoryStoreAudit(store.NewMemoryDB()), discard), discard)
+	r := gin.New()
+	r.GET("/audit", NewAuditHandler(s, time.UTC, discard).Search())
+
+	for _, test := range []struct {
+		query  string
+		fields []string
+	}{
+		{"id=x", []string{"id"}},
+		{"limit=0", []string{"limit"}},
+		{"limit=many", []string{"limit"}},
+		{"from=yesterday", []string{"from"}},
+		{"to=2024-13-01", []string{"to"}},
+	} {
+		w := serve(r, "GET", "/audit?"+test.query, "")
+		p := decodeProblem(t, w)
+		var fields []string
+		for _, field := range p.Errors {
+			fields = append(fields, field.Field)
+		}
+		if w.Code != http.StatusBadRequest || p.Code != "validation_failed" || !slices.Equal(fields, test.fields) {
+			t.Errorf("GET /audit?%s: got %d %q with fields %v, want 400 validation_failed with %v", test.query, w.Code, p.Code, fields, test.fields)
+		}
+	}
+
+	w := serve(r, "GET", "/audit?entity=patient&id=3&limit=10&from=2024-03-01&to=2024-03-31", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /audit with a valid query: got %d %s, want 200", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/auth"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/middleware"
+	"github.com/JulietaAlfie/backendGo.git/pkg/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+type authHandler struct {
+	s   auth.Service
+	log *slog.Logger
+}
+
+func NewAuthHandler(s auth.Service, log *slog.Logger) *authHandler {
+	return &authHandler{
+		s:   s,
+		log: log,
+	}
+}
+
+type credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// userRequest is the body of a user creation; dentists are linked to their
+// dentist and patients to their patient.
+type userRequest struct {
+	Username  string      `json:"username"`
+	Password  string      `json:"password"`
+	Role      domain.Role `json:"role"`
+	DentistId int         `json:"dentist_id"`
+	PatientId int         `json:"patient_id"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Login godoc
+// @Summary Login
+// @Tags Auth
+// @Description exchange a username and a password for an access and a refresh token
+// @Accept  json
+// @Produce  json
+// @Param credentials body credentials true "Credentials"
+// @Success 200 {object} web.response
+// @Failure 401 {object} web.errorResponse
+// @Router /auth/login [post]
+func (h *authHandler) Login() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req credentials
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+			web.Failure(c, 400, errors.New("invalid json"))
+			return
+		}
+		tokens, err := h.s.Login(c.Request.Context(), req.Username, req.Password)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			web.Failure(c, 401, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, 500, errors.New("login failed"))
+			return
+		}
+		web.Success(c, 200, tokens)
+	}
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Tags Auth
+// @Description exchange a refresh token, which is revoked, for a new pair of tokens
+// @Accept  json
+// @Produce  json
+// @Param refresh body refreshRequest true "Refresh token"
+// @Success 200 {object} web.response
+// @Failure 401 {object} web.errorResponse
+// @Router /auth/refresh [post]
+func (h *authHandler) Refresh() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req refreshRequest
+		err := c.ShouldBindJSON(&req)
+		if err != nil || req.RefreshToken == "" {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+			web.Failure(c, 400, errors.New("invalid json"))
+			return
+		}
+		tokens, err := h.s.Refresh(c.Request.Context(), req.RefreshToken)
+		if errors.Is(err, auth.ErrInvalidToken) {
+			web.Failure(c, 401, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, 500, errors.New("refresh failed"))
+			return
+		}
+		web.Success(c, 200, tokens)
+	}
+}
+
+// Logout godoc
+// @Summary Logout
+// @Tags Auth
+// @Description revoke the access token of the request and, if given, its refresh token
+// @Accept  json
+// @Security BearerAuth
+// @Param refresh body refreshRequest false "Refresh token"
+// @Success 204
+// @Failure 401 {object} web.errorResponse
+// @Router /auth/logout [post]
+func (h *authHandler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, _ := middleware.CurrentPrincipal(c)
+		var req refreshRequest
+		if c.Request.ContentLength != 0 {
+			err := c.ShouldBindJSON(&req)
+			if err != nil {
+				h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+				web.Failure(c, 400, errors.New("invalid json"))
+				return
+			}
+		}
+		err := h.s.Logout(c.Request.Context(), principal, req.RefreshToken)
+		if errors.Is(err, auth.ErrInvalidToken) {
+			web.Failure(c, 400, err)
+			return
+		}
+		if err != nil {
+			web.Failure(c, 500, errors.New("logout failed"))
+			return
+		}
+		web.Success(c, 204, nil)
+	}
+}
+
+// CreateUser godoc
+// @Summary Create user
+// @Tags Auth
+// @Description create a user account with a role: admin, receptionist, dentist or patient
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param user body userRequest true "User to create"
+// @Success 201 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 403 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Failure 409 {object} web.errorResponse
+// @Router /users [post]
+func (h *authHandler) CreateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req userRequest
+		err := c.ShouldBindJSON(&req)
+		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+			web.Failure(c, 400, errors.New("invalid json"))
+			return
+		}
+		user := domain.User{
+			Username:  req.Username,
+			Role:      req.Role,
+			DentistId: req.DentistId,
+			PatientId: req.PatientId,
+		}
+		user, err = h.s.CreateUser(c.Request.Context(), user, req.Password)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 201, user)
+	}
+}
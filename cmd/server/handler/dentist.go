@@ -2,7 +2,7 @@ package handler
 
 import (
 	"errors"
-	"os"
+	"log/slog"
 	"strconv"
 
 	"github.com/JulietaAlfie/backendGo.git/internal/dentist"
@@ -13,41 +13,57 @@ import (
 )
 
 type dentistHandler struct {
-	s dentist.Service
+	s   dentist.Service
+	log *slog.Logger
 }
 
-func NewDentistHandler(s dentist.Service) *dentistHandler {
+func NewDentistHandler(s dentist.Service, log *slog.Logger) *dentistHandler {
 	return &dentistHandler{
-		s: s,
+		s:   s,
+		log: log,
 	}
 }
+
+// dentistRequest is the body of the dentist writes. Its fields are checked by
+// the service, not on binding, to report every invalid one at once.
+type dentistRequest struct {
+	Lastname string `json:"lastname,omitempty"`
+	Name     string `json:"name,omitempty"`
+	License  string `json:"license,omitempty"`
+}
+
+func (r dentistRequest) toDentist() domain.Dentist {
+	return domain.Dentist{
+		Lastname: r.Lastname,
+		Name:     r.Name,
+		License:  r.License,
+	}
+}
+
 // StoreDentist godoc
 // @Summary Store dentist
 // @Tags Dentists
 // @Description store dentist
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param dentist body domain.Dentist true "Dentist to store"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.response
 // @Router /dentists [post]
 func (h *dentistHandler) Post() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var dentist domain.Dentist
-		err := c.ShouldBindJSON(&dentist)
+		var req dentistRequest
+		err := c.ShouldBindJSON(&req)
 		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		valid, err := validateEmptysDentistFields(&dentist)
-		if !valid {
-			web.Failure(c, 400, err)
-			return
-		}
-		dent, err := h.s.Create(dentist)
+		dent, err := h.s.Create(c.Request.Context(), req.toDentist())
 		if err != nil {
-			web.Failure(c, 400, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 201, dent)
@@ -56,19 +72,30 @@ func (h *dentistHandler) Post() gin.HandlerFunc {
 // ListDentists godoc
 // @Summary List dentists
 // @Tags Dentists
-// @Description get dentists
+// @Description get a page of dentists, sorted by id unless told otherwise
 // @Produce  json
+// @Param lastname query string false "Start of the lastname, ignoring case"
+// @Param sort query string false "id, lastname, name or license; after a - to sort descending"
+// @Param limit query int false "Most dentists to return, 50 by default and 500 at most"
+// @Param offset query int false "Dentists to skip"
 // @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
 // @Failure 422 {object} web.errorResponse
 // @Router /dentists [get]
 func (h *dentistHandler) GetAll() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		dentists, err := h.s.GetAll()
+		page, err := parsePage(c, domain.DentistSortKeys)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		query := domain.DentistQuery{Lastname: c.Query("lastname"), Page: page}
+		dentists, total, err := h.s.Search(c.Request.Context(), query)
 		if err != nil {
-			web.Failure(c, 422, errors.New("dentists could not be brought"))
+			web.Error(c, err)
 			return
 		}
-		web.Success(c, 200, dentists)
+		web.Paginated(c, dentists, total, page.Limit, page.Offset)
 	}
 }
 // Dentist godoc
@@ -85,12 +112,12 @@ func (h *dentistHandler) GetByID() gin.HandlerFunc {
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		dentist, err := h.s.GetByID(id)
+		dentist, err := h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("dentist not found"))
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, dentist)
@@ -102,7 +129,8 @@ func (h *dentistHandler) GetByID() gin.HandlerFunc {
 // @Description modify dentist
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param dentist body domain.Dentist true "Dentist to store"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.response
@@ -111,44 +139,34 @@ func (h *dentistHandler) GetByID() gin.HandlerFunc {
 // @Router /dentists/{id} [put]
 func (h *dentistHandler) Put() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
-			return
-		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
-			return
-		}
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		_, err = h.s.GetByID(id)
+		_, err = h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("dentist not found"))
+			web.Error(c, err)
 			return
 		}
+		var req dentistRequest
+		err = c.ShouldBindJSON(&req)
 		if err != nil {
-			web.Failure(c, 409, err)
-			return
-		}
-		var dentist domain.Dentist
-		err = c.ShouldBindJSON(&dentist)
-		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		valid, err := validateEmptysDentistFields(&dentist)
-		if !valid {
-			web.Failure(c, 400, err)
+		// A replacement must be a whole dentist on its own.
+		dentist := req.toDentist()
+		err = dentist.Validate()
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
-		dent, err := h.s.Update(id, dentist)
+		dent, err := h.s.Update(c.Request.Context(), id, dentist)
 		if err != nil {
-			web.Failure(c, 409, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, dent)
@@ -160,50 +178,33 @@ func (h *dentistHandler) Put() gin.HandlerFunc {
 // @Description modify dentist
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param dentist body domain.Dentist true "Dentist to store"
 // @Success 200 {object} web.response
 // @Router /dentists/{id} [patch]
 func (h *dentistHandler) Patch() gin.HandlerFunc {
-	type Request struct {
-		Lastname string `json:"lastname,omitempty"`
-		Name     string `json:"name,omitempty"`
-		License  string `json:"license,omitempty"`
-	}
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
-			return
-		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
-			return
-		}
-		var req Request
+		var req dentistRequest
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		_, err = h.s.GetByID(id)
+		_, err = h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("dentist not found"))
+			web.Error(c, err)
 			return
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		update := domain.Dentist{
-			Lastname: req.Lastname,
-			Name:     req.Name,
-			License:  req.License,
-		}
-		dent, err := h.s.Update(id, update)
+		dent, err := h.s.Update(c.Request.Context(), id, req.toDentist())
 		if err != nil {
-			web.Failure(c, 409, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, dent)
@@ -212,8 +213,9 @@ func (h *dentistHandler) Patch() gin.HandlerFunc {
 // DeleteDentist godoc
 // @Summary Delete dentist
 // @Tags Dentists
-// @Description delete dentist
-// @Param token header string true "token"
+// @Description delete dentist and cancel their appointments; admins can restore or purge it
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Dentist ID"
 // @Success 204 {object} web.response
 // @Failure 400 {object} web.response
@@ -222,38 +224,151 @@ func (h *dentistHandler) Patch() gin.HandlerFunc {
 // @Router /dentists/{id} [delete]
 func (h *dentistHandler) Delete() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		err = h.s.Delete(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 204, nil)
+	}
+}
+
+// RestoreDentist godoc
+// @Summary Restore dentist
+// @Tags Dentists
+// @Description bring back a deleted dentist and the appointments cancelled with it, but for those whose slot was booked meanwhile or whose other participant is deleted
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Dentist ID"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.response
+// @Failure 401 {object} web.response
+// @Failure 404 {object} web.errorResponse
+// @Router /dentists/{id}/restore [post]
+func (h *dentistHandler) Restore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
+		restored, err := h.s.Restore(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
+		web.Success(c, 200, restored)
+	}
+}
+
+// PurgeDentist godoc
+// @Summary Purge dentist
+// @Tags Dentists
+// @Description remove a deleted dentist, its schedule and its appointments for good
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Dentist ID"
+// @Success 204
+// @Failure 400 {object} web.response
+// @Failure 401 {object} web.response
+// @Failure 403 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /dentists/{id}/purge [delete]
+func (h *dentistHandler) Purge() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		err = h.s.Purge(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 204, nil)
+	}
+}
+
+// DentistSchedule godoc
+// @Summary Dentist schedule
+// @Tags Dentists
+// @Description get the weekly working hours of a dentist
+// @Produce  json
+// @Param id path int true "Dentist ID"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /dentists/{id}/schedule [get]
+func (h *dentistHandler) GetSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		err = h.s.Delete(id)
+		_, err = h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, err)
+			web.Error(c, err)
 			return
 		}
-		web.Success(c, 204, nil)
+		schedule, err := h.s.GetSchedule(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 200, schedule)
 	}
 }
 
-func validateEmptysDentistFields(dentist *domain.Dentist) (bool, error) {
-	switch {
-	case dentist.Lastname == "":
-		return false, errors.New("lastname was empty")
-	case dentist.Name == "":
-		return false, errors.New("name was empty")
-	case dentist.License == "":
-		return false, errors.New("license was empty")
+// ModifyDentistSchedule godoc
+// @Summary Modify dentist schedule
+// @Tags Dentists
+// @Description replace the weekly working hours of a dentist
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Dentist ID"
+// @Param schedule body domain.Schedule true "Working hours"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 401 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /dentists/{id}/schedule [put]
+func (h *dentistHandler) PutSchedule() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		_, err = h.s.GetByID(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		var schedule domain.Schedule
+		err = c.ShouldBindJSON(&schedule)
+		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+			web.Failure(c, 400, errors.New("invalid json"))
+			return
+		}
+		schedule, err = h.s.UpdateSchedule(c.Request.Context(), id, schedule)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 200, schedule)
 	}
-	return true, nil
 }
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/internal/dentist"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/gin-gonic/gin"
+)
+
+var discard = slog.New(slog.DiscardHandler)
+
+// problem is the part of the failures the tests check.
+type problem struct {
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Errors []struct {
+		Field string `json:"field"`
+	} `json:"errors"`
+}
+
+// serve sends the request to the router and returns the recorded response.
+func serve(r http.Handler, method string, path string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) problem {
+	t.Helper()
+	var p problem
+	err := json.Unmarshal(w.Body.Bytes(), &p)
+	if err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	return p
+}
+
+func newDentistRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	db := store.NewMemoryDB()
+	auditor := audit.NewService(audit.NewRepository(store.NewMemoryStoreAudit(db), discard), discard)
+	h := NewDentistHandler(dentist.NewService(dentist.NewRepository(store.NewMemoryStoreDentist(db), discard), auditor, discard), discard)
+	r := gin.New()
+	r.POST("/dentists", h.Post())
+	r.PUT("/dentists/:id", h.Put())
+	r.PATCH("/dentists/:id", h.Patch())
+	return r
+}
+
+func TestDentistLicenseUpdates(t *testing.T) {
+	r := newDentistRouter()
+	for _, license := range []string{"0001-1111", "0002-2222"} {
+		w := serve(r, "POST", "/dentists", `{"name":"Ana","lastname":"Perez","license":"`+license+`"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("creating dentist %s: got %d %s", license, w.Code, w.Body)
+		}
+	}
+
+	for _, test := range []struct {
+		name   string
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"patch to a fresh license", "PATCH", `{"license":"0003-3333"}`, http.StatusOK, ""},
+		{"put to a fresh license", "PUT", `{"name":"Ana","lastname":"Perez","license":"0004-4444"}`, http.StatusOK, ""},
+		{"patch to its own license", "PATCH", `{"license":"0004-4444"}`, http.StatusOK, ""},
+		{"patch to a taken license", "PATCH", `{"license":"0002-2222"}`, http.StatusConflict, "duplicate_license"},
+		{"put to a taken license", "PUT", `{"name":"Ana","lastname":"Perez","license":"0002-2222"}`, http.StatusConflict, "duplicate_license"},
+	} {
+		w := serve(r, test.method, "/dentists/1", test.body)
+		if w.Code != test.status {
+			t.Errorf("%s: got status %d %s, want %d", test.name, w.Code, w.Body, test.status)
+			continue
+		}
+		if test.code != "" {
+			if p := decodeProblem(t, w); p.Code != test.code {
+				t.Errorf("%s: got code %q, want %q", test.name, p.Code, test.code)
+			}
+		}
+	}
+}
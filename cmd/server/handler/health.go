@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+type healthHandler struct {
+	readiness *health.Checker
+}
+
+// NewHealthHandler builds the probes; readiness runs the given checks.
+func NewHealthHandler(readiness *health.Checker) *healthHandler {
+	return &healthHandler{
+		readiness: readiness,
+	}
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Tags Health
+// @Description reports that the process is up, without checking its dependencies
+// @Produce  json
+// @Success 200 {object} health.Report
+// @Router /healthz [get]
+func (h *healthHandler) Live() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, health.Report{Status: health.StatusUp, Checks: map[string]health.Result{}})
+	}
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Tags Health
+// @Description checks the database, its migrations and the configuration
+// @Produce  json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *healthHandler) Ready() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := h.readiness.Run(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
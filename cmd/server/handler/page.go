@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// parsePage reads the limit, offset and sort query parameters of a list that
+// can be sorted by keys. Sort is one of the keys, after a "-" to sort
+// descending; lists without keys keep their own order.
+func parsePage(c *gin.Context, keys []string) (domain.Page, error) {
+	var page domain.Page
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		page.Limit, err = strconv.Atoi(limit)
+		if err != nil || page.Limit < 1 {
+			return domain.Page{}, domain.Invalid("limit", "limit must be a positive number")
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		page.Offset, err = strconv.Atoi(offset)
+		if err != nil || page.Offset < 0 {
+			return domain.Page{}, domain.Invalid("offset", "offset must be zero or a positive number")
+		}
+	}
+	if sort := c.Query("sort"); sort != "" {
+		if len(keys) == 0 {
+			return domain.Page{}, domain.Invalid("sort", "the list cannot be sorted")
+		}
+		page.Sort, page.Desc = strings.CutPrefix(sort, "-")
+		if !slices.Contains(keys, page.Sort) {
+			return domain.Page{}, domain.Invalid("sort", fmt.Sprintf("sort must be one of %s, after a - to sort descending", strings.Join(keys, ", ")))
+		}
+	}
+	return page.Clamp(), nil
+}
+
+// queryId reads an optional id query parameter, 0 when missing.
+func queryId(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return 0, domain.Invalid(name, "invalid "+name)
+	}
+	return id, nil
+}
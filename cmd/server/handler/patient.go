@@ -2,8 +2,10 @@ package handler
 
 import (
 	"errors"
-	"os"
+	"log/slog"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 	"github.com/JulietaAlfie/backendGo.git/internal/patient"
@@ -13,69 +15,154 @@ import (
 )
 
 type patientHandler struct {
-	s patient.Service
+	s   patient.Service
+	loc *time.Location
+	log *slog.Logger
 }
 
-func NewPatientHandler(s patient.Service) *patientHandler {
+// NewPatientHandler builds the patient handlers; dates are read in loc, the
+// clinic timezone.
+func NewPatientHandler(s patient.Service, loc *time.Location, log *slog.Logger) *patientHandler {
 	return &patientHandler{
-		s: s,
+		s:   s,
+		loc: loc,
+		log: log,
 	}
 }
+
+// patientRequest is the body of the patient writes, with discharge_date as an
+// ISO-8601 date.
+type patientRequest struct {
+	Lastname      string `json:"lastname,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Residence     string `json:"residence,omitempty"`
+	DNI           int    `json:"dni,omitempty"`
+	DischargeDate string `json:"discharge_date,omitempty"`
+}
+
+func (r patientRequest) toPatient(loc *time.Location) (domain.Patient, error) {
+	patient := domain.Patient{
+		Lastname:  r.Lastname,
+		Name:      r.Name,
+		Residence: r.Residence,
+		DNI:       r.DNI,
+	}
+	if r.DischargeDate != "" {
+		date, err := parseDate("discharge_date", r.DischargeDate, loc)
+		if err != nil {
+			return domain.Patient{}, err
+		}
+		patient.DischargeDate = date
+	}
+	return patient, nil
+}
 // StorePatient godoc
 // @Summary Store patient
 // @Tags Patients
 // @Description store patient
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param patient body domain.Patient true "Patient to store"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.response
 // @Router /patients [post]
 func (h *patientHandler) Post() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var patient domain.Patient
-		err := c.ShouldBindJSON(&patient)
+		var req patientRequest
+		err := c.ShouldBindJSON(&req)
 		if err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		valid, err := validateEmptysPatient(&patient)
-		if !valid {
+		patient, err := req.toPatient(h.loc)
+		if err != nil {
 			web.Failure(c, 400, err)
 			return
 		}
-		p, err := h.s.Create(patient)
+		p, err := h.s.Create(c.Request.Context(), patient)
 		if err != nil {
-			web.Failure(c, 400, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 201, p)
 	}
 }
 // ListPatients godoc
-// @Summary List patient
+// @Summary List patients
 // @Tags Patients
-// @Description get patient
+// @Description get a page of patients, sorted by id unless told otherwise
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Produce  json
+// @Param lastname query string false "Start of the lastname, ignoring case"
+// @Param sort query string false "id, lastname, name or dni; after a - to sort descending"
+// @Param limit query int false "Most patients to return, 50 by default and 500 at most"
+// @Param offset query int false "Patients to skip"
 // @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
 // @Failure 422 {object} web.errorResponse
 // @Router /patients [get]
 func (h *patientHandler) GetAll() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		patients, err := h.s.GetAll()
+		page, err := parsePage(c, domain.PatientSortKeys)
 		if err != nil {
-			web.Failure(c, 422, errors.New("patients could not be brought"))
+			web.Failure(c, 400, err)
 			return
 		}
-		web.Success(c, 200, patients)
+		query := domain.PatientQuery{Lastname: c.Query("lastname"), Page: page}
+		patients, total, err := h.s.Search(c.Request.Context(), query)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Paginated(c, patients, total, page.Limit, page.Offset)
 	}
 }
+// SearchPatients godoc
+// @Summary Search patients
+// @Tags Patients
+// @Description find the patients whose name, lastname, residence or dni match every word of q, ignoring case and accents and tolerating typos, the best matches first
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param q query string true "Words to search"
+// @Param limit query int false "Most patients to return, 50 by default and 500 at most"
+// @Param offset query int false "Patients to skip"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.errorResponse
+// @Failure 422 {object} web.errorResponse
+// @Router /patients/search [get]
+func (h *patientHandler) Search() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		text := strings.TrimSpace(c.Query("q"))
+		if text == "" {
+			web.Error(c, domain.Invalid("q", "q is required"))
+			return
+		}
+		page, err := parsePage(c, nil)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
+		}
+		patients, total, err := h.s.Find(c.Request.Context(), text, page)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Paginated(c, patients, total, page.Limit, page.Offset)
+	}
+}
+
 // Patient godoc
 // @Summary patient
 // @Tags Patients
 // @Description get patient
 // @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Patient ID"
 // @Success 200 {object} web.response
 // @Failure 404 {object} web.response
@@ -85,12 +172,12 @@ func (h *patientHandler) GetByID() gin.HandlerFunc {
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		patient, err := h.s.GetByID(id)
+		patient, err := h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("patient not found"))
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, patient)
@@ -103,7 +190,8 @@ func (h *patientHandler) GetByID() gin.HandlerFunc {
 // @Description modify patient
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param patient body domain.Patient true "Patient to store"
 // @Success 200 {object} web.response
 // @Failure 400 {object} web.response
@@ -112,44 +200,38 @@ func (h *patientHandler) GetByID() gin.HandlerFunc {
 // @Router /patients/{id} [put]
 func (h *patientHandler) Put() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
-			return
-		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
-			return
-		}
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		_, err = h.s.GetByID(id)
+		_, err = h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("patient not found"))
+			web.Error(c, err)
 			return
 		}
+		var req patientRequest
+		err = c.ShouldBindJSON(&req)
 		if err != nil {
-			web.Failure(c, 409, err)
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
+			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		var patient domain.Patient
-		err = c.ShouldBindJSON(&patient)
+		patient, err := req.toPatient(h.loc)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid json"))
+			web.Failure(c, 400, err)
 			return
 		}
-		valid, err := validateEmptysPatient(&patient)
-		if !valid {
-			web.Failure(c, 400, err)
+		// A replacement must be a whole patient on its own.
+		err = patient.Validate(time.Now())
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
-		pat, err := h.s.Update(id, patient)
+		pat, err := h.s.Update(c.Request.Context(), id, patient)
 		if err != nil {
-			web.Failure(c, 409, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, pat)
@@ -161,54 +243,38 @@ func (h *patientHandler) Put() gin.HandlerFunc {
 // @Description modify patient
 // @Accept  json
 // @Produce  json
-// @Param token header string true "token"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param patient body domain.Patient true "Patient to store"
 // @Success 200 {object} web.response
 // @Router /patients/{id} [patch]
 func (h *patientHandler) Patch() gin.HandlerFunc {
-	type Request struct {
-		Lastname      string `json:"lastname,omitempty"`
-		Name          string `json:"name,omitempty"`
-		Residence     string `json:"residence,omitempty"`
-		DNI           int    `json:"dni,omitempty"`
-		DischargeDate string `json:"discharge_date,omitempty"`
-	}
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
-			return
-		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
-			return
-		}
-		var req Request
+		var req patientRequest
 		idParam := c.Param("id")
 		id, err := strconv.Atoi(idParam)
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		_, err = h.s.GetByID(id)
+		_, err = h.s.GetByID(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, errors.New("patient not found"))
+			web.Error(c, err)
 			return
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
+			h.log.DebugContext(c.Request.Context(), "invalid json", "err", err)
 			web.Failure(c, 400, errors.New("invalid json"))
 			return
 		}
-		update := domain.Patient{
-			Lastname:      req.Lastname,
-			Name:          req.Name,
-			Residence:     req.Residence,
-			DNI:           req.DNI,
-			DischargeDate: req.DischargeDate,
+		update, err := req.toPatient(h.loc)
+		if err != nil {
+			web.Failure(c, 400, err)
+			return
 		}
-		pat, err := h.s.Update(id, update)
+		pat, err := h.s.Update(c.Request.Context(), id, update)
 		if err != nil {
-			web.Failure(c, 409, err)
+			web.Error(c, err)
 			return
 		}
 		web.Success(c, 200, pat)
@@ -218,8 +284,9 @@ func (h *patientHandler) Patch() gin.HandlerFunc {
 // DeletePatient godoc
 // @Summary Delete patient
 // @Tags Patients
-// @Description delete patient
-// @Param token header string true "token"
+// @Description delete patient and cancel their appointments; admins can restore or purge it
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Patient ID"
 // @Success 204 {object} web.response
 // @Failure 400 {object} web.response
@@ -228,42 +295,75 @@ func (h *patientHandler) Patch() gin.HandlerFunc {
 // @Router /patients/{id} [delete]
 func (h *patientHandler) Delete() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := c.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(c, 401, errors.New("token not found"))
+		idParam := c.Param("id")
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		if token != os.Getenv("TOKEN") {
-			web.Failure(c, 401, errors.New("invalid token"))
+		err = h.s.Delete(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
 			return
 		}
-		idParam := c.Param("id")
-		id, err := strconv.Atoi(idParam)
+		web.Success(c, 204, nil)
+	}
+}
+
+// RestorePatient godoc
+// @Summary Restore patient
+// @Tags Patients
+// @Description bring back a deleted patient and the appointments cancelled with it, but for those whose slot was booked meanwhile or whose other participant is deleted
+// @Produce  json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Patient ID"
+// @Success 200 {object} web.response
+// @Failure 400 {object} web.response
+// @Failure 401 {object} web.response
+// @Failure 404 {object} web.errorResponse
+// @Router /patients/{id}/restore [post]
+func (h *patientHandler) Restore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
-			web.Failure(c, 400, errors.New("invalid id"))
+			web.Error(c, domain.Invalid("id", "invalid id"))
 			return
 		}
-		err = h.s.Delete(id)
+		restored, err := h.s.Restore(c.Request.Context(), id)
 		if err != nil {
-			web.Failure(c, 404, err)
+			web.Error(c, err)
 			return
 		}
-		web.Success(c, 204, nil)
+		web.Success(c, 200, restored)
 	}
 }
 
-func validateEmptysPatient(patient *domain.Patient) (bool, error) {
-	switch {
-	case patient.Lastname == "":
-		return false, errors.New("lastname was empty")
-	case patient.Name == "":
-		return false, errors.New("name was empty")
-	case patient.Residence == "":
-		return false, errors.New("residence was empty")
-	case patient.DNI == 0:
-		return false, errors.New("dni was empty")
-	case patient.DischargeDate == "":
-		return false, errors.New("discharge_date was empty")
+// PurgePatient godoc
+// @Summary Purge patient
+// @Tags Patients
+// @Description remove a deleted patient and its appointments for good
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Patient ID"
+// @Success 204
+// @Failure 400 {object} web.response
+// @Failure 401 {object} web.response
+// @Failure 403 {object} web.errorResponse
+// @Failure 404 {object} web.errorResponse
+// @Router /patients/{id}/purge [delete]
+func (h *patientHandler) Purge() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			web.Error(c, domain.Invalid("id", "invalid id"))
+			return
+		}
+		err = h.s.Purge(c.Request.Context(), id)
+		if err != nil {
+			web.Error(c, err)
+			return
+		}
+		web.Success(c, 204, nil)
 	}
-	return true, nil
 }
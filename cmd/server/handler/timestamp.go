@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+// localLayouts are the ISO-8601 forms accepted without a UTC offset; they are
+// read in the clinic timezone.
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// parseTimestamp strictly parses an ISO-8601 timestamp such as
+// "2020-03-20T15:30:00-03:00" and returns it in the clinic timezone.
+func parseTimestamp(field string, value string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t.In(loc), nil
+	}
+	for _, layout := range localLayouts {
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, domain.Invalid(field, fmt.Sprintf("%s must be an ISO-8601 timestamp such as 2020-03-20T15:30:00-03:00", field))
+}
+
+// parseDate accepts an ISO-8601 calendar date such as "2020-03-20", taken as
+// midnight in the clinic timezone, or a full timestamp.
+func parseDate(field string, value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(domain.DateLayout, value, loc)
+	if err == nil {
+		return t, nil
+	}
+	t, err = parseTimestamp(field, value, loc)
+	if err != nil {
+		return time.Time{}, domain.Invalid(field, fmt.Sprintf("%s must be an ISO-8601 date such as 2020-03-20", field))
+	}
+	return t, nil
+}
+
+// parseEnd reads the end of a search: a date includes the whole day, a
+// timestamp is excluded.
+func parseEnd(field string, value string, loc *time.Location) (time.Time, error) {
+	day, err := time.ParseInLocation(domain.DateLayout, value, loc)
+	if err == nil {
+		return day.AddDate(0, 0, 1), nil
+	}
+	t, err := parseTimestamp(field, value, loc)
+	if err != nil {
+		return time.Time{}, domain.Invalid(field, fmt.Sprintf("%s must be an ISO-8601 date such as 2020-03-20 or a timestamp", field))
+	}
+	return t, nil
+}
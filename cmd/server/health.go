@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/config"
+	"github.com/JulietaAlfie/backendGo.git/pkg/health"
+	"github.com/JulietaAlfie/backendGo.git/pkg/migrate"
+)
+
+// checkTimeout bounds each readiness check.
+const checkTimeout = 2 * time.Second
+
+// readiness checks that the database answers, that its schema is at the
+// version of the embedded migrations and that the configuration is valid.
+func readiness(cfg config.Config, db *sql.DB) (*health.Checker, error) {
+	migrator, err := migrate.New(db, cfg.Database.Driver)
+	if err != nil {
+		return nil, err
+	}
+	checker := health.New(checkTimeout)
+	checker.Add("database", db.PingContext)
+	checker.Add("migrations", func(ctx context.Context) error {
+		version, err := migrator.AppliedVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version != migrator.Latest() {
+			return fmt.Errorf("schema at version %d, expected %d", version, migrator.Latest())
+		}
+		return nil
+	})
+	checker.Add("config", func(ctx context.Context) error {
+		c := cfg
+		return c.Validate()
+	})
+	return checker, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/cmd/server/handler"
+	"github.com/JulietaAlfie/backendGo.git/pkg/config"
+	"github.com/JulietaAlfie/backendGo.git/pkg/health"
+	"github.com/JulietaAlfie/backendGo.git/pkg/migrate"
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, test := range []struct {
+		name string
+		// prepare leaves the database and the config as the case needs them.
+		prepare func(t *testing.T, db *sql.DB, migrator *migrate.Migrator, cfg *config.Config)
+		down    map[string]string // checks down, with a part of their error
+	}{
+		{"ready", func(t *testing.T, db *sql.DB, migrator *migrate.Migrator, cfg *config.Config) {}, nil},
+		{"database down", func(t *testing.T, db *sql.DB, migrator *migrate.Migrator, cfg *config.Config) {
+			db.Close()
+		}, map[string]string{"database": "closed", "migrations": "closed"}},
+		{"pending migration", func(t *testing.T, db *sql.DB, migrator *migrate.Migrator, cfg *config.Config) {
+			_, err := migrator.Down(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}, map[string]string{"migrations": "expected"}},
+		{"never migrated", func(t *testing.T, db *sql.DB, migrator *migrate.Migrator, cfg *config.Config) {
+			_, err := migrator.Down(migrator.Latest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = db.Exec("drop table schema_migrations")
+			if err != nil {
+				t.Fatal(err)
+			}
+		}, map[string]string{"migrations": "schema version"}},
+		{"invalid config", func(t *testing.T, db *sql.DB, migrator *migrate.Migrator, cfg *config.Config) {
+			cfg.Log.Level = "loud"
+		}, map[string]string{"config": "log level"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Auth.Secret = "0123456789abcdef0123456789abcdef"
+			cfg.Database.Driver = "sqlite"
+			cfg.Database.DSN = filepath.Join(t.TempDir(), "clinic.db")
+			err := cfg.Validate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			db, err := openDatabase(cfg.Database, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			migrator, err := migrate.New(db, "sqlite")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = migrator.Up()
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.prepare(t, db, migrator, &cfg)
+
+			checker, err := readiness(cfg, db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := gin.New()
+			r.GET("/readyz", handler.NewHealthHandler(checker).Ready())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+			var report health.Report
+			err = json.Unmarshal(w.Body.Bytes(), &report)
+			if err != nil {
+				t.Fatalf("decoding %s: %v", w.Body, err)
+			}
+			wantStatus, wantReport := http.StatusOK, health.StatusUp
+			if len(test.down) > 0 {
+				wantStatus, wantReport = http.StatusServiceUnavailable, health.StatusDown
+			}
+			if w.Code != wantStatus || report.Status != wantReport {
+				t.Errorf("got %d %s, want %d %s", w.Code, w.Body, wantStatus, wantReport)
+			}
+			for _, name := range []string{"database", "migrations", "config"} {
+				result, ok := report.Checks[name]
+				want, down := test.down[name]
+				switch {
+				case !ok:
+					t.Errorf("check %s: missing from %s", name, w.Body)
+				case down && (result.Status != health.StatusDown || !strings.Contains(result.Error, want)):
+					t.Errorf("check %s: got %+v, want down with %q", name, result, want)
+				case !down && result.Status != health.StatusUp:
+					t.Errorf("check %s: got %+v, want up", name, result)
+				}
+			}
+		})
+	}
+}
@@ -1,21 +1,28 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	_ "time/tzdata"
 
 	"github.com/JulietaAlfie/backendGo.git/cmd/server/handler"
 	"github.com/JulietaAlfie/backendGo.git/docs"
+	"github.com/JulietaAlfie/backendGo.git/internal/apikey"
 	"github.com/JulietaAlfie/backendGo.git/internal/appointment"
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/internal/auth"
 	"github.com/JulietaAlfie/backendGo.git/internal/dentist"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 	"github.com/JulietaAlfie/backendGo.git/internal/patient"
+	"github.com/JulietaAlfie/backendGo.git/pkg/config"
+	"github.com/JulietaAlfie/backendGo.git/pkg/logging"
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
 	"github.com/JulietaAlfie/backendGo.git/pkg/middleware"
-	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/JulietaAlfie/backendGo.git/pkg/tracing"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-
-	_ "github.com/go-sql-driver/mysql"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -27,74 +34,189 @@ import (
 
 // @license.name Apache 2.0
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer " followed by the access token of /auth/login.
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description An API key created with POST /api-keys.
 func main() {
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
 
-	if err := godotenv.Load("../../.env"); err != nil {
-		panic("Error loading .env file: " + err.Error())
+	shutdownTracing, err := tracing.Setup(context.Background(), "clinic", cfg.Tracing.Exporter, cfg.Tracing.Endpoint, cfg.Tracing.SampleRatio)
+	if err != nil {
+		fatal(logger, "setting up tracing", err)
 	}
+	defer shutdownTracing(context.Background())
 
-	dataSource := "root:root@tcp(localhost:3306)/my_db"
-	storageDB, err := sql.Open("mysql", dataSource)
+	storageDB, err := openDatabase(cfg.Database, cfg.Clinic.Location)
 	if err != nil {
-		log.Fatal(err)
+		fatal(logger, "opening database", err)
+	}
+	if err := waitForDatabase(storageDB, cfg.Database.ConnectRetries, cfg.Database.ConnectBackoff, logger); err != nil {
+		fatal(logger, "connecting to database", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		err := runMigrations(storageDB, cfg.Database.Driver, args[1:])
+		storageDB.Close()
+		if err != nil {
+			fatal(logger, "migrating", err)
+		}
+		return
 	}
+	if cfg.Database.MigrateOnStart {
+		if err := migrateOnStart(storageDB, cfg.Database.Driver, logger); err != nil {
+			fatal(logger, "migrating", err)
+		}
+	}
+
+	storageDentist, storagePatient, storageAppointment := newStores(cfg.Database.Driver, storageDB, logger)
+
+	repositoryAudit := audit.NewRepository(newAuditStore(cfg.Database.Driver, storageDB, logger), logger)
+	serviceAudit := audit.NewService(repositoryAudit, logger)
+	auditHandler := handler.NewAuditHandler(serviceAudit, cfg.Clinic.Location, logger)
+
+	repositoryDentist := dentist.NewRepository(storageDentist, logger)
+	serviceDentist := dentist.NewService(repositoryDentist, serviceAudit, logger)
+	dentistHandler := handler.NewDentistHandler(serviceDentist, logger)
 
-	storageDentist := store.NewSqlStoreDentist(storageDB)
-	repositoryDentist := dentist.NewRepository(storageDentist)
-	serviceDentist := dentist.NewService(repositoryDentist)
-	dentistHandler := handler.NewDentistHandler(serviceDentist)
+	repositoryPatient := patient.NewRepository(storagePatient, logger)
+	servicePatient := patient.NewService(repositoryPatient, serviceAudit, logger)
+	patientHandler := handler.NewPatientHandler(servicePatient, cfg.Clinic.Location, logger)
 
-	storagePatient := store.NewSqlStorePatient(storageDB)
-	repositoryPatient := patient.NewRepository(storagePatient)
-	servicePatient := patient.NewService(repositoryPatient)
-	patientHandler := handler.NewPatientHandler(servicePatient)
+	repositoryAppointment := appointment.NewRepository(storageAppointment, logger)
+	serviceAppointment := appointment.NewService(repositoryAppointment, repositoryDentist, repositoryPatient, serviceAudit, logger)
+	appointmentHandler := handler.NewAppointmentHandler(serviceAppointment, cfg.Clinic.Location, logger)
 
-	storageAppointment := store.NewSqlStoreAppointment(storageDB)
-	repositoryAppointment := appointment.NewRepository(storageAppointment)
-	serviceAppointment := appointment.NewService(repositoryAppointment)
-	appointmentHandler := handler.NewAppointmentHandler(serviceAppointment)
+	storageUser, storageToken, storageAPIKey := newAuthStores(cfg.Database.Driver, storageDB, logger)
+	repositoryAuth := auth.NewRepository(storageUser, storageToken, logger)
+	serviceAuth := auth.NewService(repositoryAuth, repositoryDentist, repositoryPatient, cfg.Auth.Secret, cfg.Auth.AccessTTL, cfg.Auth.RefreshTTL, logger)
+	authHandler := handler.NewAuthHandler(serviceAuth, logger)
 
+	repositoryAPIKey := apikey.NewRepository(storageAPIKey, logger)
+	serviceAPIKey := apikey.NewService(repositoryAPIKey, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(serviceAPIKey, cfg.Clinic.Location, logger)
+	if cfg.Auth.AdminPassword != "" {
+		if err := serviceAuth.EnsureAdmin(context.Background(), cfg.Auth.AdminUsername, cfg.Auth.AdminPassword); err != nil {
+			fatal(logger, "creating admin user", err)
+		}
+	}
+
+	readinessChecks, err := readiness(cfg, storageDB)
+	if err != nil {
+		fatal(logger, "loading migrations", err)
+	}
+	healthHandler := handler.NewHealthHandler(readinessChecks)
+	if err := metrics.RegisterDB(storageDB, cfg.Database.Driver); err != nil {
+		fatal(logger, "registering metrics", err)
+	}
+
+	gin.SetMode(cfg.Server.Mode)
 	r := gin.New()
-	r.Use(gin.Recovery(), middleware.Logger(), middleware.AllowAll())
-	  
-	docs.SwaggerInfo.Host = os.Getenv("HOST")
+	r.Use(middleware.Tracing(), middleware.RequestID(), middleware.Logger(logger), middleware.Metrics(), gin.Recovery(), middleware.AllowAll(), middleware.Timeout(cfg.Server.RequestTimeout))
+	authentication := middleware.Authentication(serviceAuth, serviceAPIKey)
+	writeDentists := middleware.Authorize(domain.WriteDentists)
+	readPatients := middleware.Authorize(domain.ReadPatients)
+	writePatients := middleware.Authorize(domain.WritePatients)
+	readAppointments := middleware.Authorize(domain.ReadAppointments)
+	writeAppointments := middleware.Authorize(domain.WriteAppointments)
+	annotateAppointments := middleware.Authorize(domain.WriteAppointments, domain.AnnotateAppointments)
+	purgeRecords := middleware.Authorize(domain.PurgeRecords)
+
+	docs.SwaggerInfo.Host = cfg.Server.Host
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/healthz", healthHandler.Live())
+	r.GET("/readyz", healthHandler.Ready())
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	authGroup := r.Group("/auth")
+	{
+		authGroup.POST("login", authHandler.Login())
+		authGroup.POST("refresh", authHandler.Refresh())
+		authGroup.POST("logout", authentication, authHandler.Logout())
+	}
+	r.POST("/users", authentication, middleware.Authorize(domain.ManageUsers), authHandler.CreateUser())
+
+	r.GET("/audit", authentication, middleware.Authorize(domain.ReadAudit), auditHandler.Search())
+
+	apiKeys := r.Group("/api-keys", authentication, middleware.Authorize(domain.ManageAPIKeys))
+	{
+		apiKeys.GET("", apiKeyHandler.GetAll())
+		apiKeys.POST("", apiKeyHandler.Post())
+		apiKeys.POST(":id/rotate", apiKeyHandler.Rotate())
+		apiKeys.DELETE(":id", apiKeyHandler.Delete())
+	}
 
 	dentists := r.Group("/dentists")
 	{
 		dentists.GET(":id", dentistHandler.GetByID())
 		dentists.GET("", dentistHandler.GetAll())
-		dentists.POST("", middleware.Authentication(), dentistHandler.Post())
-		dentists.DELETE(":id", middleware.Authentication(), dentistHandler.Delete())
-		dentists.PATCH(":id", middleware.Authentication(), dentistHandler.Patch())
-		dentists.PUT(":id", middleware.Authentication(), dentistHandler.Put())
+		dentists.POST("", authentication, writeDentists, dentistHandler.Post())
+		dentists.DELETE(":id", authentication, writeDentists, dentistHandler.Delete())
+		dentists.POST(":id/restore", authentication, writeDentists, dentistHandler.Restore())
+		dentists.DELETE(":id/purge", authentication, purgeRecords, dentistHandler.Purge())
+		dentists.PATCH(":id", authentication, writeDentists, dentistHandler.Patch())
+		dentists.PUT(":id", authentication, writeDentists, dentistHandler.Put())
+		dentists.GET(":id/schedule", dentistHandler.GetSchedule())
+		dentists.PUT(":id/schedule", authentication, writeDentists, dentistHandler.PutSchedule())
+		dentists.GET(":id/availability", appointmentHandler.GetAvailability())
+		dentists.GET(":id/appointments", authentication, readAppointments, appointmentHandler.GetAgenda())
 	}
 
-	patients := r.Group("/patients")
+	patients := r.Group("/patients", authentication)
 	{
-		patients.GET(":id", patientHandler.GetByID())
-		patients.GET("", patientHandler.GetAll())
-		patients.POST("", middleware.Authentication(), patientHandler.Post())
-		patients.DELETE(":id", middleware.Authentication(), patientHandler.Delete())
-		patients.PATCH(":id", middleware.Authentication(), patientHandler.Patch())
-		patients.PUT(":id", middleware.Authentication(), patientHandler.Put())
+		patients.GET(":id", readPatients, patientHandler.GetByID())
+		patients.GET("", readPatients, patientHandler.GetAll())
+		patients.GET("search", readPatients, patientHandler.Search())
+		patients.POST("", writePatients, patientHandler.Post())
+		patients.DELETE(":id", writePatients, patientHandler.Delete())
+		patients.POST(":id/restore", writePatients, patientHandler.Restore())
+		patients.DELETE(":id/purge", purgeRecords, patientHandler.Purge())
+		patients.PATCH(":id", writePatients, patientHandler.Patch())
+		patients.PUT(":id", writePatients, patientHandler.Put())
 	}
 
-	appointments := r.Group("/appointments")
+	appointments := r.Group("/appointments", authentication)
 	{
-		appointments.GET("", appointmentHandler.GetAll())
-		appointments.GET(":id", appointmentHandler.GetByID())
-		appointments.GET("/dni/:dni", appointmentHandler.GetByDni())
-		appointments.POST("", middleware.Authentication(), appointmentHandler.Post())
-		appointments.POST(":dni/:license", middleware.Authentication(), appointmentHandler.PostByDniAndLicence())
-		appointments.DELETE(":id", middleware.Authentication(), appointmentHandler.Delete())
-		appointments.PATCH(":id", middleware.Authentication(), appointmentHandler.Patch())
-		appointments.PUT(":id", middleware.Authentication(), appointmentHandler.Put())
+		appointments.GET("", readAppointments, appointmentHandler.GetAll())
+		appointments.GET(":id", readAppointments, appointmentHandler.GetByID())
+		appointments.GET("/dni/:dni", readAppointments, appointmentHandler.GetByDni())
+		appointments.POST("", writeAppointments, appointmentHandler.Post())
+		appointments.POST("/dni/:dni/:license", writeAppointments, appointmentHandler.PostByDniAndLicence())
+		appointments.POST(":id/restore", writeAppointments, appointmentHandler.Restore())
+		appointments.DELETE(":id", writeAppointments, appointmentHandler.Delete())
+		appointments.DELETE(":id/purge", purgeRecords, appointmentHandler.Purge())
+		appointments.PATCH(":id", annotateAppointments, appointmentHandler.Patch())
+		appointments.PUT(":id", writeAppointments, appointmentHandler.Put())
 	}
 
-	if err = r.Run(":8080"); err != nil {
-		log.Fatal(err)
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	if err := serve(srv, storageDB, cfg.Server.ShutdownTimeout, logger); err != nil {
+		fatal(logger, "serving", err)
 	}
 }
 
-
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err)
+	os.Exit(1)
+}
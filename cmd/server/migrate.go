@@ -0,0 +1,70 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/migrate"
+)
+
+const migrateUsage = "usage: server migrate up | down [steps] | status"
+
+// migrateOnStart applies every pending migration before the server starts and
+// logs how many ran, as the server writes only its JSON logs to stdout.
+func migrateOnStart(db *sql.DB, driver string, log *slog.Logger) error {
+	migrator, err := migrate.New(db, driver)
+	if err != nil {
+		return err
+	}
+	count, err := migrator.Up()
+	log.Info("migrations applied", "count", count)
+	return err
+}
+
+// runMigrations runs the migrate subcommand: "up" applies every pending
+// migration, "down" reverts the last one (or the given number of steps) and
+// "status" lists them.
+func runMigrations(db *sql.DB, driver string, args []string) error {
+	migrator, err := migrate.New(db, driver)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up()
+		fmt.Printf("applied %d migrations\n", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New(migrateUsage)
+			}
+		}
+		count, err := migrator.Down(steps)
+		fmt.Printf("reverted %d migrations\n", count)
+		return err
+	case "status":
+		list, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range list {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d %-25s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	}
+	return errors.New(migrateUsage)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// serve runs srv until it fails or the process gets SIGINT or SIGTERM. Then it
+// stops accepting connections, waits up to shutdownTimeout for the in-flight
+// requests and closes the database.
+func serve(srv *http.Server, db *sql.DB, shutdownTimeout time.Duration, log *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Info("listening", "addr", srv.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		db.Close()
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	log.Info("shutting down, waiting for in-flight requests", "timeout", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		err = errors.Join(err, srv.Close())
+	}
+	return errors.Join(err, db.Close())
+}
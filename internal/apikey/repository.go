@@ -0,0 +1,134 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+)
+
+var ErrNotFound = domain.NotFound("api key not found")
+
+type Repository interface {
+	GetAll(ctx context.Context) ([]domain.APIKey, error)
+	GetByID(ctx context.Context, id int) (domain.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (domain.APIKey, error)
+	Create(ctx context.Context, key domain.APIKey) (domain.APIKey, error)
+	Update(ctx context.Context, key domain.APIKey) (domain.APIKey, error)
+	Delete(ctx context.Context, id int) error
+	Touch(ctx context.Context, id int, at time.Time) error
+}
+
+type repository struct {
+	storage store.StoreInterfaceAPIKey
+	log     *slog.Logger
+}
+
+func NewRepository(storage store.StoreInterfaceAPIKey, log *slog.Logger) Repository {
+	return &repository{storage, log}
+}
+
+func (r *repository) GetAll(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Repository.GetAll")
+	defer span.End()
+	keys, err := r.storage.ReadAll(ctx)
+	if err != nil {
+		r.log.ErrorContext(ctx, "listing api keys", "err", err)
+		return nil, domain.Internal("error listing api keys", err)
+	}
+	list := make([]domain.APIKey, len(keys))
+	for i, key := range keys {
+		list[i] = inUTC(key)
+	}
+	return list, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id int) (domain.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Repository.GetByID")
+	defer span.End()
+	key, err := r.storage.Read(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "reading api key", "id", id, "err", err)
+		return domain.APIKey{}, domain.Internal("error reading api key", err)
+	}
+	return inUTC(key), nil
+}
+
+func (r *repository) GetByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Repository.GetByHash")
+	defer span.End()
+	key, err := r.storage.ReadByHash(ctx, hash)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "reading api key by hash", "err", err)
+		return domain.APIKey{}, domain.Internal("error reading api key", err)
+	}
+	return inUTC(key), nil
+}
+
+func (r *repository) Create(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Repository.Create")
+	defer span.End()
+	id, err := r.storage.Create(ctx, key)
+	if err != nil {
+		r.log.ErrorContext(ctx, "creating api key", "err", err)
+		return domain.APIKey{}, domain.Internal("error creating api key", err)
+	}
+	key.Id = id
+	return inUTC(key), nil
+}
+
+func (r *repository) Update(ctx context.Context, key domain.APIKey) (domain.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Repository.Update")
+	defer span.End()
+	err := r.storage.Update(ctx, key)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.APIKey{}, ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "updating api key", "id", key.Id, "err", err)
+		return domain.APIKey{}, domain.Internal("error updating api key", err)
+	}
+	return inUTC(key), nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "apikey.Repository.Delete")
+	defer span.End()
+	err := r.storage.Delete(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "deleting api key", "id", id, "err", err)
+		return domain.Internal("error deleting api key", err)
+	}
+	return nil
+}
+
+func (r *repository) Touch(ctx context.Context, id int, at time.Time) error {
+	ctx, span := tracer.Start(ctx, "apikey.Repository.Touch")
+	defer span.End()
+	err := r.storage.Touch(ctx, id, at)
+	if err != nil {
+		return domain.Internal("error recording api key use", err)
+	}
+	return nil
+}
+
+// inUTC returns the key with its times in UTC, whether they come from the
+// clock or from the database, which reads them in the clinic timezone.
+func inUTC(key domain.APIKey) domain.APIKey {
+	key.CreatedAt = key.CreatedAt.UTC()
+	key.ExpiresAt = key.ExpiresAt.UTC()
+	key.LastUsedAt = key.LastUsedAt.UTC()
+	return key
+}
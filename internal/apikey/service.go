@@ -0,0 +1,190 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/JulietaAlfie/backendGo.git/internal/apikey")
+
+var ErrInvalidKey = errors.New("invalid or expired api key")
+
+const (
+	// keyPrefix starts every key, so they are easy to spot in logs and
+	// secret scanners.
+	keyPrefix = "ck_"
+	// shownLength is how many characters of a key are kept to tell it apart.
+	shownLength   = len(keyPrefix) + 8
+	maxNameLength = 45
+	// touchInterval is how often the last use of a key is written, so a busy
+	// client does not update its key on every request.
+	touchInterval = time.Minute
+)
+
+// Issued is a key as it is created or rotated, the only time the secret is
+// shown.
+type Issued struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+type Service interface {
+	GetAll(ctx context.Context) ([]domain.APIKey, error)
+	Create(ctx context.Context, name string, scopes []domain.Permission, expiresAt time.Time) (Issued, error)
+	Rotate(ctx context.Context, id int) (Issued, error)
+	Revoke(ctx context.Context, id int) error
+	Authenticate(ctx context.Context, key string) (domain.Principal, error)
+}
+
+type service struct {
+	r   Repository
+	log *slog.Logger
+}
+
+func NewService(r Repository, log *slog.Logger) Service {
+	return &service{r, log}
+}
+
+func (s *service) GetAll(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Service.GetAll")
+	defer span.End()
+	return s.r.GetAll(ctx)
+}
+
+// Create issues a key with the scopes, which never expires if expiresAt is
+// zero.
+func (s *service) Create(ctx context.Context, name string, scopes []domain.Permission, expiresAt time.Time) (Issued, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Service.Create")
+	defer span.End()
+	now := time.Now()
+	var invalid []domain.FieldError
+	if name == "" || len(name) > maxNameLength {
+		invalid = append(invalid, domain.FieldError{Field: "name", Message: fmt.Sprintf("name must have between 1 and %d characters", maxNameLength)})
+	}
+	if len(scopes) == 0 {
+		invalid = append(invalid, domain.FieldError{Field: "scopes", Message: "scopes was empty"})
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			invalid = append(invalid, domain.FieldError{Field: "scopes", Message: fmt.Sprintf("unknown scope %q", scope)})
+		}
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(now) {
+		invalid = append(invalid, domain.FieldError{Field: "expires_at", Message: "expires_at must be in the future"})
+	}
+	if len(invalid) > 0 {
+		return Issued{}, domain.InvalidFields(invalid...)
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return Issued{}, domain.Internal("error generating api key", err)
+	}
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	key := domain.APIKey{
+		Name:      name,
+		Prefix:    secret[:shownLength],
+		Hash:      hash(secret),
+		Scopes:    slices.Compact(scopes),
+		CreatedAt: now.Truncate(time.Second),
+		ExpiresAt: expiresAt.Truncate(time.Second),
+	}
+	key, err = s.r.Create(ctx, key)
+	if err != nil {
+		return Issued{}, err
+	}
+	s.log.InfoContext(ctx, "api key created", "id", key.Id, "name", key.Name, "scopes", key.Scopes)
+	return Issued{APIKey: key, Key: secret}, nil
+}
+
+// Rotate replaces the secret of a key, keeping its name, scopes and expiry.
+// The old secret stops working at once.
+func (s *service) Rotate(ctx context.Context, id int) (Issued, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Service.Rotate")
+	defer span.End()
+	key, err := s.r.GetByID(ctx, id)
+	if err != nil {
+		return Issued{}, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return Issued{}, domain.Internal("error generating api key", err)
+	}
+	key.Prefix = secret[:shownLength]
+	key.Hash = hash(secret)
+	key, err = s.r.Update(ctx, key)
+	if err != nil {
+		return Issued{}, err
+	}
+	s.log.InfoContext(ctx, "api key rotated", "id", key.Id, "name", key.Name)
+	return Issued{APIKey: key, Key: secret}, nil
+}
+
+func (s *service) Revoke(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "apikey.Service.Revoke")
+	defer span.End()
+	err := s.r.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.log.InfoContext(ctx, "api key revoked", "id", id)
+	return nil
+}
+
+// Authenticate returns the principal of a key and records its use.
+func (s *service) Authenticate(ctx context.Context, key string) (domain.Principal, error) {
+	ctx, span := tracer.Start(ctx, "apikey.Service.Authenticate")
+	defer span.End()
+	if !strings.HasPrefix(key, keyPrefix) {
+		return domain.Principal{}, ErrInvalidKey
+	}
+	apiKey, err := s.r.GetByHash(ctx, hash(key))
+	if errors.Is(err, ErrNotFound) {
+		return domain.Principal{}, ErrInvalidKey
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	now := time.Now()
+	if apiKey.Expired(now) {
+		return domain.Principal{}, ErrInvalidKey
+	}
+	if now.Sub(apiKey.LastUsedAt) >= touchInterval {
+		err = s.r.Touch(ctx, apiKey.Id, now.Truncate(time.Second))
+		if err != nil {
+			s.log.WarnContext(ctx, "recording api key use", "id", apiKey.Id, "err", err)
+		}
+	}
+	return domain.Principal{
+		KeyId:  apiKey.Id,
+		Scopes: apiKey.Scopes,
+	}, nil
+}
+
+// newSecret returns a random key.
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(b), nil
+}
+
+// hash is what is stored of a key. Keys are random, so a plain SHA-256 is
+// enough and fast to check on every request.
+func hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
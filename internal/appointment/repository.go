@@ -1,93 +1,195 @@
 package appointment
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 	"github.com/JulietaAlfie/backendGo.git/pkg/store"
 )
 
+var (
+	ErrNotFound = domain.NotFound("appointment not found")
+	// ErrNotDeleted is returned when restoring or purging an id that is not
+	// of a deleted appointment.
+	ErrNotDeleted = &domain.Error{Code: domain.CodeNotDeleted, Message: "deleted appointment not found"}
+	// ErrParticipantDeleted is returned when restoring an appointment whose
+	// patient or dentist is deleted.
+	ErrParticipantDeleted = &domain.Error{Code: domain.CodeParticipantDeleted, Message: "the patient or the dentist of the appointment is deleted, restore them first"}
+)
+
 type Repository interface {
-	GetAll() []domain.Appointment
-	GetByID(id int) (domain.Appointment, error)
-	GetByDNI(dni int) (domain.Appointment, error)
-	Create(appointment domain.Appointment) (domain.Appointment, error)
-	CreateByDniAndLicence(dni int, license string, date string, time string, description string) (domain.Appointment, error)
-	Update(id int, appointment domain.Appointment) (domain.Appointment, error)
-	Delete(id int) error
+	Search(ctx context.Context, query domain.AppointmentQuery) ([]domain.Appointment, int, error)
+	GetByID(ctx context.Context, id int) (domain.Appointment, error)
+	GetByDentist(ctx context.Context, dentistId int, from time.Time, to time.Time) ([]domain.Appointment, error)
+	Create(ctx context.Context, appointment domain.Appointment) (domain.Appointment, error)
+	CreateByDniAndLicence(ctx context.Context, dni int, license string, appointment domain.Appointment) (domain.Appointment, error)
+	Update(ctx context.Context, id int, appointment domain.Appointment) (domain.Appointment, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, id int) error
 }
 
 type repository struct {
 	storage store.StoreInterfaceAppointment
+	log     *slog.Logger
 }
 
-func NewRepository(storage store.StoreInterfaceAppointment) Repository {
-	return &repository{storage}
+func NewRepository(storage store.StoreInterfaceAppointment, log *slog.Logger) Repository {
+	return &repository{storage, log}
 }
 
-func (r *repository) GetAll() []domain.Appointment {
-	appointments, err := r.storage.ReadAll()
+func (r *repository) Search(ctx context.Context, query domain.AppointmentQuery) ([]domain.Appointment, int, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.Search")
+	defer span.End()
+	appointments, total, err := r.storage.Search(ctx, query)
 	if err != nil {
-		return []domain.Appointment{}
+		r.log.ErrorContext(ctx, "listing appointments", "err", err)
+		return []domain.Appointment{}, 0, domain.Internal("appointments could not be brought", err)
 	}
-	return appointments
+	return appointments, total, nil
 }
 
-func (r *repository) GetByID(id int) (domain.Appointment, error) {
-	appointment, err := r.storage.Read(id)
+func (r *repository) GetByID(ctx context.Context, id int) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.GetByID")
+	defer span.End()
+	appointment, err := r.storage.Read(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Appointment{}, ErrNotFound
+	}
 	if err != nil {
-		fmt.Println(err)
-		return domain.Appointment{}, errors.New("appointment not found")
+		r.log.ErrorContext(ctx, "reading appointment", "id", id, "err", err)
+		return domain.Appointment{}, domain.Internal("error reading appointment", err)
 	}
 	return appointment, nil
 
 }
 
-func (r *repository) GetByDNI(dni int) (domain.Appointment, error) {
-	appointment, err := r.storage.ReadByDNI(dni)
+func (r *repository) GetByDentist(ctx context.Context, dentistId int, from time.Time, to time.Time) ([]domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.GetByDentist")
+	defer span.End()
+	appointments, err := r.storage.ReadByDentist(ctx, dentistId, from, to)
 	if err != nil {
-		fmt.Println(err)
-		return domain.Appointment{}, errors.New("appointment not found")
+		r.log.ErrorContext(ctx, "listing appointments of dentist", "dentist_id", dentistId, "err", err)
+		return []domain.Appointment{}, domain.Internal("appointments could not be brought", err)
 	}
-	return appointment, nil
+	return appointments, nil
 }
 
-func (r *repository) Create(appointment domain.Appointment) (domain.Appointment, error) {
-	id, err := r.storage.Create(appointment)
+func (r *repository) Create(ctx context.Context, appointment domain.Appointment) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.Create")
+	defer span.End()
+	id, err := r.storage.Create(ctx, appointment)
 	if err != nil {
-		fmt.Println(err)
-		return domain.Appointment{}, errors.New("error creating appointment")
+		if isConflict(err) {
+			return domain.Appointment{}, err
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			return domain.Appointment{}, participantNotFound(err)
+		}
+		r.log.ErrorContext(ctx, "creating appointment", "err", err)
+		return domain.Appointment{}, domain.Internal("error creating appointment", err)
 	}
 	appointment.Id = id
 	return appointment, nil
 }
 
-func (r *repository) CreateByDniAndLicence(dni int, license string, date string, time string, description string) (domain.Appointment, error) {
-	var appointment domain.Appointment
-	app, err := r.storage.CreateByDniAndLicence(dni, license, date, time, description)
+func (r *repository) CreateByDniAndLicence(ctx context.Context, dni int, license string, appointment domain.Appointment) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.CreateByDniAndLicence")
+	defer span.End()
+	app, err := r.storage.CreateByDniAndLicence(ctx, dni, license, appointment)
 	if err != nil {
-		return domain.Appointment{}, errors.New("error creating appointment")
+		if isConflict(err) {
+			return domain.Appointment{}, err
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			return domain.Appointment{}, participantNotFound(err)
+		}
+		r.log.ErrorContext(ctx, "creating appointment", "dni", dni, "license", license, "err", err)
+		return domain.Appointment{}, domain.Internal("error creating appointment", err)
 	}
-	appointment = app
-	appointment.Date = date
-	appointment.Description = description
-	appointment.Time = time
-	return appointment, nil
+	return app, nil
 }
 
-func (r *repository) Delete(id int) error {
-	err := r.storage.Delete(id)
+func (r *repository) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.Delete")
+	defer span.End()
+	err := r.storage.Delete(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotFound
+	}
 	if err != nil {
+		r.log.ErrorContext(ctx, "deleting appointment", "id", id, "err", err)
+		return domain.Internal("an error occurred deleting appointment", err)
+	}
+	return nil
+}
+
+func (r *repository) Restore(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.Restore")
+	defer span.End()
+	err := r.storage.Restore(ctx, id)
+	switch {
+	case err == nil || isConflict(err):
 		return err
+	case err == store.ErrNotFound:
+		return ErrNotDeleted
+	case errors.Is(err, store.ErrNotFound):
+		// The store wraps ErrNotFound with the patient or the dentist.
+		r.log.DebugContext(ctx, "restoring appointment", "id", id, "err", err)
+		return ErrParticipantDeleted
+	}
+	r.log.ErrorContext(ctx, "restoring appointment", "id", id, "err", err)
+	return domain.Internal("an error occurred restoring appointment", err)
+}
+
+func (r *repository) Purge(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.Purge")
+	defer span.End()
+	err := r.storage.Purge(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotDeleted
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "purging appointment", "id", id, "err", err)
+		return domain.Internal("an error occurred purging appointment", err)
 	}
 	return nil
 }
 
-func (r *repository) Update(id int, appointment domain.Appointment) (domain.Appointment, error) {
-	err := r.storage.Update(appointment)
+func (r *repository) Update(ctx context.Context, id int, appointment domain.Appointment) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Repository.Update")
+	defer span.End()
+	err := r.storage.Update(ctx, appointment)
 	if err != nil {
-		return domain.Appointment{}, errors.New("an error occurred updating appointment")
+		if isConflict(err) {
+			return domain.Appointment{}, err
+		}
+		if err == store.ErrNotFound {
+			return domain.Appointment{}, ErrNotFound
+		}
+		if errors.Is(err, store.ErrNotFound) {
+			return domain.Appointment{}, participantNotFound(err)
+		}
+		r.log.ErrorContext(ctx, "updating appointment", "id", id, "err", err)
+		return domain.Appointment{}, domain.Internal("an error occurred updating appointment", err)
 	}
 	return appointment, nil
 }
+
+// participantNotFound is the error of an appointment whose patient or dentist
+// does not exist. The store names which one when it wraps ErrNotFound.
+func participantNotFound(err error) error {
+	message := "patient or dentist not found"
+	if err != store.ErrNotFound {
+		message = err.Error()
+	}
+	return &domain.Error{Code: domain.CodeNotFound, Message: message, Err: err}
+}
+
+func isConflict(err error) bool {
+	var conflict *domain.ConflictError
+	return errors.As(err, &conflict)
+}
@@ -1,95 +1,329 @@
 package appointment
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/internal/dentist"
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/internal/patient"
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
+
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/JulietaAlfie/backendGo.git/internal/appointment")
+
+// maxAvailabilityDays bounds the range of a free-slot search.
+const maxAvailabilityDays = 31
+
 type Service interface {
-	GetAll() ([]domain.Appointment, error)
-	GetByID(id int) (domain.Appointment, error)
-	GetByDNI(dni int) (domain.Appointment, error)
-	Create(appointment domain.Appointment) (domain.Appointment, error)
-	CreateByDniAndLicence(dni int, license string, date string, time string, description string) (domain.Appointment, error)
-	Delete(id int) error
-	Update(id int, appointment domain.Appointment) (domain.Appointment, error)
+	Search(ctx context.Context, query domain.AppointmentQuery) ([]domain.Appointment, int, error)
+	GetByID(ctx context.Context, id int) (domain.Appointment, error)
+	History(ctx context.Context, dni int, query domain.AppointmentQuery) ([]domain.Appointment, int, error)
+	Agenda(ctx context.Context, dentistId int, query domain.AppointmentQuery) ([]domain.Appointment, int, error)
+	Create(ctx context.Context, appointment domain.Appointment) (domain.Appointment, error)
+	CreateByDniAndLicence(ctx context.Context, dni int, license string, appointment domain.Appointment) (domain.Appointment, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (domain.Appointment, error)
+	Purge(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, appointment domain.Appointment) (domain.Appointment, error)
+	Availability(ctx context.Context, dentistId int, from time.Time, to time.Time) ([]domain.Slot, error)
 }
 
 type service struct {
-	r Repository
+	r        Repository
+	dentists dentist.Repository
+	patients patient.Repository
+	auditor  audit.Recorder
+	log      *slog.Logger
 }
 
-func NewService(r Repository) Service {
-	return &service{r}
+func NewService(r Repository, dentists dentist.Repository, patients patient.Repository, auditor audit.Recorder, log *slog.Logger) Service {
+	return &service{r, dentists, patients, auditor, log}
 }
 
-func (s *service) GetAll() ([]domain.Appointment, error) {
-	appointments := s.r.GetAll()
-	return appointments, nil
+// Search returns a page of the appointments that match the query and how many
+// match in total. The page holds DefaultPageLimit appointments unless told
+// otherwise, and MaxPageLimit at most.
+func (s *service) Search(ctx context.Context, query domain.AppointmentQuery) ([]domain.Appointment, int, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Search")
+	defer span.End()
+	query.Page = query.Page.Clamp()
+	return s.r.Search(ctx, query)
 }
 
-func (s *service) GetByID(id int) (domain.Appointment, error) {
-	appointment, err := s.r.GetByID(id)
+func (s *service) GetByID(ctx context.Context, id int) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.GetByID")
+	defer span.End()
+	appointment, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return domain.Appointment{}, err
 	}
 	return appointment, nil
 }
 
-func (s *service) GetByDNI(dni int) (domain.Appointment, error) {
-	appointment, err := s.r.GetByDNI(dni)
+// History returns a page of the appointments of the patient with the dni that
+// also match the query, and how many match in total.
+func (s *service) History(ctx context.Context, dni int, query domain.AppointmentQuery) ([]domain.Appointment, int, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.History")
+	defer span.End()
+	_, err := s.patients.GetByDNI(ctx, dni)
 	if err != nil {
-		fmt.Println(err)
-		return domain.Appointment{}, err
+		return []domain.Appointment{}, 0, err
 	}
-	return appointment, nil
+	query.PatientDNI = dni
+	query.Page = query.Page.Clamp()
+	return s.r.Search(ctx, query)
+}
+
+// Agenda returns a page of the appointments of the dentist that also match the
+// query, and how many match in total.
+func (s *service) Agenda(ctx context.Context, dentistId int, query domain.AppointmentQuery) ([]domain.Appointment, int, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Agenda")
+	defer span.End()
+	_, err := s.dentists.GetByID(ctx, dentistId)
+	if err != nil {
+		return []domain.Appointment{}, 0, err
+	}
+	if query.DentistId != 0 && query.DentistId != dentistId {
+		return []domain.Appointment{}, 0, nil
+	}
+	query.DentistId = dentistId
+	query.Page = query.Page.Clamp()
+	return s.r.Search(ctx, query)
 }
 
-func (s *service) Create(appointment domain.Appointment) (domain.Appointment, error) {
-	appointment, err := s.r.Create(appointment)
+func (s *service) Create(ctx context.Context, appointment domain.Appointment) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Create")
+	defer span.End()
+	err := appointment.ValidateBooking(time.Now())
 	if err != nil {
 		return domain.Appointment{}, err
 	}
+	appointment.SetEnd()
+	err = s.checkWorkingHours(ctx, appointment.Dentist.Id, appointment)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	appointment, err = s.r.Create(ctx, appointment)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	s.auditor.Record(ctx, domain.AuditCreate, "appointment", appointment.Id, nil, appointment.AuditRecord())
+	metrics.AppointmentsCreated.Inc()
+	s.log.InfoContext(ctx, "appointment booked", "id", appointment.Id, "dentist_id", appointment.Dentist.Id, "patient_id", appointment.Patient.Id)
 	return appointment, nil
 }
 
-func (s *service) CreateByDniAndLicence(dni int, license string, date string, time string, description string) (domain.Appointment, error) {
-
-	appointment, err := s.r.CreateByDniAndLicence(dni, license, date, time, description)
+func (s *service) CreateByDniAndLicence(ctx context.Context, dni int, license string, appointment domain.Appointment) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.CreateByDniAndLicence")
+	defer span.End()
+	p, err := s.patients.GetByDNI(ctx, dni)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	d, err := s.dentists.GetByLicense(ctx, license)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	appointment.Patient, appointment.Dentist = p, d
+	err = appointment.ValidateBooking(time.Now())
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	appointment.SetEnd()
+	err = s.checkWorkingHours(ctx, d.Id, appointment)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	appointment, err = s.r.CreateByDniAndLicence(ctx, dni, license, appointment)
 	if err != nil {
 		return domain.Appointment{}, err
 	}
+	s.auditor.Record(ctx, domain.AuditCreate, "appointment", appointment.Id, nil, appointment.AuditRecord())
+	metrics.AppointmentsCreated.Inc()
+	s.log.InfoContext(ctx, "appointment booked", "id", appointment.Id, "dentist_id", appointment.Dentist.Id, "patient_id", appointment.Patient.Id)
 	return appointment, nil
 
 }
 
-func (s *service) Update(id int, appointment domain.Appointment) (domain.Appointment, error) {
-	appointmentDB, err := s.r.GetByID(id)
+func (s *service) Update(ctx context.Context, id int, appointment domain.Appointment) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Update")
+	defer span.End()
+	appointmentDB, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return domain.Appointment{}, err
 	}
+	before := appointmentDB.AuditRecord()
 	if appointment.Description != "" {
 		appointmentDB.Description = appointment.Description
 	}
-	if appointment.Date != "" {
+	patientChanged := appointment.Patient.Id != 0 && appointment.Patient.Id != appointmentDB.Patient.Id
+	if patientChanged {
+		appointmentDB.Patient = domain.Patient{Id: appointment.Patient.Id}
+	}
+	dentistChanged := appointment.Dentist.Id != 0 && appointment.Dentist.Id != appointmentDB.Dentist.Id
+	if dentistChanged {
+		appointmentDB.Dentist = domain.Dentist{Id: appointment.Dentist.Id}
+	}
+	rescheduled := dentistChanged
+	if appointment.Treatment != "" && appointment.Treatment != appointmentDB.Treatment {
+		appointmentDB.Treatment = appointment.Treatment
+		appointmentDB.Duration = 0
+		rescheduled = true
+	}
+	if appointment.Duration != 0 {
+		appointmentDB.Duration = appointment.Duration
+		rescheduled = true
+	}
+	// Only a new date must be in the future, so past appointments can still
+	// be corrected.
+	moved := !appointment.Date.IsZero() && !appointment.Date.Equal(appointmentDB.Date)
+	if moved {
 		appointmentDB.Date = appointment.Date
+		rescheduled = true
+		err = appointmentDB.ValidateBooking(time.Now())
+	} else {
+		err = appointmentDB.Validate()
+	}
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	if patientChanged {
+		appointmentDB.Patient, err = s.patients.GetByID(ctx, appointmentDB.Patient.Id)
+		if err != nil {
+			return domain.Appointment{}, err
+		}
+	}
+	if dentistChanged {
+		appointmentDB.Dentist, err = s.dentists.GetByID(ctx, appointmentDB.Dentist.Id)
+		if err != nil {
+			return domain.Appointment{}, err
+		}
 	}
-	if appointment.Time != "" {
-		appointmentDB.Time = appointment.Time
+	if rescheduled {
+		appointmentDB.SetEnd()
+		err = s.checkWorkingHours(ctx, appointmentDB.Dentist.Id, appointmentDB)
+		if err != nil {
+			return domain.Appointment{}, err
+		}
 	}
-	appointmentDB, err = s.r.Update(id, appointmentDB)
+	appointmentDB, err = s.r.Update(ctx, id, appointmentDB)
 	if err != nil {
 		return domain.Appointment{}, err
 	}
+	s.auditor.Record(ctx, domain.AuditUpdate, "appointment", id, before, appointmentDB.AuditRecord())
+	s.log.InfoContext(ctx, "appointment updated", "id", id, "rescheduled", rescheduled)
 
 	return appointmentDB, nil
 }
 
-func (s *service) Delete(id int) error {
-	err := s.r.Delete(id)
+// Delete cancels the appointment; it fails if there is none with the id.
+func (s *service) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Delete")
+	defer span.End()
+	appointment, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
+	err = s.r.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.auditor.Record(ctx, domain.AuditDelete, "appointment", id, appointment.AuditRecord(), nil)
+	metrics.AppointmentsCancelled.Inc()
+	s.log.InfoContext(ctx, "appointment cancelled", "id", id)
 	return nil
 }
+
+// Restore books a cancelled appointment again; it fails if its patient or its
+// dentist is deleted or if the slot was booked meanwhile.
+func (s *service) Restore(ctx context.Context, id int) (domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Restore")
+	defer span.End()
+	err := s.r.Restore(ctx, id)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	appointment, err := s.r.GetByID(ctx, id)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	s.auditor.Record(ctx, domain.AuditRestore, "appointment", id, nil, appointment.AuditRecord())
+	s.log.InfoContext(ctx, "appointment restored", "id", id)
+	return appointment, nil
+}
+
+// Purge removes a cancelled appointment for good.
+func (s *service) Purge(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Purge")
+	defer span.End()
+	err := s.r.Purge(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.auditor.Record(ctx, domain.AuditPurge, "appointment", id, nil, nil)
+	s.log.InfoContext(ctx, "appointment purged", "id", id)
+	return nil
+}
+
+// Availability lists the free slots of a dentist from the start of the from
+// day to the end of the to day, subtracting the slots already booked. Days are
+// taken in the location of from.
+func (s *service) Availability(ctx context.Context, dentistId int, from time.Time, to time.Time) ([]domain.Slot, error) {
+	ctx, span := tracer.Start(ctx, "appointment.Service.Availability")
+	defer span.End()
+	if to.Before(from) {
+		return nil, domain.Invalid("to", "from must not be after to")
+	}
+	if to.Sub(from) >= maxAvailabilityDays*24*time.Hour {
+		return nil, domain.Invalid("to", fmt.Sprintf("the range cannot exceed %d days", maxAvailabilityDays))
+	}
+	_, err := s.dentists.GetByID(ctx, dentistId)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.dentists.GetSchedule(ctx, dentistId)
+	if err != nil {
+		return nil, err
+	}
+	end := to.AddDate(0, 0, 1)
+	appointments, err := s.r.GetByDentist(ctx, dentistId, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	slots := []domain.Slot{}
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		length := schedule.SlotLength(day.Weekday())
+		for _, start := range schedule.Slots(day) {
+			slot := domain.Appointment{Date: start, End: start.Add(length)}
+			if !overlapsAny(slot, appointments) {
+				slots = append(slots, domain.Slot{Start: slot.Date, End: slot.End})
+			}
+		}
+	}
+	return slots, nil
+}
+
+func (s *service) checkWorkingHours(ctx context.Context, dentistId int, appointment domain.Appointment) error {
+	schedule, err := s.dentists.GetSchedule(ctx, dentistId)
+	if err != nil {
+		return err
+	}
+	return schedule.Allows(appointment.Date, appointment.End)
+}
+
+// overlapsAny reports whether slot overlaps any of the booked appointments.
+func overlapsAny(slot domain.Appointment, booked []domain.Appointment) bool {
+	for _, b := range booked {
+		if slot.Overlaps(b) {
+			return true
+		}
+	}
+	return false
+}
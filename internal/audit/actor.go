@@ -0,0 +1,23 @@
+package audit
+
+import "context"
+
+// system is the actor of the changes made outside of a request, such as the
+// start-up of the server.
+const system = "system"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying who makes the changes.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the actor carried by ctx, "system" if none.
+func Actor(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return system
+	}
+	return actor
+}
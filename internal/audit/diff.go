@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// change is the value of a field before and after a change, null when the
+// record did not exist.
+type change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// diff returns, as JSON, the fields that differ between the JSON encodings of
+// before and after. Either may be nil, for creations and deletions.
+func diff(before any, after any) (json.RawMessage, error) {
+	from, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]change{}
+	for name, value := range from {
+		if other, ok := to[name]; !ok || !reflect.DeepEqual(value, other) {
+			changes[name] = change{Before: value, After: to[name]}
+		}
+	}
+	for name, value := range to {
+		if _, ok := from[name]; !ok {
+			changes[name] = change{After: value}
+		}
+	}
+	return json.Marshal(changes)
+}
+
+// fields decodes the JSON encoding of v into its top-level fields.
+func fields(v any) (map[string]any, error) {
+	if v == nil {
+		return map[string]any{}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]any{}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
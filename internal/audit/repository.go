@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+)
+
+type Repository interface {
+	Create(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, error)
+	Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error)
+}
+
+type repository struct {
+	storage store.StoreInterfaceAudit
+	log     *slog.Logger
+}
+
+func NewRepository(storage store.StoreInterfaceAudit, log *slog.Logger) Repository {
+	return &repository{storage, log}
+}
+
+func (r *repository) Create(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, error) {
+	ctx, span := tracer.Start(ctx, "audit.Repository.Create")
+	defer span.End()
+	id, err := r.storage.Create(ctx, entry)
+	if err != nil {
+		return domain.AuditEntry{}, err
+	}
+	entry.Id = id
+	return entry, nil
+}
+
+func (r *repository) Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	ctx, span := tracer.Start(ctx, "audit.Repository.Search")
+	defer span.End()
+	entries, err := r.storage.Search(ctx, query)
+	if err != nil {
+		r.log.ErrorContext(ctx, "searching audit log", "err", err)
+		return nil, domain.Internal("error searching audit log", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/logging"
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var tracer = otel.Tracer("github.com/JulietaAlfie/backendGo.git/internal/audit")
+
+// recordTimeout bounds the writing of an entry, which outlives the request.
+const recordTimeout = 5 * time.Second
+
+// recordAttempts is how many times an entry is written before it is given up,
+// waiting retryBackoff after the first failure and twice as long after each.
+const (
+	recordAttempts = 3
+	retryBackoff   = 100 * time.Millisecond
+)
+
+const (
+	// DefaultLimit is how many entries a search returns when no limit is
+	// given, and MaxLimit the most it returns.
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Recorder is what the services that change records need of the audit log.
+//
+// Entries are written after the change is committed, in their own
+// transaction, so the log is best effort: a change is never undone because its
+// entry could not be written. An entry that still fails after the retries is
+// logged at ERROR as "audit entry lost", with every field needed to insert it
+// by hand, marked as an error on the trace and counted in
+// metrics.AuditFailures, which should be alerted on.
+type Recorder interface {
+	// Record appends an entry for the action on the entity with the id, made
+	// by the actor of ctx. before is nil for creations and after for
+	// deletions.
+	Record(ctx context.Context, action string, entity string, id int, before any, after any)
+}
+
+type Service interface {
+	Recorder
+	Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error)
+}
+
+type service struct {
+	r   Repository
+	log *slog.Logger
+}
+
+func NewService(r Repository, log *slog.Logger) Service {
+	return &service{r, log}
+}
+
+// Record does not fail the change it records, which is already made; see
+// Recorder for what happens to an entry that cannot be written. The entry is
+// written even if the request is cancelled or times out meanwhile.
+func (s *service) Record(ctx context.Context, action string, entity string, id int, before any, after any) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "audit.Service.Record")
+	defer span.End()
+	entry := domain.AuditEntry{
+		Actor:     Actor(ctx),
+		Action:    action,
+		Entity:    entity,
+		EntityId:  id,
+		RequestId: logging.RequestID(ctx),
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+	changes, err := diff(before, after)
+	if err == nil {
+		entry.Changes = changes
+		err = s.create(ctx, entry)
+	}
+	if err != nil {
+		metrics.AuditFailures.Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "audit entry lost")
+		s.log.ErrorContext(ctx, "audit entry lost",
+			"actor", entry.Actor, "action", action, "entity", entity, "id", id,
+			"changes", string(entry.Changes), "request_id", entry.RequestId, "created_at", entry.CreatedAt, "err", err)
+	}
+}
+
+// create writes the entry, retrying while the attempts and ctx last.
+func (s *service) create(ctx context.Context, entry domain.AuditEntry) error {
+	wait := retryBackoff
+	for attempt := 1; ; attempt++ {
+		_, err := s.r.Create(ctx, entry)
+		if err == nil || attempt == recordAttempts {
+			return err
+		}
+		s.log.WarnContext(ctx, "recording audit entry", "attempt", attempt, "entity", entry.Entity, "id", entry.EntityId, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+func (s *service) Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	ctx, span := tracer.Start(ctx, "audit.Service.Search")
+	defer span.End()
+	if query.Limit <= 0 {
+		query.Limit = DefaultLimit
+	}
+	query.Limit = min(query.Limit, MaxLimit)
+	return s.r.Search(ctx, query)
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecordOutlivesTheRequest(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := NewService(NewRepository(store.NewMemoryStoreAudit(store.NewMemoryDB()), log), log)
+
+	ctx, cancel := context.WithCancel(WithActor(context.Background(), "admin"))
+	cancel()
+	s.Record(ctx, domain.AuditCreate, "dentist", 1, nil, domain.Dentist{Id: 1, Name: "Ana"})
+
+	entries, err := s.Search(context.Background(), domain.AuditQuery{Entity: "dentist", EntityId: 1})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "admin" || entries[0].Action != domain.AuditCreate {
+		t.Errorf("Search after recording with a cancelled request: got %+v, want the creation by admin", entries)
+	}
+}
+
+// failingRepository fails the first failures creations.
+type failingRepository struct {
+	Repository
+	failures int
+	attempts int
+}
+
+func (r *failingRepository) Create(ctx context.Context, entry domain.AuditEntry) (domain.AuditEntry, error) {
+	r.attempts++
+	if r.attempts <= r.failures {
+		return domain.AuditEntry{}, errors.New("database is locked")
+	}
+	return r.Repository.Create(ctx, entry)
+}
+
+func TestRecordFailures(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		failures int
+		attempts int
+		lost     bool
+	}{
+		{"written at once", 0, 1, false},
+		{"written on a retry", recordAttempts - 1, recordAttempts, false},
+		{"lost", recordAttempts, recordAttempts, true},
+	} {
+		var logged bytes.Buffer
+		log := slog.New(slog.NewJSONHandler(&logged, nil))
+		r := &failingRepository{Repository: NewRepository(store.NewMemoryStoreAudit(store.NewMemoryDB()), log), failures: test.failures}
+		s := NewService(r, log)
+		failures := testutil.ToFloat64(metrics.AuditFailures)
+
+		s.Record(WithActor(context.Background(), "admin"), domain.AuditUpdate, "dentist", 7, domain.Dentist{Id: 7, Name: "Ana"}, domain.Dentist{Id: 7, Name: "Maria"})
+
+		if r.attempts != test.attempts {
+			t.Errorf("%s: got %d attempts, want %d", test.name, r.attempts, test.attempts)
+		}
+		entries, _ := s.Search(context.Background(), domain.AuditQuery{})
+		if got := len(entries) == 0; got != test.lost {
+			t.Errorf("%s: got entries %+v, want lost %v", test.name, entries, test.lost)
+		}
+		if got := testutil.ToFloat64(metrics.AuditFailures) - failures; got != map[bool]float64{true: 1}[test.lost] {
+			t.Errorf("%s: got %v more audit failures counted", test.name, got)
+		}
+		// A lost entry is logged with what it takes to insert it by hand.
+		lost := strings.Contains(logged.String(), `"msg":"audit entry lost"`)
+		if lost != test.lost {
+			t.Errorf("%s: got log %s, want an audit entry lost %v", test.name, logged.String(), test.lost)
+		}
+		for _, field := range []string{`"actor":"admin"`, `"action":"update"`, `"entity":"dentist"`, `"id":7`, `Maria`} {
+			if lost && !strings.Contains(logged.String(), field) {
+				t.Errorf("%s: got log %s, want it with %s", test.name, logged.String(), field)
+			}
+		}
+	}
+}
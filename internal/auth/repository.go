@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+type Repository interface {
+	GetUser(ctx context.Context, id int) (domain.User, error)
+	GetUserByUsername(ctx context.Context, username string) (domain.User, error)
+	CreateUser(ctx context.Context, user domain.User) (domain.User, error)
+	// Revoke reports whether it revoked the token, false if it already was.
+	Revoke(ctx context.Context, tokenId string, expiresAt time.Time) (bool, error)
+	IsRevoked(ctx context.Context, tokenId string) (bool, error)
+}
+
+type repository struct {
+	users  store.StoreInterfaceUser
+	tokens store.StoreInterfaceToken
+	log    *slog.Logger
+}
+
+func NewRepository(users store.StoreInterfaceUser, tokens store.StoreInterfaceToken, log *slog.Logger) Repository {
+	return &repository{users, tokens, log}
+}
+
+func (r *repository) GetUser(ctx context.Context, id int) (domain.User, error) {
+	ctx, span := tracer.Start(ctx, "auth.Repository.GetUser")
+	defer span.End()
+	user, err := r.users.Read(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *repository) GetUserByUsername(ctx context.Context, username string) (domain.User, error) {
+	ctx, span := tracer.Start(ctx, "auth.Repository.GetUserByUsername")
+	defer span.End()
+	user, err := r.users.ReadByUsername(ctx, username)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *repository) CreateUser(ctx context.Context, user domain.User) (domain.User, error) {
+	ctx, span := tracer.Start(ctx, "auth.Repository.CreateUser")
+	defer span.End()
+	id, err := r.users.Create(ctx, user)
+	if errors.Is(err, store.ErrDuplicateUsername) {
+		return domain.User{}, &domain.Error{Code: domain.CodeDuplicateUsername, Message: "existing username", Err: err}
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "creating user", "err", err)
+		return domain.User{}, domain.Internal("error creating user", err)
+	}
+	user.Id = id
+	return user, nil
+}
+
+func (r *repository) Revoke(ctx context.Context, tokenId string, expiresAt time.Time) (bool, error) {
+	ctx, span := tracer.Start(ctx, "auth.Repository.Revoke")
+	defer span.End()
+	revoked, err := r.tokens.Revoke(ctx, tokenId, expiresAt)
+	if err != nil {
+		r.log.ErrorContext(ctx, "revoking token", "err", err)
+		return false, errors.New("error revoking token")
+	}
+	return revoked, nil
+}
+
+func (r *repository) IsRevoked(ctx context.Context, tokenId string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "auth.Repository.IsRevoked")
+	defer span.End()
+	return r.tokens.IsRevoked(ctx, tokenId)
+}
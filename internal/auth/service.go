@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/dentist"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/internal/patient"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var tracer = otel.Tracer("github.com/JulietaAlfie/backendGo.git/internal/auth")
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+)
+
+const (
+	minPasswordLength = 8
+	// bcrypt ignores the bytes after the 72th.
+	maxPasswordLength = 72
+	maxUsernameLength = 45
+)
+
+// Tokens is the response of a login or a refresh.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // seconds until the access token expires
+}
+
+type Service interface {
+	Login(ctx context.Context, username string, password string) (Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (Tokens, error)
+	Logout(ctx context.Context, principal domain.Principal, refreshToken string) error
+	Authenticate(ctx context.Context, accessToken string) (domain.Principal, error)
+	CreateUser(ctx context.Context, user domain.User, password string) (domain.User, error)
+	EnsureAdmin(ctx context.Context, username string, password string) error
+}
+
+type service struct {
+	r          Repository
+	dentists   dentist.Repository
+	patients   patient.Repository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	log        *slog.Logger
+	// dummyHash is compared against when the user does not exist, so a login
+	// takes as long whether the username is taken or not.
+	dummyHash []byte
+}
+
+// NewService builds the authentication service; tokens are signed with
+// secret and expire after accessTTL and refreshTTL. The dentists and patients
+// are looked up to link users to them.
+func NewService(r Repository, dentists dentist.Repository, patients patient.Repository, secret string, accessTTL time.Duration, refreshTTL time.Duration, log *slog.Logger) Service {
+	dummyHash, _ := bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+	return &service{
+		r:          r,
+		dentists:   dentists,
+		patients:   patients,
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		log:        log,
+		dummyHash:  dummyHash,
+	}
+}
+
+func (s *service) Login(ctx context.Context, username string, password string) (Tokens, error) {
+	ctx, span := tracer.Start(ctx, "auth.Service.Login")
+	defer span.End()
+	user, err := s.r.GetUserByUsername(ctx, username)
+	if errors.Is(err, ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return Tokens{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		s.log.WarnContext(ctx, "failed login", "username", username)
+		return Tokens{}, ErrInvalidCredentials
+	}
+	s.log.InfoContext(ctx, "user logged in", "user_id", user.Id)
+	return s.issue(user)
+}
+
+// Refresh exchanges a refresh token for a new pair of tokens. The refresh
+// token is revoked, so it can be used only once: of two refreshes with the
+// same token only the one that revokes it gets new tokens.
+func (s *service) Refresh(ctx context.Context, refreshToken string) (Tokens, error) {
+	ctx, span := tracer.Start(ctx, "auth.Service.Refresh")
+	defer span.End()
+	c, err := s.parse(refreshToken, typeRefresh)
+	if err != nil {
+		return Tokens{}, err
+	}
+	principal, err := c.principal()
+	if err != nil {
+		return Tokens{}, err
+	}
+	user, err := s.r.GetUser(ctx, principal.UserId)
+	if errors.Is(err, ErrUserNotFound) {
+		return Tokens{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Tokens{}, err
+	}
+	revoked, err := s.r.Revoke(ctx, c.ID, c.ExpiresAt.Time)
+	if err != nil {
+		return Tokens{}, err
+	}
+	if !revoked {
+		return Tokens{}, ErrInvalidToken
+	}
+	return s.issue(user)
+}
+
+// Logout revokes the access token of the principal and, if given, the refresh
+// token issued along with it.
+func (s *service) Logout(ctx context.Context, principal domain.Principal, refreshToken string) error {
+	ctx, span := tracer.Start(ctx, "auth.Service.Logout")
+	defer span.End()
+	if principal.TokenId == "" {
+		// API keys are revoked by an admin, not logged out.
+		return ErrInvalidToken
+	}
+	if refreshToken != "" {
+		c, err := s.parse(refreshToken, typeRefresh)
+		if err != nil {
+			return err
+		}
+		if p, err := c.principal(); err != nil || p.UserId != principal.UserId {
+			return ErrInvalidToken
+		}
+		_, err = s.r.Revoke(ctx, c.ID, c.ExpiresAt.Time)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := s.r.Revoke(ctx, principal.TokenId, principal.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	s.log.InfoContext(ctx, "user logged out", "user_id", principal.UserId)
+	return nil
+}
+
+func (s *service) Authenticate(ctx context.Context, accessToken string) (domain.Principal, error) {
+	ctx, span := tracer.Start(ctx, "auth.Service.Authenticate")
+	defer span.End()
+	c, err := s.parse(accessToken, typeAccess)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	err = s.checkRevoked(ctx, c.ID)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	return c.principal()
+}
+
+// CreateUser creates a user with the role and links of user and the given
+// password. Dentists must be linked to a dentist and patients to a patient.
+func (s *service) CreateUser(ctx context.Context, user domain.User, password string) (domain.User, error) {
+	ctx, span := tracer.Start(ctx, "auth.Service.CreateUser")
+	defer span.End()
+	var invalid []domain.FieldError
+	if user.Username == "" || len(user.Username) > maxUsernameLength {
+		invalid = append(invalid, domain.FieldError{Field: "username", Message: fmt.Sprintf("username must have between 1 and %d characters", maxUsernameLength)})
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		invalid = append(invalid, domain.FieldError{Field: "password", Message: fmt.Sprintf("password must have between %d and %d bytes", minPasswordLength, maxPasswordLength)})
+	}
+	if len(invalid) > 0 {
+		return domain.User{}, domain.InvalidFields(invalid...)
+	}
+	err := s.checkLinks(ctx, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return domain.User{}, domain.Internal("error creating user", err)
+	}
+	user.PasswordHash = string(hash)
+	user, err = s.r.CreateUser(ctx, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+	s.log.InfoContext(ctx, "user created", "id", user.Id, "username", user.Username, "role", user.Role)
+	return user, nil
+}
+
+// EnsureAdmin creates an admin unless the username is taken.
+func (s *service) EnsureAdmin(ctx context.Context, username string, password string) error {
+	ctx, span := tracer.Start(ctx, "auth.Service.EnsureAdmin")
+	defer span.End()
+	_, err := s.r.GetUserByUsername(ctx, username)
+	if !errors.Is(err, ErrUserNotFound) {
+		return err
+	}
+	_, err = s.CreateUser(ctx, domain.User{Username: username, Role: domain.RoleAdmin}, password)
+	return err
+}
+
+// checkLinks validates the role of a new user and the dentist or patient it
+// is linked to, which must exist.
+func (s *service) checkLinks(ctx context.Context, user domain.User) error {
+	var invalid []domain.FieldError
+	if !user.Role.Valid() {
+		invalid = append(invalid, domain.FieldError{Field: "role", Message: fmt.Sprintf("role must be one of %s, %s, %s or %s", domain.RoleAdmin, domain.RoleReceptionist, domain.RoleDentist, domain.RolePatient)})
+	}
+	if (user.Role == domain.RoleDentist) != (user.DentistId != 0) {
+		invalid = append(invalid, domain.FieldError{Field: "dentist_id", Message: "dentist_id is required for dentists and only for them"})
+	}
+	if (user.Role == domain.RolePatient) != (user.PatientId != 0) {
+		invalid = append(invalid, domain.FieldError{Field: "patient_id", Message: "patient_id is required for patients and only for them"})
+	}
+	if len(invalid) > 0 {
+		return domain.InvalidFields(invalid...)
+	}
+	if user.DentistId != 0 {
+		_, err := s.dentists.GetByID(ctx, user.DentistId)
+		if err != nil {
+			return err
+		}
+	}
+	if user.PatientId != 0 {
+		_, err := s.patients.GetByID(ctx, user.PatientId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) issue(user domain.User) (Tokens, error) {
+	access, err := s.sign(user, typeAccess, s.accessTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+	refresh, err := s.sign(user, typeRefresh, s.refreshTTL)
+	if err != nil {
+		return Tokens{}, err
+	}
+	return Tokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func (s *service) checkRevoked(ctx context.Context, tokenId string) error {
+	revoked, err := s.r.IsRevoked(ctx, tokenId)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/dentist"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/internal/patient"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+)
+
+var ctx = context.Background()
+
+const (
+	testSecret   = "a secret only for the tests"
+	testPassword = "password"
+)
+
+// newTestService returns a service backed by the in-memory stores, with an
+// admin "admin" and a receptionist "front" whose password is testPassword.
+func newTestService(t *testing.T, secret string, accessTTL time.Duration, refreshTTL time.Duration) Service {
+	t.Helper()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db := store.NewMemoryDB()
+	s := NewService(
+		NewRepository(store.NewMemoryStoreUser(db), store.NewMemoryStoreToken(db), log),
+		dentist.NewRepository(store.NewMemoryStoreDentist(db), log),
+		patient.NewRepository(store.NewMemoryStorePatient(db), log),
+		secret, accessTTL, refreshTTL, log,
+	)
+	err := s.EnsureAdmin(ctx, "admin", testPassword)
+	if err != nil {
+		t.Fatalf("EnsureAdmin: %v", err)
+	}
+	_, err = s.CreateUser(ctx, domain.User{Username: "front", Role: domain.RoleReceptionist}, testPassword)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return s
+}
+
+func login(t *testing.T, s Service, username string) Tokens {
+	t.Helper()
+	tokens, err := s.Login(ctx, username, testPassword)
+	if err != nil {
+		t.Fatalf("Login %s: %v", username, err)
+	}
+	return tokens
+}
+
+func TestLogin(t *testing.T) {
+	s := newTestService(t, testSecret, time.Minute, time.Hour)
+
+	tokens := login(t, s, "admin")
+	if tokens.TokenType != "Bearer" || tokens.ExpiresIn != 60 || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Errorf("Login: got %+v, want a Bearer pair expiring in 60 seconds", tokens)
+	}
+	principal, err := s.Authenticate(ctx, tokens.AccessToken)
+	if err != nil || principal.Username != "admin" || principal.Role != domain.RoleAdmin || principal.TokenId == "" {
+		t.Errorf("Authenticate: got %+v, %v, want the admin", principal, err)
+	}
+
+	for _, test := range []struct {
+		username string
+		password string
+	}{
+		{"admin", "wrong password"},
+		{"nobody", testPassword},
+	} {
+		_, err := s.Login(ctx, test.username, test.password)
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("Login %s with %q: got error %v, want ErrInvalidCredentials", test.username, test.password, err)
+		}
+	}
+}
+
+func TestAuthenticateRejects(t *testing.T) {
+	s := newTestService(t, testSecret, time.Minute, time.Hour)
+	tokens := login(t, s, "admin")
+	other := login(t, newTestService(t, "another secret", time.Minute, time.Hour), "admin")
+	expired := login(t, newTestService(t, testSecret, -time.Minute, time.Hour), "admin")
+
+	for _, test := range []struct {
+		name  string
+		token string
+	}{
+		{"a refresh token", tokens.RefreshToken},
+		{"a token signed with another secret", other.AccessToken},
+		{"an expired token", expired.AccessToken},
+		{"garbage", "not.a.token"},
+		{"an empty token", ""},
+	} {
+		_, err := s.Authenticate(ctx, test.token)
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Authenticate with %s: got error %v, want ErrInvalidToken", test.name, err)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	s := newTestService(t, testSecret, time.Minute, time.Hour)
+	tokens := login(t, s, "front")
+
+	refreshed, err := s.Refresh(ctx, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if refreshed.RefreshToken == tokens.RefreshToken || refreshed.AccessToken == tokens.AccessToken {
+		t.Errorf("Refresh: got the same tokens, want new ones")
+	}
+	principal, err := s.Authenticate(ctx, refreshed.AccessToken)
+	if err != nil || principal.Username != "front" || principal.Role != domain.RoleReceptionist {
+		t.Errorf("Authenticate with the refreshed token: got %+v, %v, want the receptionist", principal, err)
+	}
+
+	_, err = s.Refresh(ctx, tokens.RefreshToken)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh with a used token: got error %v, want ErrInvalidToken", err)
+	}
+	_, err = s.Refresh(ctx, refreshed.AccessToken)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh with an access token: got error %v, want ErrInvalidToken", err)
+	}
+	_, err = s.Refresh(ctx, refreshed.RefreshToken)
+	if err != nil {
+		t.Errorf("Refresh with the new token: %v", err)
+	}
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	s := newTestService(t, testSecret, time.Minute, time.Hour)
+	tokens := login(t, s, "admin")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Refresh(ctx, tokens.RefreshToken)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	refreshed := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			refreshed++
+		case !errors.Is(err, ErrInvalidToken):
+			t.Errorf("concurrent Refresh: got error %v, want ErrInvalidToken", err)
+		}
+	}
+	if refreshed != 1 {
+		t.Errorf("concurrent Refresh with the same token: %d succeeded, want 1", refreshed)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s := newTestService(t, testSecret, time.Minute, time.Hour)
+	tokens := login(t, s, "admin")
+	other := login(t, s, "front")
+	principal, err := s.Authenticate(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	err = s.Logout(ctx, principal, other.RefreshToken)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Logout with the refresh token of another user: got error %v, want ErrInvalidToken", err)
+	}
+	err = s.Logout(ctx, domain.Principal{UserId: principal.UserId, Role: domain.RoleAdmin}, "")
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Logout of an API key: got error %v, want ErrInvalidToken", err)
+	}
+
+	err = s.Logout(ctx, principal, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	_, err = s.Authenticate(ctx, tokens.AccessToken)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Authenticate after Logout: got error %v, want ErrInvalidToken", err)
+	}
+	_, err = s.Refresh(ctx, tokens.RefreshToken)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh after Logout: got error %v, want ErrInvalidToken", err)
+	}
+	_, err = s.Authenticate(ctx, other.AccessToken)
+	if err != nil {
+		t.Errorf("Authenticate of another user after Logout: %v", err)
+	}
+}
+
+func TestCreateUserErrors(t *testing.T) {
+	s := newTestService(t, testSecret, time.Minute, time.Hour)
+	for _, test := range []struct {
+		name     string
+		user     domain.User
+		password string
+		code     string
+		fields   []string
+	}{
+		{"taken username", domain.User{Username: "front", Role: domain.RoleReceptionist}, testPassword, domain.CodeDuplicateUsername, nil},
+		{"no username nor password", domain.User{Role: domain.RoleAdmin}, "short", domain.CodeValidation, []string{"username", "password"}},
+		{"unknown role", domain.User{Username: "x", Role: "superuser"}, testPassword, domain.CodeValidation, []string{"role"}},
+		{"unlinked dentist", domain.User{Username: "x", Role: domain.RoleDentist}, testPassword, domain.CodeValidation, []string{"dentist_id"}},
+		{"linked receptionist", domain.User{Username: "x", Role: domain.RoleReceptionist, DentistId: 1, PatientId: 1}, testPassword, domain.CodeValidation, []string{"dentist_id", "patient_id"}},
+		{"missing dentist", domain.User{Username: "x", Role: domain.RoleDentist, DentistId: 99}, testPassword, domain.CodeNotFound, nil},
+		{"missing patient", domain.User{Username: "x", Role: domain.RolePatient, PatientId: 99}, testPassword, domain.CodeNotFound, nil},
+	} {
+		_, err := s.CreateUser(ctx, test.user, test.password)
+		if code := domain.ErrorCode(err); code != test.code {
+			t.Errorf("CreateUser with a %s: got error %v with code %q, want %q", test.name, err, code, test.code)
+		}
+		var fields []string
+		for _, field := range domain.InvalidFieldsOf(err) {
+			fields = append(fields, field.Field)
+		}
+		if !slices.Equal(fields, test.fields) {
+			t.Errorf("CreateUser with a %s: got invalid fields %v, want %v", test.name, fields, test.fields)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	issuer      = "clinic"
+	typeAccess  = "access"
+	typeRefresh = "refresh"
+)
+
+// claims are the content of the access and refresh tokens, told apart by Type
+// so a refresh token cannot be used to authenticate requests.
+type claims struct {
+	Username  string      `json:"name"`
+	Type      string      `json:"typ"`
+	Role      domain.Role `json:"role"`
+	DentistId int         `json:"dentist_id,omitempty"`
+	PatientId int         `json:"patient_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// sign issues a token of the type for the user, valid for ttl.
+func (s *service) sign(user domain.User, typ string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Username:  user.Username,
+		Type:      typ,
+		Role:      user.Role,
+		DentistId: user.DentistId,
+		PatientId: user.PatientId,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(user.Id),
+			ID:        hex.EncodeToString(id),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	return token.SignedString(s.secret)
+}
+
+// parse checks the signature, the expiry and the type of a token and returns
+// its claims.
+func (s *service) parse(token string, typ string) (*claims, error) {
+	c := &claims{}
+	_, err := jwt.ParseWithClaims(token, c, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
+	if err != nil || c.Type != typ || c.ID == "" {
+		return nil, ErrInvalidToken
+	}
+	return c, nil
+}
+
+// principal returns who the claims of an access token authenticate.
+func (c *claims) principal() (domain.Principal, error) {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return domain.Principal{}, ErrInvalidToken
+	}
+	return domain.Principal{
+		UserId:    id,
+		Username:  c.Username,
+		Role:      c.Role,
+		DentistId: c.DentistId,
+		PatientId: c.PatientId,
+		TokenId:   c.ID,
+		ExpiresAt: c.ExpiresAt.Time,
+	}, nil
+}
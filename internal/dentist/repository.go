@@ -1,74 +1,181 @@
 package dentist
 
 import (
-	
+	"context"
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 	"errors"
-	"fmt"
+	"log/slog"
 	"github.com/JulietaAlfie/backendGo.git/pkg/store"
 )
 
+var ErrNotFound = domain.NotFound("dentist not found")
+
+// ErrNotDeleted is returned when restoring or purging an id that is not of a
+// deleted dentist.
+var ErrNotDeleted = &domain.Error{Code: domain.CodeNotDeleted, Message: "deleted dentist not found"}
+
 type Repository interface {
-	GetAll() []domain.Dentist
-	GetByID(id int) (domain.Dentist, error)
-	Create(dentist domain.Dentist) (domain.Dentist, error)
-	Update(id int, dentist domain.Dentist) (domain.Dentist, error)
-	Delete(id int) error
+	Search(ctx context.Context, query domain.DentistQuery) ([]domain.Dentist, int, error)
+	GetByID(ctx context.Context, id int) (domain.Dentist, error)
+	Create(ctx context.Context, dentist domain.Dentist) (domain.Dentist, error)
+	Update(ctx context.Context, id int, dentist domain.Dentist) (domain.Dentist, error)
+	// Delete returns the appointments cancelled with the dentist, and Restore
+	// the ones restored with it.
+	Delete(ctx context.Context, id int) ([]domain.Appointment, error)
+	Restore(ctx context.Context, id int) ([]domain.Appointment, error)
+	Purge(ctx context.Context, id int) error
+	GetByLicense(ctx context.Context, license string) (domain.Dentist, error)
+	GetSchedule(ctx context.Context, id int) (domain.Schedule, error)
+	UpdateSchedule(ctx context.Context, schedule domain.Schedule) (domain.Schedule, error)
 }
 
 type repository struct {
 	storage store.StoreInterfaceDentist
+	log     *slog.Logger
 }
 
-func NewRepository(storage store.StoreInterfaceDentist) Repository {
-	return &repository{storage}
+func NewRepository(storage store.StoreInterfaceDentist, log *slog.Logger) Repository {
+	return &repository{storage, log}
 }
 
-func (r *repository) GetAll() []domain.Dentist {
-	dentists, err := r.storage.ReadAll()
+func (r *repository) Search(ctx context.Context, query domain.DentistQuery) ([]domain.Dentist, int, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.Search")
+	defer span.End()
+	dentists, total, err := r.storage.Search(ctx, query)
 	if err != nil {
-		return []domain.Dentist{}
+		r.log.ErrorContext(ctx, "listing dentists", "err", err)
+		return []domain.Dentist{}, 0, domain.Internal("dentists could not be brought", err)
 	}
-	return dentists
+	return dentists, total, nil
 }
 
-func (r *repository) GetByID(id int) (domain.Dentist, error) {
-	dentist, err := r.storage.Read(id)
+func (r *repository) GetByID(ctx context.Context, id int) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.GetByID")
+	defer span.End()
+	dentist, err := r.storage.Read(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Dentist{}, ErrNotFound
+	}
 	if err != nil {
-		return domain.Dentist{}, errors.New("dentist not found")
+		r.log.ErrorContext(ctx, "reading dentist", "id", id, "err", err)
+		return domain.Dentist{}, domain.Internal("error reading dentist", err)
 	}
 	return dentist, nil
 
 }
 
-func (r *repository) Create(dentist domain.Dentist) (domain.Dentist, error) {
-	if r.storage.Exists(dentist.License) {
-		return domain.Dentist{}, errors.New("existing dentist license")
+func (r *repository) Create(ctx context.Context, dentist domain.Dentist) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.Create")
+	defer span.End()
+	if r.storage.Exists(ctx, dentist.License) {
+		return domain.Dentist{}, &domain.Error{Code: domain.CodeDuplicateLicense, Message: "existing dentist license"}
+	}
+	id, err := r.storage.Create(ctx, dentist)
+	if errors.Is(err, store.ErrDuplicateLicense) {
+		return domain.Dentist{}, &domain.Error{Code: domain.CodeDuplicateLicense, Message: "that license belongs to a deleted dentist, restore it instead", Err: err}
 	}
-	id, err := r.storage.Create(dentist)
 	if err != nil {
-		fmt.Println(err)
-		return domain.Dentist{}, errors.New("an error occurred creating dentist")
+		r.log.ErrorContext(ctx, "creating dentist", "err", err)
+		return domain.Dentist{}, domain.Internal("an error occurred creating dentist", err)
 	}
 	dentist.Id = id
 	return dentist, nil
 }
 
-func (r *repository) Delete(id int) error {
-	err := r.storage.Delete(id)
+func (r *repository) Delete(ctx context.Context, id int) ([]domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.Delete")
+	defer span.End()
+	appointments, err := r.storage.Delete(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return []domain.Appointment{}, ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "deleting dentist", "id", id, "err", err)
+		return []domain.Appointment{}, domain.Internal("an error occurred deleting dentist", err)
+	}
+	return appointments, nil
+}
+
+func (r *repository) Restore(ctx context.Context, id int) ([]domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.Restore")
+	defer span.End()
+	appointments, err := r.storage.Restore(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return []domain.Appointment{}, ErrNotDeleted
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "restoring dentist", "id", id, "err", err)
+		return []domain.Appointment{}, domain.Internal("an error occurred restoring dentist", err)
+	}
+	return appointments, nil
+}
+
+func (r *repository) Purge(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.Purge")
+	defer span.End()
+	err := r.storage.Purge(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotDeleted
+	}
 	if err != nil {
-		return err
+		r.log.ErrorContext(ctx, "purging dentist", "id", id, "err", err)
+		return domain.Internal("an error occurred purging dentist", err)
 	}
 	return nil
 }
 
-func (r *repository) Update(id int, dentist domain.Dentist) (domain.Dentist, error) {
-	if !r.storage.Exists(dentist.License) {
-		return domain.Dentist{}, errors.New("existing dentist license")
+func (r *repository) Update(ctx context.Context, id int, dentist domain.Dentist) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.Update")
+	defer span.End()
+	err := r.storage.Update(ctx, dentist)
+	if errors.Is(err, store.ErrDuplicateLicense) {
+		return domain.Dentist{}, &domain.Error{Code: domain.CodeDuplicateLicense, Message: "existing dentist license", Err: err}
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Dentist{}, ErrNotFound
 	}
-	err := r.storage.Update(dentist)
 	if err != nil {
-		return domain.Dentist{}, errors.New("an error occurred updating dentist")
+		r.log.ErrorContext(ctx, "updating dentist", "id", id, "err", err)
+		return domain.Dentist{}, domain.Internal("an error occurred updating dentist", err)
 	}
 	return dentist, nil
 }
+
+func (r *repository) GetByLicense(ctx context.Context, license string) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.GetByLicense")
+	defer span.End()
+	dentist, err := r.storage.ReadByLicense(ctx, license)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Dentist{}, ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "reading dentist", "license", license, "err", err)
+		return domain.Dentist{}, domain.Internal("error reading dentist", err)
+	}
+	return dentist, nil
+}
+
+func (r *repository) GetSchedule(ctx context.Context, id int) (domain.Schedule, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.GetSchedule")
+	defer span.End()
+	schedule, err := r.storage.ReadSchedule(ctx, id)
+	if err != nil {
+		r.log.ErrorContext(ctx, "reading schedule", "dentist_id", id, "err", err)
+		return domain.Schedule{}, domain.Internal("an error occurred reading schedule", err)
+	}
+	return schedule, nil
+}
+
+func (r *repository) UpdateSchedule(ctx context.Context, schedule domain.Schedule) (domain.Schedule, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Repository.UpdateSchedule")
+	defer span.End()
+	err := r.storage.UpdateSchedule(ctx, schedule)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Schedule{}, ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "updating schedule", "dentist_id", schedule.DentistId, "err", err)
+		return domain.Schedule{}, domain.Internal("an error occurred updating schedule", err)
+	}
+	return schedule, nil
+}
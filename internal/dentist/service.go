@@ -1,51 +1,83 @@
 package dentist
 
 import (
+	"context"
+	"log/slog"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/JulietaAlfie/backendGo.git/internal/dentist")
+
 type Service interface {
-	GetAll() ([]domain.Dentist, error)
-	GetByID(id int) (domain.Dentist, error)
-	Create(d domain.Dentist) (domain.Dentist, error)
-	Delete(id int) error
-	Update(id int, d domain.Dentist) (domain.Dentist, error)
+	Search(ctx context.Context, query domain.DentistQuery) ([]domain.Dentist, int, error)
+	GetByID(ctx context.Context, id int) (domain.Dentist, error)
+	Create(ctx context.Context, d domain.Dentist) (domain.Dentist, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (domain.Dentist, error)
+	Purge(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, d domain.Dentist) (domain.Dentist, error)
+	GetSchedule(ctx context.Context, id int) (domain.Schedule, error)
+	UpdateSchedule(ctx context.Context, id int, schedule domain.Schedule) (domain.Schedule, error)
 }
 
 type service struct {
-	r Repository
+	r       Repository
+	auditor audit.Recorder
+	log     *slog.Logger
 }
 
 // NewService crea un nuevo servicio
-func NewService(r Repository) Service {
-	return &service{r}
+func NewService(r Repository, auditor audit.Recorder, log *slog.Logger) Service {
+	return &service{r, auditor, log}
 }
 
-func (s *service) GetAll() ([]domain.Dentist, error) {
-	dentists := s.r.GetAll()
-	return dentists, nil
+// Search returns a page of the dentists that match the query and how many
+// match in total. The page holds DefaultPageLimit dentists unless told
+// otherwise, and MaxPageLimit at most.
+func (s *service) Search(ctx context.Context, query domain.DentistQuery) ([]domain.Dentist, int, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Service.Search")
+	defer span.End()
+	query.Page = query.Page.Clamp()
+	return s.r.Search(ctx, query)
 }
 
-func (s *service) GetByID(id int) (domain.Dentist, error) {
-	d, err := s.r.GetByID(id)
+func (s *service) GetByID(ctx context.Context, id int) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Service.GetByID")
+	defer span.End()
+	d, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return domain.Dentist{}, err
 	}
 	return d, nil
 }
 
-func (s *service) Create(d domain.Dentist) (domain.Dentist, error) {
-	d, err := s.r.Create(d)
+func (s *service) Create(ctx context.Context, d domain.Dentist) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Service.Create")
+	defer span.End()
+	err := d.Validate()
+	if err != nil {
+		return domain.Dentist{}, err
+	}
+	d, err = s.r.Create(ctx, d)
 	if err != nil {
 		return domain.Dentist{}, err
 	}
+	s.auditor.Record(ctx, domain.AuditCreate, "dentist", d.Id, nil, d)
+	s.log.InfoContext(ctx, "dentist created", "id", d.Id)
 	return d, nil
 }
-func (s *service) Update(id int, d domain.Dentist) (domain.Dentist, error) {
-	dentist, err := s.r.GetByID(id)
+func (s *service) Update(ctx context.Context, id int, d domain.Dentist) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Service.Update")
+	defer span.End()
+	dentist, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return domain.Dentist{}, err
 	}
+	before := dentist
 	if d.Lastname != "" {
 		dentist.Lastname = d.Lastname
 	}
@@ -55,17 +87,106 @@ func (s *service) Update(id int, d domain.Dentist) (domain.Dentist, error) {
 	if d.License != "" {
 		dentist.License = d.License
 	}
-	dentist, err = s.r.Update(id, dentist)
+	err = dentist.Validate()
+	if err != nil {
+		return domain.Dentist{}, err
+	}
+	dentist, err = s.r.Update(ctx, id, dentist)
+	if err != nil {
+		return domain.Dentist{}, err
+	}
+	s.auditor.Record(ctx, domain.AuditUpdate, "dentist", id, before, dentist)
+	s.log.InfoContext(ctx, "dentist updated", "id", id)
+	return dentist, nil
+}
+
+// Delete soft deletes the dentist and cancels their appointments.
+func (s *service) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "dentist.Service.Delete")
+	defer span.End()
+	dentist, err := s.r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	cancelled, err := s.r.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.auditor.Record(ctx, domain.AuditDelete, "dentist", id, dentist, nil)
+	for _, appointment := range cancelled {
+		s.auditor.Record(ctx, domain.AuditDelete, "appointment", appointment.Id, appointment.AuditRecord(), nil)
+	}
+	metrics.AppointmentsCancelled.Add(float64(len(cancelled)))
+	s.log.InfoContext(ctx, "dentist deleted", "id", id, "appointments_cancelled", len(cancelled))
+	return nil
+}
+
+// Restore brings back a deleted dentist and the appointments cancelled with
+// it, but for those whose slot was booked meanwhile or whose other participant
+// is deleted.
+func (s *service) Restore(ctx context.Context, id int) (domain.Dentist, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Service.Restore")
+	defer span.End()
+	restored, err := s.r.Restore(ctx, id)
+	if err != nil {
+		return domain.Dentist{}, err
+	}
+	dentist, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return domain.Dentist{}, err
 	}
+	s.auditor.Record(ctx, domain.AuditRestore, "dentist", id, nil, dentist)
+	for _, appointment := range restored {
+		s.auditor.Record(ctx, domain.AuditRestore, "appointment", appointment.Id, nil, appointment.AuditRecord())
+	}
+	s.log.InfoContext(ctx, "dentist restored", "id", id, "appointments_restored", len(restored))
 	return dentist, nil
 }
 
-func (s *service) Delete(id int) error {
-	err := s.r.Delete(id)
+// Purge removes a deleted dentist and its appointments for good.
+func (s *service) Purge(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "dentist.Service.Purge")
+	defer span.End()
+	err := s.r.Purge(ctx, id)
 	if err != nil {
 		return err
 	}
+	s.auditor.Record(ctx, domain.AuditPurge, "dentist", id, nil, nil)
+	s.log.InfoContext(ctx, "dentist purged", "id", id)
 	return nil
 }
+
+func (s *service) GetSchedule(ctx context.Context, id int) (domain.Schedule, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Service.GetSchedule")
+	defer span.End()
+	_, err := s.r.GetByID(ctx, id)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	return s.r.GetSchedule(ctx, id)
+}
+
+func (s *service) UpdateSchedule(ctx context.Context, id int, schedule domain.Schedule) (domain.Schedule, error) {
+	ctx, span := tracer.Start(ctx, "dentist.Service.UpdateSchedule")
+	defer span.End()
+	_, err := s.r.GetByID(ctx, id)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	schedule.DentistId = id
+	err = schedule.Validate()
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	before, err := s.r.GetSchedule(ctx, id)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	schedule, err = s.r.UpdateSchedule(ctx, schedule)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	s.auditor.Record(ctx, domain.AuditUpdate, "schedule", id, before, schedule)
+	s.log.InfoContext(ctx, "schedule updated", "dentist_id", id)
+	return schedule, nil
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// APIKey lets a machine client call the API with the permissions of its
+// scopes. Only the hash of the key is kept.
+type APIKey struct {
+	Id         int          `json:"id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"` // first characters of the key, to tell keys apart
+	Hash       string       `json:"-"`
+	Scopes     []Permission `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ExpiresAt  time.Time    `json:"expires_at,omitzero"`   // zero if it never expires
+	LastUsedAt time.Time    `json:"last_used_at,omitzero"` // zero if it was never used
+}
+
+// Expired reports whether the key expired at now.
+func (k APIKey) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
@@ -1,12 +1,97 @@
 package domain
 
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultDuration is the length of an appointment whose treatment has no
+// default duration.
+const DefaultDuration = 30
+
+// TreatmentDurations holds the default length in minutes of each treatment,
+// used when an appointment is booked without a duration.
+var TreatmentDurations = map[string]int{
+	"checkup":    15,
+	"cleaning":   30,
+	"filling":    45,
+	"extraction": 60,
+	"root_canal": 90,
+}
+
 type Appointment struct {
-	Id          int     `json:"id"`
-	Patient     Patient `json:"patient" binding:"required"`
-	Dentist     Dentist `json:"dentist" binding:"required"`
-	Date        string  `json:"date" binding:"required"`
-	Time        string  `json:"time" binding:"required"`
-	Description string  `json:"description" binding:"required"`
+	Id          int       `json:"id"`
+	Patient     Patient   `json:"patient"`
+	Dentist     Dentist   `json:"dentist"`
+	Date        time.Time `json:"date"`
+	Duration    int       `json:"duration_minutes"` // minutes, by default the treatment duration
+	End         time.Time `json:"end"`              // computed from Date and Duration
+	Treatment   string    `json:"treatment"`
+	Description string    `json:"description"`
+}
+
+// Validate returns the fields of the appointment that are missing or invalid:
+// the patient and the dentist by id, the date, a duration of MaxDuration at
+// most and texts that fit their columns.
+func (a Appointment) Validate() error {
+	v := a.validate()
+	return v.err()
+}
+
+// ValidateBooking is Validate for an appointment being booked or rescheduled,
+// which must also start after now.
+func (a Appointment) ValidateBooking(now time.Time) error {
+	v := a.validate()
+	if !a.Date.IsZero() {
+		v.check(a.Date.After(now), "date", "date must be in the future")
+	}
+	return v.err()
+}
+
+func (a Appointment) validate() validation {
+	v := validation{}
+	v.reference("patient.id", a.Patient.Id)
+	v.reference("dentist.id", a.Dentist.Id)
+	v.date("date", a.Date)
+	v.check(a.Duration >= 0, "duration_minutes", "duration_minutes can't be negative")
+	v.check(a.Duration <= MaxDuration, "duration_minutes", fmt.Sprintf("duration_minutes must be at most %d", MaxDuration))
+	v.maxLength("treatment", a.Treatment)
+	v.text("description", a.Description)
+	return v
 }
 
+// SetEnd fills in the default duration of the treatment when the appointment
+// has none and computes its end.
+func (a *Appointment) SetEnd() {
+	if a.Duration <= 0 {
+		a.Duration = DefaultDuration
+		if minutes, ok := TreatmentDurations[a.Treatment]; ok {
+			a.Duration = minutes
+		}
+	}
+	a.End = a.Date.Add(time.Duration(a.Duration) * time.Minute)
+}
 
+// Overlaps reports whether the intervals of both appointments intersect.
+func (a Appointment) Overlaps(other Appointment) bool {
+	return a.Date.Before(other.End) && other.Date.Before(a.End)
+}
+
+// ConflictError is returned when an appointment overlaps an existing booking
+// of the same dentist or the same patient.
+type ConflictError struct {
+	Appointment Appointment
+	Dentist     bool
+}
+
+func (e *ConflictError) ErrorCode() string {
+	return CodeSlotConflict
+}
+
+func (e *ConflictError) Error() string {
+	who := fmt.Sprintf("patient %d", e.Appointment.Patient.Id)
+	if e.Dentist {
+		who = fmt.Sprintf("dentist %d", e.Appointment.Dentist.Id)
+	}
+	return fmt.Sprintf("%s already has appointment %d from %s to %s", who, e.Appointment.Id, e.Appointment.Date.Format(time.RFC3339), e.Appointment.End.Format(time.RFC3339))
+}
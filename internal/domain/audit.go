@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions of the audit entries.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditEntry records who changed a record, when and how. Changes maps each
+// field that changed to its value before and after, as JSON.
+type AuditEntry struct {
+	Id        int             `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityId  int             `json:"entity_id"`
+	Changes   json.RawMessage `json:"changes" swaggertype:"object"`
+	RequestId string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditQuery filters the audit entries; zero fields match every entry. From is
+// included and To excluded.
+type AuditQuery struct {
+	Entity   string
+	EntityId int
+	Actor    string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// AppointmentRecord is what the audit log keeps of an appointment: its patient
+// and dentist by id only.
+type AppointmentRecord struct {
+	PatientId   int       `json:"patient_id"`
+	DentistId   int       `json:"dentist_id"`
+	Date        time.Time `json:"date"`
+	Duration    int       `json:"duration_minutes"`
+	End         time.Time `json:"end"`
+	Treatment   string    `json:"treatment"`
+	Description string    `json:"description"`
+}
+
+func (a Appointment) AuditRecord() AppointmentRecord {
+	return AppointmentRecord{
+		PatientId:   a.Patient.Id,
+		DentistId:   a.Dentist.Id,
+		Date:        a.Date,
+		Duration:    a.Duration,
+		End:         a.End,
+		Treatment:   a.Treatment,
+		Description: a.Description,
+	}
+}
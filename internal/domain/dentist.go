@@ -2,7 +2,21 @@ package domain
 
 type Dentist struct {
 	Id       int    `json:"id"`
-	Lastname string `json:"lastname" binding:"required"`
-	Name     string `json:"name" binding:"required"`
-	License  string `json:"license" binding:"required"`
+	Lastname string `json:"lastname"`
+	Name     string `json:"name"`
+	License  string `json:"license"`
+}
+
+// Validate returns the fields of the dentist that are missing or invalid: the
+// texts must fit their columns and the license must look like 0009-1111.
+func (d Dentist) Validate() error {
+	v := validation{}
+	v.text("lastname", d.Lastname)
+	v.text("name", d.Name)
+	if d.License == "" {
+		v.check(false, "license", "license was empty")
+	} else {
+		v.check(licensePattern.MatchString(d.License), "license", "license must look like 0009-1111")
+	}
+	return v.err()
 }
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Stable codes of the errors, for clients to switch on instead of parsing
+// messages.
+const (
+	CodeNotFound            = "not_found"
+	CodeNotDeleted          = "not_deleted"
+	CodeDuplicate           = "duplicate"
+	CodeDuplicateDNI        = "duplicate_dni"
+	CodeDuplicateLicense    = "duplicate_license"
+	CodeDuplicateUsername   = "duplicate_username"
+	CodeSlotConflict        = "slot_conflict"
+	CodeOutsideWorkingHours = "outside_working_hours"
+	CodeParticipantDeleted  = "participant_deleted"
+	CodeValidation          = "validation_failed"
+	CodeInternal            = "internal_error"
+)
+
+// Error is an error with a stable code. Errors made with the same code and
+// message are still distinct: compare them with errors.Is against the
+// sentinels, or by code with ErrorCode.
+type Error struct {
+	Code    string
+	Message string
+	Fields  []FieldError // of a validation failure
+	Err     error        // the cause, if any
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) ErrorCode() string {
+	return e.Code
+}
+
+// FieldError tells why a field of a request is invalid. Field is its path in
+// the request, such as "patient.id", or the name of the query or path
+// parameter.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// NotFound returns an error with CodeNotFound.
+func NotFound(message string) *Error {
+	return &Error{Code: CodeNotFound, Message: message}
+}
+
+// Internal returns an error with CodeInternal that hides its cause from the
+// message.
+func Internal(message string, err error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Err: err}
+}
+
+// Invalid returns a validation failure of one field.
+func Invalid(field string, message string) *Error {
+	return InvalidFields(FieldError{Field: field, Message: message})
+}
+
+// InvalidFields returns a validation failure of every field given, with their
+// messages joined as its own.
+func InvalidFields(fields ...FieldError) *Error {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return &Error{Code: CodeValidation, Message: strings.Join(messages, "; "), Fields: fields}
+}
+
+// ErrorCode returns the code of the first error in the chain of err that has
+// one, or "" if none has.
+func ErrorCode(err error) string {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return ""
+}
+
+// InvalidFieldsOf returns the fields of the validation failure in the chain of
+// err, if any.
+func InvalidFieldsOf(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) && e.Code == CodeValidation {
+		return e.Fields
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Scores of a word of a search against a word of a patient, from the best.
+const (
+	matchExact  = 4
+	matchPrefix = 3
+	matchInfix  = 2
+	matchTypo   = 1
+)
+
+// minInfixLength is the shortest term that matches a part of a word.
+const minInfixLength = 3
+
+// SearchTerms splits the text of a search into words, lowercased and without
+// accents.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SearchText is what the stores keep of a patient to prefilter the searches
+// with FilterTerm: the words of its name, lastname and residence, as
+// SearchTerms splits them, and its DNI, each after a space.
+func (p Patient) SearchText() string {
+	words := SearchTerms(p.Name + " " + p.Lastname + " " + p.Residence)
+	return " " + strings.Join(append(words, strconv.Itoa(p.DNI)), " ")
+}
+
+// TermFilter narrows a search to the patients whose SearchText contains
+// Contains or has a word that starts with WordPrefix; an empty field is left
+// out.
+type TermFilter struct {
+	Contains   string
+	WordPrefix string
+}
+
+// FilterTerm returns a filter that keeps every patient MatchPatient may match
+// with the term: a whole word, the start of a word or a part of it holds the
+// term, and a word with typos starts with its first letter.
+func FilterTerm(term string) TermFilter {
+	if isNumber(term) {
+		return TermFilter{Contains: term}
+	}
+	filter := TermFilter{WordPrefix: term}
+	if len(term) >= minInfixLength {
+		filter = TermFilter{Contains: term}
+	}
+	if typosAllowed(term) > 0 {
+		filter.WordPrefix = string([]rune(term)[:1])
+	}
+	return filter
+}
+
+// RankPatients returns the patients that match every term, the best matches
+// first and ties by lastname, name and id.
+func RankPatients(patients []Patient, terms []string) []Patient {
+	type match struct {
+		patient Patient
+		score   int
+	}
+	matches := []match{}
+	for _, patient := range patients {
+		if score := MatchPatient(patient, terms); score > 0 {
+			matches = append(matches, match{patient, score})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(a.patient.Lastname, b.patient.Lastname),
+			cmp.Compare(a.patient.Name, b.patient.Name),
+			cmp.Compare(a.patient.Id, b.patient.Id),
+		)
+	})
+	ranked := make([]Patient, len(matches))
+	for i, m := range matches {
+		ranked[i] = m.patient
+	}
+	return ranked
+}
+
+// MatchPatient scores how well the patient matches the terms, 0 when some term
+// matches nothing. Each term scores its best match in the name or lastname, or
+// half of it in the residence: a whole word beats the start of a word, which
+// beats a part of it, which beats a word with typos but in its first letter.
+// Terms of digits match the DNI, whole, from the start or in part, and never
+// with typos.
+func MatchPatient(patient Patient, terms []string) int {
+	if len(terms) == 0 {
+		return 0
+	}
+	names := SearchTerms(patient.Name + " " + patient.Lastname)
+	residence := SearchTerms(patient.Residence)
+	dni := strconv.Itoa(patient.DNI)
+	total := 0
+	for _, term := range terms {
+		score := 0
+		if isNumber(term) {
+			score = 2 * matchWord(term, dni, false)
+		} else {
+			for _, word := range names {
+				score = max(score, 2*matchWord(term, word, true))
+			}
+			for _, word := range residence {
+				score = max(score, matchWord(term, word, true))
+			}
+		}
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+	return total
+}
+
+func matchWord(term string, word string, typos bool) int {
+	switch {
+	case word == term:
+		return matchExact
+	case strings.HasPrefix(word, term):
+		return matchPrefix
+	case len(term) >= minInfixLength && strings.Contains(word, term):
+		return matchInfix
+	}
+	if !typos {
+		return 0
+	}
+	allowed := typosAllowed(term)
+	if allowed == 0 {
+		return 0
+	}
+	t, w := []rune(term), []rune(word)
+	if t[0] != w[0] {
+		return 0
+	}
+	if distance(t, w) <= allowed {
+		return matchTypo
+	}
+	// The term may be the start of the word, typed with typos.
+	if len(w) > len(t) && distance(t, w[:len(t)]) <= allowed {
+		return matchTypo
+	}
+	return 0
+}
+
+// typosAllowed is how many edits a term may be away from a word: none for
+// short terms, where one edit matches too much.
+func typosAllowed(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	}
+	return 2
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func isNumber(term string) bool {
+	return strings.IndexFunc(term, func(r rune) bool { return r < '0' || r > '9' }) == -1
+}
+
+// fold lowercases s and strips its accents, so that "Gómez" reads "gomez".
+func fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+	return strings.ToLower(folded)
+}
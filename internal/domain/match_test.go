@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		want []string
+	}{
+		{"Gómez", []string{"gomez"}},
+		{"  PEÑA,josé  ", []string{"pena", "jose"}},
+		{"Müller-Ibáñez", []string{"muller", "ibanez"}},
+		{"DNI 30.123.456", []string{"dni", "30", "123", "456"}},
+		{"", []string{}},
+		{" ,.- ", []string{}},
+	} {
+		got := SearchTerms(test.text)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("SearchTerms(%q): got %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestMatchPatient(t *testing.T) {
+	patient := Patient{Name: "José", Lastname: "Gómez", Residence: "Córdoba 500", DNI: 30123456}
+	for _, test := range []struct {
+		text string
+		want int
+	}{
+		{"gomez", 2 * matchExact},
+		{"GÓMEZ", 2 * matchExact},
+		{"gom", 2 * matchPrefix},
+		{"ome", 2 * matchInfix},
+		{"om", 0},
+		{"gomes", 2 * matchTypo},
+		{"gomze", 0},
+		{"homez", 0},
+		{"jos", 2 * matchPrefix},
+		{"jsoe", 0},
+		{"cordoba", matchExact},
+		{"cordova", matchTypo},
+		{"30123456", 2 * matchExact},
+		{"3012", 2 * matchPrefix},
+		{"123", 2 * matchInfix},
+		{"30123457", 0},
+		{"500", 0},
+		{"jose gomez", 4 * matchExact},
+		{"jose perez", 0},
+		{"", 0},
+	} {
+		got := MatchPatient(patient, SearchTerms(test.text))
+		if got != test.want {
+			t.Errorf("MatchPatient(%q): got %d, want %d", test.text, got, test.want)
+		}
+	}
+}
+
+func TestMatchWordTypos(t *testing.T) {
+	for _, test := range []struct {
+		term string
+		word string
+		want int
+	}{
+		{"per", "pez", 0},
+		{"pere", "perez", matchPrefix},
+		{"pare", "perez", matchTypo},
+		{"perz", "perez", matchTypo},
+		{"prez", "perez", matchTypo},
+		{"pzez", "perez", 0},
+		{"rodriguez", "rodrigues", matchTypo},
+		{"rodrigez", "rodriguez", matchTypo},
+		{"rodrgez", "rodriguez", 0},
+		{"rdrgez", "rodriguez", 0},
+		{"fernandes", "fernandez", matchTypo},
+		{"hernandez", "fernandez", 0},
+	} {
+		got := matchWord(test.term, test.word, true)
+		if got != test.want {
+			t.Errorf("matchWord(%q, %q): got %d, want %d", test.term, test.word, got, test.want)
+		}
+		if got := matchWord(test.term, test.word, false); got == matchTypo {
+			t.Errorf("matchWord(%q, %q) without typos: got a typo match", test.term, test.word)
+		}
+	}
+}
+
+func TestRankPatients(t *testing.T) {
+	patients := []Patient{
+		{Id: 1, Name: "Josefina", Lastname: "Peña", Residence: "Córdoba 500"},
+		{Id: 2, Name: "Ana", Lastname: "Córdoba", Residence: "Mitre 10"},
+		{Id: 3, Name: "Mario", Lastname: "Cordobes", Residence: "Calle 9"},
+		{Id: 4, Name: "Luis", Lastname: "Córdoba", Residence: "Mitre 10"},
+		{Id: 5, Name: "Ana", Lastname: "Córdoba", Residence: "Mitre 10"},
+		{Id: 6, Name: "Juan", Lastname: "Perez", Residence: "Calle 9"},
+	}
+	for _, test := range []struct {
+		text string
+		want []int
+	}{
+		// A word of the name beats one of the residence, but not with typos;
+		// ties by lastname, name and id.
+		{"cordoba", []int{2, 5, 4, 1, 3}},
+		{"cordova", []int{2, 5, 4, 1}},
+		{"ana cordoba", []int{2, 5}},
+		{"mitre", []int{2, 5, 4}},
+		{"xyz", []int{}},
+		{"", []int{}},
+	} {
+		got := []int{}
+		for _, patient := range RankPatients(patients, SearchTerms(test.text)) {
+			got = append(got, patient.Id)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("RankPatients(%q): got %v, want %v", test.text, got, test.want)
+		}
+	}
+}
+
+// TestFilterTerm checks that the filter keeps every patient MatchPatient
+// matches, as the SQL stores rank only the patients it keeps.
+func TestFilterTerm(t *testing.T) {
+	patients := []Patient{
+		{Name: "José", Lastname: "Gómez", Residence: "Córdoba 500", DNI: 30123456},
+		{Name: "María Inés", Lastname: "Núñez-Ibáñez", Residence: "Av. Mitre 10, 2do", DNI: 1234567},
+		{Name: "Ana", Lastname: "O'Brien", Residence: "Calle 9", DNI: 40555666},
+	}
+	terms := []string{
+		"jose", "jo", "j", "gomes", "ome", "om", "cordova", "ordo", "500",
+		"maria", "ines", "inez", "nunez", "nunes", "ibanez", "ibañes", "bane", "mitre", "mitra", "2do",
+		"ana", "obrien", "brien", "brian", "o", "calle", "9",
+		"30123456", "3012", "123", "1234567", "4055", "homez", "xyz",
+	}
+	for _, patient := range patients {
+		text := patient.SearchText()
+		for _, term := range SearchTerms(strings.Join(terms, " ")) {
+			if MatchPatient(patient, []string{term}) == 0 {
+				continue
+			}
+			filter := FilterTerm(term)
+			kept := filter.Contains != "" && strings.Contains(text, filter.Contains) ||
+				filter.WordPrefix != "" && strings.Contains(text, " "+filter.WordPrefix)
+			if !kept {
+				t.Errorf("FilterTerm(%q) = %+v leaves out %q, which matches", term, filter, text)
+			}
+		}
+	}
+}
+
+func TestSearchText(t *testing.T) {
+	patient := Patient{Name: "José", Lastname: "Núñez-Ibáñez", Residence: "Av. Mitre 10", DNI: 30123456}
+	want := " jose nunez ibanez av mitre 10 30123456"
+	if got := patient.SearchText(); got != want {
+		t.Errorf("SearchText: got %q, want %q", got, want)
+	}
+}
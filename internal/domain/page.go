@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	// DefaultPageLimit is how many items a list returns when no limit is
+	// given, and MaxPageLimit the most it returns.
+	DefaultPageLimit = 50
+	MaxPageLimit     = 500
+)
+
+// Sort keys of the lists; the first of each is the default.
+var (
+	AppointmentSortKeys = []string{"date", "id"}
+	PatientSortKeys     = []string{"id", "lastname", "name", "dni"}
+	DentistSortKeys     = []string{"id", "lastname", "name", "license"}
+)
+
+// Page selects Limit items of a list, after skipping Offset, sorted by Sort.
+// Ties are broken by id, in the same direction.
+type Page struct {
+	Limit  int
+	Offset int
+	Sort   string // one of the sort keys of the list; empty for the default
+	Desc   bool
+}
+
+// Clamp returns the page with the default limit if none was given and the
+// limit capped at MaxPageLimit.
+func (p Page) Clamp() Page {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	p.Limit = min(p.Limit, MaxPageLimit)
+	p.Offset = max(p.Offset, 0)
+	return p
+}
+
+// SortBy returns the sort key of the page among keys, the first of them when
+// the page has none or an unknown one.
+func (p Page) SortBy(keys []string) string {
+	if slices.Contains(keys, p.Sort) {
+		return p.Sort
+	}
+	return keys[0]
+}
+
+// Statuses of an appointment, by whether it starts after now.
+const (
+	AppointmentUpcoming = "upcoming"
+	AppointmentPast     = "past"
+)
+
+// AppointmentQuery filters the appointments; zero fields match every
+// appointment. From is included and To excluded, compared with the start of
+// the appointments.
+type AppointmentQuery struct {
+	DentistId  int
+	PatientId  int
+	PatientDNI int
+	From       time.Time
+	To         time.Time
+	Page
+}
+
+// WithStatus narrows the query to the upcoming appointments, which start at
+// now or later, or to the past ones, which started before now.
+func (q *AppointmentQuery) WithStatus(status string, now time.Time) error {
+	switch status {
+	case AppointmentUpcoming:
+		if q.From.Before(now) {
+			q.From = now
+		}
+	case AppointmentPast:
+		if q.To.IsZero() || q.To.After(now) {
+			q.To = now
+		}
+	default:
+		return Invalid("status", fmt.Sprintf("status must be %s or %s", AppointmentUpcoming, AppointmentPast))
+	}
+	return nil
+}
+
+// PatientQuery filters the patients by the start of their lastname, ignoring
+// case.
+type PatientQuery struct {
+	Lastname string
+	Page
+}
+
+// DentistQuery filters the dentists by the start of their lastname, ignoring
+// case.
+type DentistQuery struct {
+	Lastname string
+	Page
+}
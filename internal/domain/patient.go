@@ -1,10 +1,31 @@
 package domain
 
+import "time"
+
 type Patient struct {
-	Id            int    `json:"id"`
-	Name          string `json:"name" binding:"required"`
-	Lastname      string `json:"lastname" binding:"required"`
-	Residence     string `json:"residence" binding:"required"`
-	DNI           int    `json:"dni" binding:"required"`
-	DischargeDate string `json:"discharge_date" binding:"required"`
+	Id            int       `json:"id"`
+	Name          string    `json:"name"`
+	Lastname      string    `json:"lastname"`
+	Residence     string    `json:"residence"`
+	DNI           int       `json:"dni"`
+	DischargeDate time.Time `json:"discharge_date"`
+}
+
+// Validate returns the fields of the patient that are missing or invalid: the
+// texts must fit their columns, the DNI must have 7 or 8 digits and the
+// patient cannot be discharged after now.
+func (p Patient) Validate(now time.Time) error {
+	v := validation{}
+	v.text("lastname", p.Lastname)
+	v.text("name", p.Name)
+	v.text("residence", p.Residence)
+	if p.DNI == 0 {
+		v.check(false, "dni", "dni was empty")
+	} else {
+		v.check(p.DNI >= MinDNI && p.DNI <= MaxDNI, "dni", "dni must have 7 or 8 digits")
+	}
+	if v.date("discharge_date", p.DischargeDate) {
+		v.check(!p.DischargeDate.After(now), "discharge_date", "discharge_date can't be in the future")
+	}
+	return v.err()
 }
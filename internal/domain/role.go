@@ -0,0 +1,70 @@
+package domain
+
+// Role decides what a user is allowed to do.
+type Role string
+
+const (
+	RoleAdmin        Role = "admin"
+	RoleReceptionist Role = "receptionist"
+	RoleDentist      Role = "dentist"
+	RolePatient      Role = "patient"
+)
+
+// Permission is an action on a group of routes.
+type Permission string
+
+const (
+	ReadPatients         Permission = "patients:read"
+	WritePatients        Permission = "patients:write"
+	WriteDentists        Permission = "dentists:write"
+	ReadAppointments     Permission = "appointments:read"
+	WriteAppointments    Permission = "appointments:write"
+	AnnotateAppointments Permission = "appointments:annotate" // change the description
+	ManageUsers          Permission = "users:manage"
+	ManageAPIKeys        Permission = "api-keys:manage"
+	ReadAudit            Permission = "audit:read"
+	PurgeRecords         Permission = "records:purge" // remove deleted records for good
+)
+
+// permissions is the matrix of what each role can do. Dentists and patients
+// are further limited to their own appointments, see Principal.CanAccess.
+// Admins have every permission.
+var permissions = map[Role][]Permission{
+	RoleAdmin: {
+		ReadPatients, WritePatients, WriteDentists,
+		ReadAppointments, WriteAppointments, AnnotateAppointments,
+		ManageUsers, ManageAPIKeys, ReadAudit, PurgeRecords,
+	},
+	RoleReceptionist: {
+		ReadPatients, WritePatients,
+		ReadAppointments, WriteAppointments, AnnotateAppointments,
+	},
+	RoleDentist: {
+		ReadPatients,
+		ReadAppointments, AnnotateAppointments,
+	},
+	RolePatient: {
+		ReadAppointments,
+	},
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := permissions[r]
+	return ok
+}
+
+// Valid reports whether p is one of the known permissions.
+func (p Permission) Valid() bool {
+	return RoleAdmin.Can(p)
+}
+
+// Can reports whether the role has the permission.
+func (r Role) Can(p Permission) bool {
+	for _, permission := range permissions[r] {
+		if permission == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "testing"
+
+// allPermissions lists every permission, to check the roles against all of
+// them and not only the ones they have.
+var allPermissions = []Permission{
+	ReadPatients, WritePatients, WriteDentists,
+	ReadAppointments, WriteAppointments, AnnotateAppointments,
+	ManageUsers, ManageAPIKeys, ReadAudit, PurgeRecords,
+}
+
+func TestRoleCan(t *testing.T) {
+	for _, test := range []struct {
+		role Role
+		want []Permission
+	}{
+		{RoleAdmin, allPermissions},
+		{RoleReceptionist, []Permission{ReadPatients, WritePatients, ReadAppointments, WriteAppointments, AnnotateAppointments}},
+		{RoleDentist, []Permission{ReadPatients, ReadAppointments, AnnotateAppointments}},
+		{RolePatient, []Permission{ReadAppointments}},
+		{"", nil},
+		{"superuser", nil},
+	} {
+		allowed := map[Permission]bool{}
+		for _, permission := range test.want {
+			allowed[permission] = true
+		}
+		for _, permission := range allPermissions {
+			if got := test.role.Can(permission); got != allowed[permission] {
+				t.Errorf("Role(%q).Can(%s): got %v, want %v", test.role, permission, got, allowed[permission])
+			}
+		}
+		if test.role.Can("patients:delete") {
+			t.Errorf("Role(%q).Can an unknown permission", test.role)
+		}
+	}
+}
+
+func TestRoleValid(t *testing.T) {
+	for _, role := range []Role{RoleAdmin, RoleReceptionist, RoleDentist, RolePatient} {
+		if !role.Valid() {
+			t.Errorf("Role(%q).Valid: got false, want true", role)
+		}
+	}
+	for _, role := range []Role{"", "Admin", "superuser"} {
+		if role.Valid() {
+			t.Errorf("Role(%q).Valid: got true, want false", role)
+		}
+	}
+}
+
+func TestPermissionValid(t *testing.T) {
+	for _, permission := range allPermissions {
+		if !permission.Valid() {
+			t.Errorf("Permission(%q).Valid: got false, want true", permission)
+		}
+	}
+	for _, permission := range []Permission{"", "patients:delete", "*"} {
+		if permission.Valid() {
+			t.Errorf("Permission(%q).Valid: got true, want false", permission)
+		}
+	}
+}
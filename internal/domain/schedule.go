@@ -0,0 +1,189 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DateLayout is the ISO-8601 format of calendar dates, e.g. "2020-03-20".
+	DateLayout = "2006-01-02"
+	// TimeLayout is the format of the working hours, e.g. "15:30".
+	TimeLayout = "15:04"
+)
+
+var ErrOutsideWorkingHours = &Error{Code: CodeOutsideWorkingHours, Message: "appointment is outside the dentist's working hours"}
+
+// Schedule is the weekly working-hours schedule of a dentist. A dentist
+// without working days has no schedule and can be booked at any time.
+type Schedule struct {
+	DentistId int          `json:"dentist_id"`
+	Days      []WorkingDay `json:"days"`
+}
+
+// WorkingDay holds the hours a dentist works on a weekday (0 is Sunday),
+// split into slots of SlotMinutes and skipping the breaks.
+type WorkingDay struct {
+	Weekday     time.Weekday `json:"weekday" swaggertype:"integer"`
+	Start       string       `json:"start"`
+	End         string       `json:"end"`
+	SlotMinutes int          `json:"slot_minutes"`
+	Breaks      []Break      `json:"breaks"`
+}
+
+type Break struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Slot is a free appointment slot of a dentist.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Validate checks every working day: a weekday from 0 to 6 given once, a
+// start before the end, positive slots and breaks inside the working hours.
+// The fields are named after their place, as in "days[1].breaks[0].end".
+func (s Schedule) Validate() error {
+	var v validation
+	seen := map[time.Weekday]bool{}
+	for i, day := range s.Days {
+		field := fmt.Sprintf("days[%d]", i)
+		if day.Weekday < time.Sunday || day.Weekday > time.Saturday {
+			v.check(false, field+".weekday", fmt.Sprintf("%s.weekday must be from 0 (Sunday) to 6 (Saturday)", field))
+		} else {
+			v.check(!seen[day.Weekday], field+".weekday", fmt.Sprintf("weekday %d is repeated", day.Weekday))
+			seen[day.Weekday] = true
+		}
+		start, end, ok := v.clockRange(field, day.Start, day.End)
+		v.check(day.SlotMinutes > 0, field+".slot_minutes", field+".slot_minutes must be positive")
+		for j, b := range day.Breaks {
+			breakField := fmt.Sprintf("%s.breaks[%d]", field, j)
+			bStart, bEnd, bOk := v.clockRange(breakField, b.Start, b.End)
+			if ok && bOk {
+				v.check(bStart >= start && bEnd <= end, breakField, fmt.Sprintf("break %s-%s is outside working hours", b.Start, b.End))
+			}
+		}
+	}
+	return v.err()
+}
+
+// clockRange checks the start and the end of a range of hours under field and
+// returns them, or false if they are not valid.
+func (v *validation) clockRange(field string, start string, end string) (time.Duration, time.Duration, bool) {
+	from, startErr := parseClock(start)
+	v.check(startErr == nil, field+".start", fmt.Sprintf("%s.start %q must have the format hh:mm", field, start))
+	to, endErr := parseClock(end)
+	v.check(endErr == nil, field+".end", fmt.Sprintf("%s.end %q must have the format hh:mm", field, end))
+	if startErr != nil || endErr != nil {
+		return 0, 0, false
+	}
+	v.check(from < to, field+".end", fmt.Sprintf("%s.end %s must be after start %s", field, end, start))
+	return from, to, from < to
+}
+
+// Slots returns the start of every slot the dentist works on the given date.
+func (s Schedule) Slots(date time.Time) []time.Time {
+	day, ok := s.day(date.Weekday())
+	if !ok {
+		return nil
+	}
+	start, end, err := parseRange(day.Start, day.End)
+	if err != nil || day.SlotMinutes <= 0 {
+		return nil
+	}
+	length := time.Duration(day.SlotMinutes) * time.Minute
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	var slots []time.Time
+	for from := start; from+length <= end; from += length {
+		if !day.inBreak(from, from+length) {
+			slots = append(slots, midnight.Add(from))
+		}
+	}
+	return slots
+}
+
+// SlotLength returns how long a slot lasts on the given weekday, or zero when
+// the dentist does not work that day.
+func (s Schedule) SlotLength(weekday time.Weekday) time.Duration {
+	day, ok := s.day(weekday)
+	if !ok {
+		return 0
+	}
+	return time.Duration(day.SlotMinutes) * time.Minute
+}
+
+// Allows returns ErrOutsideWorkingHours unless an appointment from start to
+// end, read in the location of start, falls inside the working hours and
+// outside the breaks.
+func (s Schedule) Allows(start time.Time, end time.Time) error {
+	if len(s.Days) == 0 {
+		return nil
+	}
+	day, ok := s.day(start.Weekday())
+	if !ok {
+		return ErrOutsideWorkingHours
+	}
+	begin, finish, err := parseRange(day.Start, day.End)
+	if err != nil {
+		return err
+	}
+	from := sinceMidnight(start)
+	to := from + end.Sub(start)
+	if from < begin || to > finish || day.inBreak(from, to) {
+		return ErrOutsideWorkingHours
+	}
+	return nil
+}
+
+func (s Schedule) day(weekday time.Weekday) (WorkingDay, bool) {
+	for _, day := range s.Days {
+		if day.Weekday == weekday {
+			return day, true
+		}
+	}
+	return WorkingDay{}, false
+}
+
+func (d WorkingDay) inBreak(from, to time.Duration) bool {
+	for _, b := range d.Breaks {
+		bStart, bEnd, err := parseRange(b.Start, b.End)
+		if err != nil {
+			continue
+		}
+		if from < bEnd && bStart < to {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock converts "hh:mm" into the time elapsed since midnight.
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse(TimeLayout, value)
+	if err != nil {
+		return 0, err
+	}
+	return sinceMidnight(t), nil
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+func parseRange(start, end string) (time.Duration, time.Duration, error) {
+	from, err := parseClock(start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("start %q must have the format hh:mm", start)
+	}
+	to, err := parseClock(end)
+	if err != nil {
+		return 0, 0, fmt.Errorf("end %q must have the format hh:mm", end)
+	}
+	if to <= from {
+		return 0, 0, fmt.Errorf("start %s must be before end %s", start, end)
+	}
+	return from, to, nil
+}
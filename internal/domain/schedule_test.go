@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// weekdays works Monday mornings with a break, Tuesday mornings, Saturday
+// until midnight and Sunday from midnight.
+var weekdays = Schedule{Days: []WorkingDay{
+	{Weekday: time.Monday, Start: "09:00", End: "13:00", SlotMinutes: 30, Breaks: []Break{{Start: "11:00", End: "11:30"}}},
+	{Weekday: time.Tuesday, Start: "09:00", End: "10:45", SlotMinutes: 30},
+	{Weekday: time.Saturday, Start: "22:00", End: "23:59", SlotMinutes: 60},
+	{Weekday: time.Sunday, Start: "00:00", End: "01:00", SlotMinutes: 30},
+}}
+
+// at returns the time of the day of now, a Monday, plus days.
+func at(days int, clock string) time.Time {
+	t, err := time.Parse(TimeLayout, clock)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(now.Year(), now.Month(), now.Day()+days, t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+func TestScheduleValidate(t *testing.T) {
+	day := func(change func(d *WorkingDay)) Schedule {
+		d := WorkingDay{Weekday: time.Monday, Start: "09:00", End: "13:00", SlotMinutes: 30, Breaks: []Break{{Start: "11:00", End: "11:30"}}}
+		change(&d)
+		return Schedule{Days: []WorkingDay{d}}
+	}
+	for _, test := range []struct {
+		name     string
+		schedule Schedule
+		want     []string
+	}{
+		{"valid", weekdays, nil},
+		{"no days", Schedule{}, nil},
+		{"from midnight", day(func(d *WorkingDay) { d.Start, d.Breaks = "00:00", nil }), nil},
+		{"break at the start", day(func(d *WorkingDay) { d.Breaks[0] = Break{Start: "09:00", End: "09:30"} }), nil},
+		{"break at the end", day(func(d *WorkingDay) { d.Breaks[0] = Break{Start: "12:30", End: "13:00"} }), nil},
+		{"weekday 7", day(func(d *WorkingDay) { d.Weekday = 7 }), []string{"days[0].weekday"}},
+		{"negative weekday", day(func(d *WorkingDay) { d.Weekday = -1 }), []string{"days[0].weekday"}},
+		{"repeated weekday", Schedule{Days: []WorkingDay{weekdays.Days[0], weekdays.Days[1], weekdays.Days[0]}}, []string{"days[2].weekday"}},
+		{"bad start", day(func(d *WorkingDay) { d.Start = "9am" }), []string{"days[0].start"}},
+		{"bad end", day(func(d *WorkingDay) { d.End = "24:00" }), []string{"days[0].end"}},
+		{"empty hours", day(func(d *WorkingDay) { d.Start, d.End = "", "" }), []string{"days[0].start", "days[0].end"}},
+		{"end before start", day(func(d *WorkingDay) { d.Start, d.End, d.Breaks = "13:00", "09:00", nil }), []string{"days[0].end"}},
+		{"end at start", day(func(d *WorkingDay) { d.End, d.Breaks = "09:00", nil }), []string{"days[0].end"}},
+		{"no slots", day(func(d *WorkingDay) { d.SlotMinutes = 0 }), []string{"days[0].slot_minutes"}},
+		{"break before", day(func(d *WorkingDay) { d.Breaks[0] = Break{Start: "08:30", End: "09:30"} }), []string{"days[0].breaks[0]"}},
+		{"break after", day(func(d *WorkingDay) { d.Breaks[0] = Break{Start: "12:30", End: "13:30"} }), []string{"days[0].breaks[0]"}},
+		{"bad break", day(func(d *WorkingDay) { d.Breaks[0].End = "11" }), []string{"days[0].breaks[0].end"}},
+		{"reversed break", day(func(d *WorkingDay) { d.Breaks[0] = Break{Start: "11:30", End: "11:00"} }), []string{"days[0].breaks[0].end"}},
+		{"every error", day(func(d *WorkingDay) { d.Weekday, d.End, d.SlotMinutes = 9, "08:00", -30 }), []string{"days[0].weekday", "days[0].end", "days[0].slot_minutes"}},
+	} {
+		got := invalidFields(t, test.schedule.Validate())
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got invalid fields %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestScheduleSlots(t *testing.T) {
+	buenosAires := time.FixedZone("ART", -3*60*60)
+	for _, test := range []struct {
+		name string
+		date time.Time
+		want []string
+	}{
+		{"skipping the break", at(0, "08:00"), []string{"09:00", "09:30", "10:00", "10:30", "11:30", "12:00", "12:30"}},
+		{"whole slots only", at(1, "00:00"), []string{"09:00", "09:30", "10:00"}},
+		{"until midnight", at(5, "12:00"), []string{"22:00"}},
+		{"from midnight", at(6, "23:00"), []string{"00:00", "00:30"}},
+		{"not working", at(2, "09:00"), nil},
+	} {
+		var got []string
+		for _, slot := range weekdays.Slots(test.date) {
+			if !sameDay(slot, test.date) {
+				t.Errorf("Slots %s: got slot %v, not on %v", test.name, slot, test.date)
+			}
+			got = append(got, slot.Format(TimeLayout))
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Slots %s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	// The slots are in the location of the date.
+	date := time.Date(2025, time.March, 10, 23, 0, 0, 0, buenosAires)
+	slots := weekdays.Slots(date)
+	if len(slots) == 0 || slots[0].Location() != buenosAires || slots[0].Format(TimeLayout) != "09:00" {
+		t.Errorf("Slots in Buenos Aires: got %v, want from 09:00 there", slots)
+	}
+	if got := (Schedule{}).Slots(now); got != nil {
+		t.Errorf("Slots without schedule: got %v, want none", got)
+	}
+}
+
+func sameDay(a time.Time, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+func TestScheduleAllows(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		start   time.Time
+		minutes int
+		allowed bool
+	}{
+		{"inside", at(0, "09:30"), 30, true},
+		{"at the start", at(0, "09:00"), 30, true},
+		{"until the end", at(0, "12:00"), 60, true},
+		{"until the break", at(0, "10:30"), 30, true},
+		{"from the break", at(0, "11:30"), 30, true},
+		{"not on the slots", at(0, "09:10"), 45, true},
+		{"before the start", at(0, "08:30"), 60, false},
+		{"past the end", at(0, "12:30"), 60, false},
+		{"over the break", at(0, "10:45"), 30, false},
+		{"in the break", at(0, "11:00"), 15, false},
+		{"across the break", at(0, "10:00"), 120, false},
+		{"not working", at(2, "10:00"), 30, false},
+		{"before midnight", at(5, "22:30"), 29, true},
+		{"across midnight", at(5, "23:30"), 60, false},
+		{"from midnight", at(6, "00:00"), 60, true},
+	} {
+		err := weekdays.Allows(test.start, test.start.Add(time.Duration(test.minutes)*time.Minute))
+		if test.allowed && err != nil || !test.allowed && !errors.Is(err, ErrOutsideWorkingHours) {
+			t.Errorf("Allows %s: got error %v, want allowed %v", test.name, err, test.allowed)
+		}
+	}
+
+	err := Schedule{}.Allows(at(2, "03:00"), at(2, "04:00"))
+	if err != nil {
+		t.Errorf("Allows without schedule: got error %v, want nil", err)
+	}
+}
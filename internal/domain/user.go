@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// User is an account that can sign in to the API. Dentists and patients are
+// linked to their record, which limits the appointments they can see.
+type User struct {
+	Id           int    `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+	Role         Role   `json:"role"`
+	DentistId    int    `json:"dentist_id,omitempty"`
+	PatientId    int    `json:"patient_id,omitempty"`
+}
+
+// Principal is the user or the API key a request is authenticated as.
+type Principal struct {
+	UserId    int          `json:"user_id,omitempty"`
+	Username  string       `json:"username,omitempty"`
+	Role      Role         `json:"role,omitempty"`
+	DentistId int          `json:"dentist_id,omitempty"`
+	PatientId int          `json:"patient_id,omitempty"`
+	KeyId     int          `json:"key_id,omitempty"`
+	Scopes    []Permission `json:"scopes,omitempty"` // of the API key
+	TokenId   string       `json:"-"`                // of the access token, to revoke it
+	ExpiresAt time.Time    `json:"-"`
+}
+
+// Actor names the principal in the audit log: the username of users and
+// "api-key:<id>" for API keys.
+func (p Principal) Actor() string {
+	if p.KeyId != 0 {
+		return fmt.Sprintf("api-key:%d", p.KeyId)
+	}
+	return p.Username
+}
+
+// Can reports whether the principal has the permission: users through their
+// role and API keys through their scopes.
+func (p Principal) Can(permission Permission) bool {
+	if p.KeyId != 0 {
+		return slices.Contains(p.Scopes, permission)
+	}
+	return p.Role.Can(permission)
+}
+
+// CanAccess reports whether the principal may see the appointment: dentists
+// only see theirs, patients only their own and the staff and the API keys
+// every one.
+func (p Principal) CanAccess(a Appointment) bool {
+	if p.KeyId != 0 {
+		return true
+	}
+	switch p.Role {
+	case RoleDentist:
+		return p.DentistId != 0 && a.Dentist.Id == p.DentistId
+	case RolePatient:
+		return p.PatientId != 0 && a.Patient.Id == p.PatientId
+	}
+	return p.Role.Valid()
+}
+
+// Restrict narrows the query to the appointments the principal may see, as
+// CanAccess does, and reports whether there is any left to search.
+func (p Principal) Restrict(query *AppointmentQuery) bool {
+	if p.KeyId != 0 {
+		return true
+	}
+	switch p.Role {
+	case RoleDentist:
+		if p.DentistId == 0 || (query.DentistId != 0 && query.DentistId != p.DentistId) {
+			return false
+		}
+		query.DentistId = p.DentistId
+	case RolePatient:
+		if p.PatientId == 0 || (query.PatientId != 0 && query.PatientId != p.PatientId) {
+			return false
+		}
+		query.PatientId = p.PatientId
+	}
+	return p.Role.Valid()
+}
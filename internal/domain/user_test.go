@@ -0,0 +1,100 @@
+package domain
+
+import "testing"
+
+var (
+	admin        = Principal{UserId: 1, Username: "admin", Role: RoleAdmin}
+	receptionist = Principal{UserId: 2, Username: "recepcion", Role: RoleReceptionist}
+	dentist      = Principal{UserId: 3, Username: "aperez", Role: RoleDentist, DentistId: 7}
+	patient      = Principal{UserId: 4, Username: "jgomez", Role: RolePatient, PatientId: 9}
+	apiKey       = Principal{KeyId: 5, Scopes: []Permission{ReadAppointments}}
+)
+
+func TestPrincipalCan(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		principal Principal
+		want      []Permission
+	}{
+		{"admin", admin, allPermissions},
+		{"dentist", dentist, []Permission{ReadPatients, ReadAppointments, AnnotateAppointments}},
+		{"patient", patient, []Permission{ReadAppointments}},
+		// API keys have their scopes only, whatever the role says.
+		{"api key", apiKey, []Permission{ReadAppointments}},
+		{"api key with a role", Principal{KeyId: 5, Role: RoleAdmin, Scopes: []Permission{ReadAudit}}, []Permission{ReadAudit}},
+		{"api key without scopes", Principal{KeyId: 5}, nil},
+		{"no role", Principal{UserId: 6, Username: "nadie"}, nil},
+	} {
+		allowed := map[Permission]bool{}
+		for _, permission := range test.want {
+			allowed[permission] = true
+		}
+		for _, permission := range allPermissions {
+			if got := test.principal.Can(permission); got != allowed[permission] {
+				t.Errorf("Can %s for %s: got %v, want %v", permission, test.name, got, allowed[permission])
+			}
+		}
+	}
+}
+
+func TestPrincipalCanAccess(t *testing.T) {
+	appointment := func(dentistId, patientId int) Appointment {
+		return Appointment{Id: 1, Dentist: Dentist{Id: dentistId}, Patient: Patient{Id: patientId}}
+	}
+	for _, test := range []struct {
+		name        string
+		principal   Principal
+		appointment Appointment
+		want        bool
+	}{
+		{"admin", admin, appointment(1, 2), true},
+		{"receptionist", receptionist, appointment(1, 2), true},
+		{"api key", apiKey, appointment(1, 2), true},
+		{"dentist on theirs", dentist, appointment(7, 2), true},
+		{"dentist on another's", dentist, appointment(1, 2), false},
+		{"dentist on the patient with their id", dentist, appointment(1, 7), false},
+		{"unlinked dentist", Principal{UserId: 3, Role: RoleDentist}, appointment(0, 2), false},
+		{"patient on their own", patient, appointment(1, 9), true},
+		{"patient on another's", patient, appointment(1, 2), false},
+		{"patient on the dentist with their id", patient, appointment(9, 2), false},
+		{"unlinked patient", Principal{UserId: 4, Role: RolePatient}, appointment(1, 0), false},
+		{"no role", Principal{UserId: 6}, appointment(1, 2), false},
+		{"unknown role", Principal{UserId: 6, Role: "superuser"}, appointment(1, 2), false},
+	} {
+		if got := test.principal.CanAccess(test.appointment); got != test.want {
+			t.Errorf("CanAccess for %s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPrincipalRestrict(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		principal Principal
+		query     AppointmentQuery
+		want      AppointmentQuery
+		ok        bool
+	}{
+		{"admin", admin, AppointmentQuery{DentistId: 1}, AppointmentQuery{DentistId: 1}, true},
+		{"receptionist", receptionist, AppointmentQuery{PatientId: 2}, AppointmentQuery{PatientId: 2}, true},
+		{"api key", apiKey, AppointmentQuery{}, AppointmentQuery{}, true},
+		{"dentist", dentist, AppointmentQuery{}, AppointmentQuery{DentistId: 7}, true},
+		{"dentist asking for theirs", dentist, AppointmentQuery{DentistId: 7, PatientId: 2}, AppointmentQuery{DentistId: 7, PatientId: 2}, true},
+		{"dentist asking for another's", dentist, AppointmentQuery{DentistId: 1}, AppointmentQuery{DentistId: 1}, false},
+		{"unlinked dentist", Principal{UserId: 3, Role: RoleDentist}, AppointmentQuery{}, AppointmentQuery{}, false},
+		{"patient", patient, AppointmentQuery{}, AppointmentQuery{PatientId: 9}, true},
+		{"patient by dni", patient, AppointmentQuery{PatientDNI: 30123456}, AppointmentQuery{PatientId: 9, PatientDNI: 30123456}, true},
+		{"patient asking for another's", patient, AppointmentQuery{PatientId: 2}, AppointmentQuery{PatientId: 2}, false},
+		{"unlinked patient", Principal{UserId: 4, Role: RolePatient}, AppointmentQuery{}, AppointmentQuery{}, false},
+		{"no role", Principal{UserId: 6}, AppointmentQuery{}, AppointmentQuery{}, false},
+	} {
+		query := test.query
+		ok := test.principal.Restrict(&query)
+		if ok != test.ok {
+			t.Errorf("Restrict for %s: got %v, want %v", test.name, ok, test.ok)
+		}
+		if ok && query != test.want {
+			t.Errorf("Restrict for %s: got query %+v, want %+v", test.name, query, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTextLength is the most characters the text columns, varchar(45), hold.
+	MaxTextLength = 45
+	// MinDNI and MaxDNI bound the DNIs, of 7 or 8 digits.
+	MinDNI = 1_000_000
+	MaxDNI = 99_999_999
+	// MaxDuration is the longest appointment, in minutes.
+	MaxDuration = 8 * 60
+)
+
+// licensePattern is the format of the dentist licenses, such as "0009-1111".
+var licensePattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{4}$`)
+
+// validation collects every field that breaks a rule, to report them all at
+// once.
+type validation []FieldError
+
+func (v *validation) check(ok bool, field string, message string) {
+	if !ok {
+		*v = append(*v, FieldError{Field: field, Message: message})
+	}
+}
+
+// text checks that a required text is not blank and fits its column.
+func (v *validation) text(field string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.check(false, field, field+" was empty")
+		return
+	}
+	v.maxLength(field, value)
+}
+
+func (v *validation) maxLength(field string, value string) {
+	v.check(utf8.RuneCountInString(value) <= MaxTextLength, field, fmt.Sprintf("%s must be at most %d characters", field, MaxTextLength))
+}
+
+// reference checks that a required entity is given by id.
+func (v *validation) reference(field string, id int) {
+	v.check(id != 0, field, strings.TrimSuffix(field, ".id")+" was empty")
+	v.check(id >= 0, field, field+" must be positive")
+}
+
+func (v *validation) date(field string, value time.Time) bool {
+	v.check(!value.IsZero(), field, field+" was empty")
+	return !value.IsZero()
+}
+
+func (v validation) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return InvalidFields(v...)
+}
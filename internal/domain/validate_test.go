@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+// invalidFields returns the fields of the validation failure err, or fails the
+// test if err is another error.
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	if code := ErrorCode(err); code != CodeValidation {
+		t.Fatalf("got error %v with code %q, want %q", err, code, CodeValidation)
+	}
+	fields := []string{}
+	for _, field := range InvalidFieldsOf(err) {
+		fields = append(fields, field.Field)
+	}
+	return fields
+}
+
+func TestPatientValidate(t *testing.T) {
+	valid := Patient{Name: "Juan", Lastname: "Gomez", Residence: "Calle 123", DNI: 30123456, DischargeDate: now.AddDate(0, 0, -1)}
+	for _, test := range []struct {
+		name   string
+		change func(p *Patient)
+		want   []string
+	}{
+		{"valid", func(p *Patient) {}, nil},
+		{"discharged now", func(p *Patient) { p.DischargeDate = now }, nil},
+		{"7 digits DNI", func(p *Patient) { p.DNI = MinDNI }, nil},
+		{"8 digits DNI", func(p *Patient) { p.DNI = MaxDNI }, nil},
+		{"45 characters", func(p *Patient) { p.Residence = strings.Repeat("ñ", MaxTextLength) }, nil},
+		{"empty", func(p *Patient) { *p = Patient{} }, []string{"lastname", "name", "residence", "dni", "discharge_date"}},
+		{"blank name", func(p *Patient) { p.Name = "  " }, []string{"name"}},
+		{"46 characters", func(p *Patient) { p.Lastname = strings.Repeat("a", MaxTextLength+1) }, []string{"lastname"}},
+		{"6 digits DNI", func(p *Patient) { p.DNI = MinDNI - 1 }, []string{"dni"}},
+		{"9 digits DNI", func(p *Patient) { p.DNI = MaxDNI + 1 }, []string{"dni"}},
+		{"negative DNI", func(p *Patient) { p.DNI = -30123456 }, []string{"dni"}},
+		{"discharged tomorrow", func(p *Patient) { p.DischargeDate = now.AddDate(0, 0, 1) }, []string{"discharge_date"}},
+	} {
+		p := valid
+		test.change(&p)
+		got := invalidFields(t, p.Validate(now))
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got invalid fields %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDentistValidate(t *testing.T) {
+	valid := Dentist{Name: "Ana", Lastname: "Perez", License: "0009-1111"}
+	for _, test := range []struct {
+		name   string
+		change func(d *Dentist)
+		want   []string
+	}{
+		{"valid", func(d *Dentist) {}, nil},
+		{"empty", func(d *Dentist) { *d = Dentist{} }, []string{"lastname", "name", "license"}},
+		{"license without dash", func(d *Dentist) { d.License = "00091111" }, []string{"license"}},
+		{"short license", func(d *Dentist) { d.License = "009-1111" }, []string{"license"}},
+		{"license with letters", func(d *Dentist) { d.License = "000A-1111" }, []string{"license"}},
+		{"license with spaces", func(d *Dentist) { d.License = " 0009-1111" }, []string{"license"}},
+		{"long name", func(d *Dentist) { d.Name = strings.Repeat("a", MaxTextLength+1) }, []string{"name"}},
+	} {
+		d := valid
+		test.change(&d)
+		got := invalidFields(t, d.Validate())
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got invalid fields %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAppointmentValidate(t *testing.T) {
+	valid := Appointment{
+		Patient:     Patient{Id: 1},
+		Dentist:     Dentist{Id: 2},
+		Date:        now.Add(time.Hour),
+		Treatment:   "cleaning",
+		Description: "control",
+	}
+	for _, test := range []struct {
+		name    string
+		change  func(a *Appointment)
+		want    []string
+		booking []string // the invalid fields of ValidateBooking
+	}{
+		{"valid", func(a *Appointment) {}, nil, nil},
+		{"default duration", func(a *Appointment) { a.Duration = 0 }, nil, nil},
+		{"longest", func(a *Appointment) { a.Duration = MaxDuration }, nil, nil},
+		{"without treatment", func(a *Appointment) { a.Treatment = "" }, nil, nil},
+		{"empty", func(a *Appointment) { *a = Appointment{} }, []string{"patient.id", "dentist.id", "date", "description"}, []string{"patient.id", "dentist.id", "date", "description"}},
+		{"negative ids", func(a *Appointment) { a.Patient.Id, a.Dentist.Id = -1, -2 }, []string{"patient.id", "dentist.id"}, []string{"patient.id", "dentist.id"}},
+		{"negative duration", func(a *Appointment) { a.Duration = -1 }, []string{"duration_minutes"}, []string{"duration_minutes"}},
+		{"too long", func(a *Appointment) { a.Duration = MaxDuration + 1 }, []string{"duration_minutes"}, []string{"duration_minutes"}},
+		{"long treatment", func(a *Appointment) { a.Treatment = strings.Repeat("a", MaxTextLength+1) }, []string{"treatment"}, []string{"treatment"}},
+		{"blank description", func(a *Appointment) { a.Description = " " }, []string{"description"}, []string{"description"}},
+		{"past", func(a *Appointment) { a.Date = now.Add(-time.Hour) }, nil, []string{"date"}},
+		{"starting now", func(a *Appointment) { a.Date = now }, nil, []string{"date"}},
+		{"past and without description", func(a *Appointment) { a.Date, a.Description = now.Add(-time.Hour), "" }, []string{"description"}, []string{"description", "date"}},
+	} {
+		a := valid
+		test.change(&a)
+		got := invalidFields(t, a.Validate())
+		if !slices.Equal(got, test.want) {
+			t.Errorf("Validate %s: got invalid fields %v, want %v", test.name, got, test.want)
+		}
+		got = invalidFields(t, a.ValidateBooking(now))
+		if !slices.Equal(got, test.booking) {
+			t.Errorf("ValidateBooking %s: got invalid fields %v, want %v", test.name, got, test.booking)
+		}
+	}
+}
+
+func TestInvalidFieldsMessage(t *testing.T) {
+	err := Dentist{}.Validate()
+	want := "lastname was empty; name was empty; license was empty"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
@@ -1,75 +1,170 @@
 package patient
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"log/slog"
+
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 	"github.com/JulietaAlfie/backendGo.git/pkg/store"
 )
 
+var ErrNotFound = domain.NotFound("patient not found")
+
+// ErrNotDeleted is returned when restoring or purging an id that is not of a
+// deleted patient.
+var ErrNotDeleted = &domain.Error{Code: domain.CodeNotDeleted, Message: "deleted patient not found"}
+
 type Repository interface {
-	GetAll() []domain.Patient
-	GetByID(id int) (domain.Patient, error)
-	Create(od domain.Patient) (domain.Patient, error)
-	Update(id int, od domain.Patient) (domain.Patient, error)
-	Delete(id int) error
+	Search(ctx context.Context, query domain.PatientQuery) ([]domain.Patient, int, error)
+	Find(ctx context.Context, text string, page domain.Page) ([]domain.Patient, int, error)
+	GetByID(ctx context.Context, id int) (domain.Patient, error)
+	GetByDNI(ctx context.Context, dni int) (domain.Patient, error)
+	Create(ctx context.Context, od domain.Patient) (domain.Patient, error)
+	Update(ctx context.Context, id int, od domain.Patient) (domain.Patient, error)
+	// Delete returns the appointments cancelled with the patient, and Restore
+	// the ones restored with it.
+	Delete(ctx context.Context, id int) ([]domain.Appointment, error)
+	Restore(ctx context.Context, id int) ([]domain.Appointment, error)
+	Purge(ctx context.Context, id int) error
 }
 
 type repository struct {
 	storage store.StoreInterfacePatient
+	log     *slog.Logger
 }
 
-func NewRepository(storage store.StoreInterfacePatient) Repository {
-	return &repository{storage}
+func NewRepository(storage store.StoreInterfacePatient, log *slog.Logger) Repository {
+	return &repository{storage, log}
 }
 
-func (r *repository) GetAll() []domain.Patient {
-	patients, err := r.storage.ReadAll()
+func (r *repository) Search(ctx context.Context, query domain.PatientQuery) ([]domain.Patient, int, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.Search")
+	defer span.End()
+	patients, total, err := r.storage.Search(ctx, query)
 	if err != nil {
+		r.log.ErrorContext(ctx, "listing patients", "err", err)
+		return []domain.Patient{}, 0, domain.Internal("patients could not be brought", err)
+	}
+	return patients, total, nil
+}
 
-		fmt.Println(patients, err)
-		return []domain.Patient{}
+func (r *repository) Find(ctx context.Context, text string, page domain.Page) ([]domain.Patient, int, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.Find")
+	defer span.End()
+	patients, total, err := r.storage.Find(ctx, text, page)
+	if err != nil {
+		r.log.ErrorContext(ctx, "finding patients", "err", err)
+		return []domain.Patient{}, 0, domain.Internal("patients could not be brought", err)
 	}
-	return patients
+	return patients, total, nil
 }
 
-func (r *repository) GetByID(id int) (domain.Patient, error) {
-	patient, err := r.storage.Read(id)
+func (r *repository) GetByID(ctx context.Context, id int) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.GetByID")
+	defer span.End()
+	patient, err := r.storage.Read(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Patient{}, ErrNotFound
+	}
 	if err != nil {
-		return domain.Patient{}, errors.New("patient not found")
+		r.log.ErrorContext(ctx, "reading patient", "id", id, "err", err)
+		return domain.Patient{}, domain.Internal("error reading patient", err)
 	}
 	return patient, nil
 
 }
 
-func (r *repository) Create(pac domain.Patient) (domain.Patient, error) {
-	if r.storage.Exists(pac.DNI) {
-		return domain.Patient{}, errors.New("that dni already exists")
+func (r *repository) GetByDNI(ctx context.Context, dni int) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.GetByDNI")
+	defer span.End()
+	patient, err := r.storage.ReadByDNI(ctx, dni)
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Patient{}, ErrNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "reading patient", "dni", dni, "err", err)
+		return domain.Patient{}, domain.Internal("error reading patient", err)
+	}
+	return patient, nil
+}
+
+func (r *repository) Create(ctx context.Context, pac domain.Patient) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.Create")
+	defer span.End()
+	if r.storage.Exists(ctx, pac.DNI) {
+		return domain.Patient{}, &domain.Error{Code: domain.CodeDuplicateDNI, Message: "that dni already exists"}
+	}
+	id, err := r.storage.Create(ctx, pac)
+	if errors.Is(err, store.ErrDuplicateDNI) {
+		return domain.Patient{}, &domain.Error{Code: domain.CodeDuplicateDNI, Message: "that dni belongs to a deleted patient, restore it instead", Err: err}
 	}
-	id, err := r.storage.Create(pac)
 	if err != nil {
-		fmt.Printf("err: %v\n", err)
-		return domain.Patient{}, errors.New("error creating patient")
+		r.log.ErrorContext(ctx, "creating patient", "err", err)
+		return domain.Patient{}, domain.Internal("error creating patient", err)
 	}
 	pac.Id = id
 	return pac, nil
 }
 
-func (r *repository) Delete(id int) error {
-	err := r.storage.Delete(id)
+func (r *repository) Delete(ctx context.Context, id int) ([]domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.Delete")
+	defer span.End()
+	appointments, err := r.storage.Delete(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return []domain.Appointment{}, ErrNotFound
+	}
 	if err != nil {
-		return err
+		r.log.ErrorContext(ctx, "deleting patient", "id", id, "err", err)
+		return []domain.Appointment{}, domain.Internal("error deleting patient", err)
+	}
+	return appointments, nil
+}
+
+func (r *repository) Restore(ctx context.Context, id int) ([]domain.Appointment, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.Restore")
+	defer span.End()
+	appointments, err := r.storage.Restore(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return []domain.Appointment{}, ErrNotDeleted
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "restoring patient", "id", id, "err", err)
+		return []domain.Appointment{}, domain.Internal("error restoring patient", err)
+	}
+	return appointments, nil
+}
+
+func (r *repository) Purge(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "patient.Repository.Purge")
+	defer span.End()
+	err := r.storage.Purge(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return ErrNotDeleted
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "purging patient", "id", id, "err", err)
+		return domain.Internal("error purging patient", err)
 	}
 	return nil
 }
 
-func (r *repository) Update(id int, pac domain.Patient) (domain.Patient, error) {
-	if !r.storage.Exists(pac.DNI) {
-		return domain.Patient{}, errors.New("existing identity document")
+func (r *repository) Update(ctx context.Context, id int, pac domain.Patient) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Repository.Update")
+	defer span.End()
+	if !r.storage.Exists(ctx, pac.DNI) {
+		return domain.Patient{}, domain.Invalid("dni", "existing identity document")
+	}
+	err := r.storage.Update(ctx, pac)
+	if errors.Is(err, store.ErrDuplicateDNI) {
+		return domain.Patient{}, &domain.Error{Code: domain.CodeDuplicateDNI, Message: "that dni already exists", Err: err}
+	}
+	if errors.Is(err, store.ErrNotFound) {
+		return domain.Patient{}, ErrNotFound
 	}
-	err := r.storage.Update(pac)
 	if err != nil {
-		return domain.Patient{}, errors.New("error updating patient")
+		r.log.ErrorContext(ctx, "updating patient", "id", id, "err", err)
+		return domain.Patient{}, domain.Internal("error updating patient", err)
 	}
 	return pac, nil
 }
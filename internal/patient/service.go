@@ -1,48 +1,90 @@
 package patient
 
-import "github.com/JulietaAlfie/backendGo.git/internal/domain"
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/JulietaAlfie/backendGo.git/internal/patient")
 
 type Service interface {
-	GetAll() ([]domain.Patient, error)
-	GetByID(id int) (domain.Patient, error)
-	Create(pac domain.Patient) (domain.Patient, error)
-	Delete(id int) error
-	Update(id int, pac domain.Patient) (domain.Patient, error)
+	Search(ctx context.Context, query domain.PatientQuery) ([]domain.Patient, int, error)
+	Find(ctx context.Context, text string, page domain.Page) ([]domain.Patient, int, error)
+	GetByID(ctx context.Context, id int) (domain.Patient, error)
+	Create(ctx context.Context, pac domain.Patient) (domain.Patient, error)
+	Delete(ctx context.Context, id int) error
+	Restore(ctx context.Context, id int) (domain.Patient, error)
+	Purge(ctx context.Context, id int) error
+	Update(ctx context.Context, id int, pac domain.Patient) (domain.Patient, error)
 }
 
 type service struct {
-	r Repository
+	r       Repository
+	auditor audit.Recorder
+	log     *slog.Logger
+}
+
+func NewService(r Repository, auditor audit.Recorder, log *slog.Logger) Service {
+	return &service{r, auditor, log}
 }
 
-func NewService(r Repository) Service {
-	return &service{r}
+// Search returns a page of the patients that match the query and how many
+// match in total. The page holds DefaultPageLimit patients unless told
+// otherwise, and MaxPageLimit at most.
+func (s *service) Search(ctx context.Context, query domain.PatientQuery) ([]domain.Patient, int, error) {
+	ctx, span := tracer.Start(ctx, "patient.Service.Search")
+	defer span.End()
+	query.Page = query.Page.Clamp()
+	return s.r.Search(ctx, query)
 }
 
-func (s *service) GetAll() ([]domain.Patient, error) {
-	patients := s.r.GetAll()
-	return patients, nil
+// Find returns a page of the patients that match the text of a search, the
+// best matches first, and how many match in total.
+func (s *service) Find(ctx context.Context, text string, page domain.Page) ([]domain.Patient, int, error) {
+	ctx, span := tracer.Start(ctx, "patient.Service.Find")
+	defer span.End()
+	return s.r.Find(ctx, text, page.Clamp())
 }
 
-func (s *service) GetByID(id int) (domain.Patient, error) {
-	pac, err := s.r.GetByID(id)
+func (s *service) GetByID(ctx context.Context, id int) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Service.GetByID")
+	defer span.End()
+	pac, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return domain.Patient{}, err
 	}
 	return pac, nil
 }
 
-func (s *service) Create(pac domain.Patient) (domain.Patient, error) {
-	pac, err := s.r.Create(pac)
+func (s *service) Create(ctx context.Context, pac domain.Patient) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Service.Create")
+	defer span.End()
+	err := pac.Validate(time.Now())
 	if err != nil {
 		return domain.Patient{}, err
 	}
+	pac, err = s.r.Create(ctx, pac)
+	if err != nil {
+		return domain.Patient{}, err
+	}
+	s.auditor.Record(ctx, domain.AuditCreate, "patient", pac.Id, nil, pac)
+	s.log.InfoContext(ctx, "patient created", "id", pac.Id)
 	return pac, nil
 }
-func (s *service) Update(id int, pac domain.Patient) (domain.Patient, error) {
-	pacien, err := s.r.GetByID(id)
+func (s *service) Update(ctx context.Context, id int, pac domain.Patient) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Service.Update")
+	defer span.End()
+	pacien, err := s.r.GetByID(ctx, id)
 	if err != nil {
 		return domain.Patient{}, err
 	}
+	before := pacien
 	if pac.Name != "" {
 		pacien.Name = pac.Name
 	}
@@ -55,20 +97,74 @@ func (s *service) Update(id int, pac domain.Patient) (domain.Patient, error) {
 	if pac.DNI != 0 {
 		pacien.DNI = pac.DNI
 	}
-	if pac.DischargeDate != "" {
+	if !pac.DischargeDate.IsZero() {
 		pacien.DischargeDate = pac.DischargeDate
 	}
-	pacien, err = s.r.Update(id, pacien)
+	err = pacien.Validate(time.Now())
 	if err != nil {
 		return domain.Patient{}, err
 	}
+	pacien, err = s.r.Update(ctx, id, pacien)
+	if err != nil {
+		return domain.Patient{}, err
+	}
+	s.auditor.Record(ctx, domain.AuditUpdate, "patient", id, before, pacien)
+	s.log.InfoContext(ctx, "patient updated", "id", id)
 	return pacien, nil
 }
 
-func (s *service) Delete(id int) error {
-	err := s.r.Delete(id)
+// Delete soft deletes the patient and cancels their appointments.
+func (s *service) Delete(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "patient.Service.Delete")
+	defer span.End()
+	pac, err := s.r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	cancelled, err := s.r.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.auditor.Record(ctx, domain.AuditDelete, "patient", id, pac, nil)
+	for _, appointment := range cancelled {
+		s.auditor.Record(ctx, domain.AuditDelete, "appointment", appointment.Id, appointment.AuditRecord(), nil)
+	}
+	metrics.AppointmentsCancelled.Add(float64(len(cancelled)))
+	s.log.InfoContext(ctx, "patient deleted", "id", id, "appointments_cancelled", len(cancelled))
+	return nil
+}
+
+// Restore brings back a deleted patient and the appointments cancelled with
+// it, but for those whose slot was booked meanwhile or whose other participant
+// is deleted.
+func (s *service) Restore(ctx context.Context, id int) (domain.Patient, error) {
+	ctx, span := tracer.Start(ctx, "patient.Service.Restore")
+	defer span.End()
+	restored, err := s.r.Restore(ctx, id)
+	if err != nil {
+		return domain.Patient{}, err
+	}
+	pac, err := s.r.GetByID(ctx, id)
+	if err != nil {
+		return domain.Patient{}, err
+	}
+	s.auditor.Record(ctx, domain.AuditRestore, "patient", id, nil, pac)
+	for _, appointment := range restored {
+		s.auditor.Record(ctx, domain.AuditRestore, "appointment", appointment.Id, nil, appointment.AuditRecord())
+	}
+	s.log.InfoContext(ctx, "patient restored", "id", id, "appointments_restored", len(restored))
+	return pac, nil
+}
+
+// Purge removes a deleted patient and its appointments for good.
+func (s *service) Purge(ctx context.Context, id int) error {
+	ctx, span := tracer.Start(ctx, "patient.Service.Purge")
+	defer span.End()
+	err := s.r.Purge(ctx, id)
 	if err != nil {
 		return err
 	}
+	s.auditor.Record(ctx, domain.AuditPurge, "patient", id, nil, nil)
+	s.log.InfoContext(ctx, "patient purged", "id", id)
 	return nil
 }
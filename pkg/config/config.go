@@ -0,0 +1,380 @@
+// Package config loads the settings of the server. Each setting takes, from
+// lowest to highest precedence, its default, the value in the optional YAML
+// config file, the environment variable and the command-line flag.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// SecretPlaceholder is the auth secret of the example configs, refused so a
+// server is never started with a secret published in the repository.
+const SecretPlaceholder = "change-me-to-at-least-32-random-bytes"
+
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Database Database `yaml:"database"`
+	Clinic   Clinic   `yaml:"clinic"`
+	Auth     Auth     `yaml:"auth"`
+	Log      Log      `yaml:"log"`
+	Tracing  Tracing  `yaml:"tracing"`
+}
+
+type Server struct {
+	Addr            string        `yaml:"addr"` // address to listen on
+	Host            string        `yaml:"host"` // public host shown in the swagger docs
+	Mode            string        `yaml:"mode"` // gin mode: debug, release or test
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // to drain in-flight requests
+	RequestTimeout  time.Duration `yaml:"request_timeout"`  // deadline of each request, 0 for none
+}
+
+type Database struct {
+	Driver          string        `yaml:"driver"` // mysql, postgres or sqlite
+	DSN             string        `yaml:"dsn"`    // empty for the default of the driver
+	MigrateOnStart  bool          `yaml:"migrate_on_start"`
+	MaxOpenConns    int           `yaml:"max_open_conns"` // 0 is unlimited
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"` // 0 is unlimited
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	ConnectRetries  int           `yaml:"connect_retries"` // pings after the first one on start-up
+	ConnectBackoff  time.Duration `yaml:"connect_backoff"` // wait before the first retry, doubled on each
+}
+
+type Clinic struct {
+	Timezone string         `yaml:"timezone"`
+	Location *time.Location `yaml:"-"` // loaded from Timezone by Validate
+}
+
+type Auth struct {
+	Secret        string        `yaml:"secret"` // signs the tokens, at least 32 bytes
+	AccessTTL     time.Duration `yaml:"access_ttl"`
+	RefreshTTL    time.Duration `yaml:"refresh_ttl"`
+	AdminUsername string        `yaml:"admin_username"`
+	AdminPassword string        `yaml:"admin_password"` // creates the admin on start if set
+}
+
+type Log struct {
+	Level  string `yaml:"level"`  // debug, info, warn or error
+	Format string `yaml:"format"` // json or text
+}
+
+type Tracing struct {
+	Exporter    string  `yaml:"exporter"` // none, stdout or otlp
+	Endpoint    string  `yaml:"endpoint"` // OTLP/HTTP URL, OTEL_EXPORTER_OTLP_ENDPOINT if empty
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+func Default() Config {
+	return Config{
+		Server: Server{
+			Addr:            ":8080",
+			Host:            "localhost:8080",
+			Mode:            "debug",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+			RequestTimeout:  5 * time.Second,
+		},
+		Database: Database{
+			Driver:          "mysql",
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			ConnectRetries:  5,
+			ConnectBackoff:  time.Second,
+		},
+		Clinic: Clinic{
+			Timezone: "America/Argentina/Buenos_Aires",
+		},
+		Auth: Auth{
+			AccessTTL:     15 * time.Minute,
+			RefreshTTL:    7 * 24 * time.Hour,
+			AdminUsername: "admin",
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load builds the configuration from the command-line arguments, without the
+// program name, and the environment, and returns it along with the arguments
+// left after the flags. Variables in a .env file in the working directory are
+// added to the environment, without overriding it.
+func Load(args []string) (Config, []string, error) {
+	cfg := Default()
+
+	// Flags are applied last, once the file and the environment are read.
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	file := flags.String("config", "", "YAML config file (CONFIG_FILE)")
+	var fromFlags []func() error
+	value := func(name string, usage string, apply func(string) error) {
+		flags.Func(name, usage, func(s string) error {
+			fromFlags = append(fromFlags, func() error { return apply(s) })
+			return nil
+		})
+	}
+	value("addr", "address to listen on (HTTP_ADDR)", str(&cfg.Server.Addr))
+	value("host", "public host shown in the swagger docs (HOST)", str(&cfg.Server.Host))
+	value("mode", "gin mode: debug, release or test (GIN_MODE)", str(&cfg.Server.Mode))
+	value("read-timeout", "maximum duration to read a request (HTTP_READ_TIMEOUT)", duration(&cfg.Server.ReadTimeout))
+	value("write-timeout", "maximum duration to write a response (HTTP_WRITE_TIMEOUT)", duration(&cfg.Server.WriteTimeout))
+	value("idle-timeout", "maximum duration of idle keep-alive connections (HTTP_IDLE_TIMEOUT)", duration(&cfg.Server.IdleTimeout))
+	value("shutdown-timeout", "maximum duration to drain requests on shutdown (SHUTDOWN_TIMEOUT)", duration(&cfg.Server.ShutdownTimeout))
+	value("request-timeout", "deadline of each request, 0 for none (REQUEST_TIMEOUT)", duration(&cfg.Server.RequestTimeout))
+	value("db-driver", "database driver: mysql, postgres or sqlite (DB_DRIVER)", str(&cfg.Database.Driver))
+	value("db-dsn", "database DSN (DB_DSN)", str(&cfg.Database.DSN))
+	value("db-max-open-conns", "maximum open database connections, 0 for unlimited (DB_MAX_OPEN_CONNS)", integer(&cfg.Database.MaxOpenConns))
+	value("db-max-idle-conns", "maximum idle database connections (DB_MAX_IDLE_CONNS)", integer(&cfg.Database.MaxIdleConns))
+	value("db-conn-max-lifetime", "maximum lifetime of database connections, 0 for unlimited (DB_CONN_MAX_LIFETIME)", duration(&cfg.Database.ConnMaxLifetime))
+	value("db-conn-max-idle-time", "maximum idle time of database connections, 0 for unlimited (DB_CONN_MAX_IDLE_TIME)", duration(&cfg.Database.ConnMaxIdleTime))
+	value("db-connect-retries", "database pings to retry on start-up (DB_CONNECT_RETRIES)", integer(&cfg.Database.ConnectRetries))
+	value("db-connect-backoff", "wait before the first retry, doubled on each (DB_CONNECT_BACKOFF)", duration(&cfg.Database.ConnectBackoff))
+	value("timezone", "clinic timezone (CLINIC_TIMEZONE)", str(&cfg.Clinic.Timezone))
+	value("auth-access-ttl", "lifetime of the access tokens (AUTH_ACCESS_TTL)", duration(&cfg.Auth.AccessTTL))
+	value("auth-refresh-ttl", "lifetime of the refresh tokens (AUTH_REFRESH_TTL)", duration(&cfg.Auth.RefreshTTL))
+	value("log-level", "minimum log level: debug, info, warn or error (LOG_LEVEL)", str(&cfg.Log.Level))
+	value("log-format", "log format: json or text (LOG_FORMAT)", str(&cfg.Log.Format))
+	value("tracing-exporter", "trace exporter: none, stdout or otlp (TRACING_EXPORTER)", str(&cfg.Tracing.Exporter))
+	value("tracing-endpoint", "OTLP/HTTP endpoint of the traces (TRACING_ENDPOINT)", str(&cfg.Tracing.Endpoint))
+	value("tracing-sample-ratio", "fraction of the traces sampled, from 0 to 1 (TRACING_SAMPLE_RATIO)", number(&cfg.Tracing.SampleRatio))
+	flags.BoolFunc("migrate", "apply pending migrations before serving (MIGRATE_ON_START)", func(s string) error {
+		apply := boolean(&cfg.Database.MigrateOnStart)
+		fromFlags = append(fromFlags, func() error { return apply(s) })
+		return nil
+	})
+	err := flags.Parse(args)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	err = godotenv.Load()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil, fmt.Errorf("loading .env: %w", err)
+	}
+	if *file == "" {
+		*file = os.Getenv("CONFIG_FILE")
+	}
+	if *file != "" {
+		err = readFile(&cfg, *file)
+		if err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	err = applyEnv(map[string]func(string) error{
+		"HTTP_ADDR":             str(&cfg.Server.Addr),
+		"HOST":                  str(&cfg.Server.Host),
+		"GIN_MODE":              str(&cfg.Server.Mode),
+		"HTTP_READ_TIMEOUT":     duration(&cfg.Server.ReadTimeout),
+		"HTTP_WRITE_TIMEOUT":    duration(&cfg.Server.WriteTimeout),
+		"HTTP_IDLE_TIMEOUT":     duration(&cfg.Server.IdleTimeout),
+		"SHUTDOWN_TIMEOUT":      duration(&cfg.Server.ShutdownTimeout),
+		"REQUEST_TIMEOUT":       duration(&cfg.Server.RequestTimeout),
+		"DB_DRIVER":             str(&cfg.Database.Driver),
+		"DB_DSN":                str(&cfg.Database.DSN),
+		"MIGRATE_ON_START":      boolean(&cfg.Database.MigrateOnStart),
+		"DB_MAX_OPEN_CONNS":     integer(&cfg.Database.MaxOpenConns),
+		"DB_MAX_IDLE_CONNS":     integer(&cfg.Database.MaxIdleConns),
+		"DB_CONN_MAX_LIFETIME":  duration(&cfg.Database.ConnMaxLifetime),
+		"DB_CONN_MAX_IDLE_TIME": duration(&cfg.Database.ConnMaxIdleTime),
+		"DB_CONNECT_RETRIES":    integer(&cfg.Database.ConnectRetries),
+		"DB_CONNECT_BACKOFF":    duration(&cfg.Database.ConnectBackoff),
+		"CLINIC_TIMEZONE":       str(&cfg.Clinic.Timezone),
+		"AUTH_SECRET":           str(&cfg.Auth.Secret),
+		"AUTH_ACCESS_TTL":       duration(&cfg.Auth.AccessTTL),
+		"AUTH_REFRESH_TTL":      duration(&cfg.Auth.RefreshTTL),
+		"AUTH_ADMIN_USERNAME":   str(&cfg.Auth.AdminUsername),
+		"AUTH_ADMIN_PASSWORD":   str(&cfg.Auth.AdminPassword),
+		"LOG_LEVEL":             str(&cfg.Log.Level),
+		"LOG_FORMAT":            str(&cfg.Log.Format),
+		"TRACING_EXPORTER":      str(&cfg.Tracing.Exporter),
+		"TRACING_ENDPOINT":      str(&cfg.Tracing.Endpoint),
+		"TRACING_SAMPLE_RATIO":  number(&cfg.Tracing.SampleRatio),
+	})
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	for _, apply := range fromFlags {
+		err = apply()
+		if err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	return cfg, flags.Args(), cfg.Validate()
+}
+
+// Validate checks every setting, loads the clinic location and returns all
+// the problems found.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server address is empty"))
+	}
+	switch c.Server.Mode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("unknown gin mode %q", c.Server.Mode))
+	}
+	if c.Server.ReadTimeout < 0 || c.Server.WriteTimeout < 0 || c.Server.IdleTimeout < 0 || c.Server.RequestTimeout < 0 {
+		errs = append(errs, errors.New("server timeouts must not be negative"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown timeout must be positive"))
+	}
+	switch c.Database.Driver {
+	case "mysql", "postgres", "sqlite":
+	default:
+		errs = append(errs, fmt.Errorf("unknown database driver %q", c.Database.Driver))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database connection limits must not be negative"))
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("database connection times must not be negative"))
+	}
+	if c.Database.ConnectRetries < 0 || c.Database.ConnectBackoff < 0 {
+		errs = append(errs, errors.New("database connect retries and backoff must not be negative"))
+	}
+	location, err := time.LoadLocation(c.Clinic.Timezone)
+	if err != nil || c.Clinic.Timezone == "" {
+		errs = append(errs, fmt.Errorf("invalid clinic timezone %q", c.Clinic.Timezone))
+	}
+	c.Clinic.Location = location
+	switch {
+	case c.Auth.Secret == "":
+		errs = append(errs, errors.New("auth secret is empty"))
+	case c.Auth.Secret == SecretPlaceholder:
+		errs = append(errs, errors.New("auth secret is still the placeholder of the examples"))
+	case len(c.Auth.Secret) < 32:
+		errs = append(errs, errors.New("auth secret must have at least 32 bytes"))
+	}
+	if c.Auth.AccessTTL <= 0 || c.Auth.RefreshTTL <= 0 {
+		errs = append(errs, errors.New("token lifetimes must be positive"))
+	}
+	if c.Auth.AdminPassword != "" && c.Auth.AdminUsername == "" {
+		errs = append(errs, errors.New("admin username is empty"))
+	}
+	var level slog.Level
+	if level.UnmarshalText([]byte(c.Log.Level)) != nil {
+		errs = append(errs, fmt.Errorf("unknown log level %q", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("unknown log format %q", c.Log.Format))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("unknown tracing exporter %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing sample ratio must be between 0 and 1"))
+	}
+	return errors.Join(errs...)
+}
+
+// readFile sets the settings in the YAML file. Unknown keys are an error, so
+// a typo does not leave a setting at its default unnoticed.
+func readFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func applyEnv(vars map[string]func(string) error) error {
+	for name, apply := range vars {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		err := apply(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func str(p *string) func(string) error {
+	return func(s string) error {
+		*p = s
+		return nil
+	}
+}
+
+func boolean(p *bool) func(string) error {
+	return func(s string) error {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*p = b
+		return nil
+	}
+}
+
+func integer(p *int) func(string) error {
+	return func(s string) error {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*p = i
+		return nil
+	}
+}
+
+func number(p *float64) func(string) error {
+	return func(s string) error {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+		return nil
+	}
+}
+
+func duration(p *time.Duration) func(string) error {
+	return func(s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*p = d
+		return nil
+	}
+}
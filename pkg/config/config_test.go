@@ -0,0 +1,181 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+// setEnv clears the variables Load reads, so the environment of the test run
+// does not leak in, and then sets vars.
+func setEnv(t *testing.T, vars map[string]string) {
+	t.Helper()
+	for _, name := range []string{
+		"CONFIG_FILE", "HTTP_ADDR", "HOST", "GIN_MODE", "HTTP_READ_TIMEOUT", "HTTP_WRITE_TIMEOUT",
+		"HTTP_IDLE_TIMEOUT", "SHUTDOWN_TIMEOUT", "REQUEST_TIMEOUT", "DB_DRIVER", "DB_DSN",
+		"MIGRATE_ON_START", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME",
+		"DB_CONN_MAX_IDLE_TIME", "DB_CONNECT_RETRIES", "DB_CONNECT_BACKOFF", "CLINIC_TIMEZONE",
+		"AUTH_SECRET", "AUTH_ACCESS_TTL", "AUTH_REFRESH_TTL", "AUTH_ADMIN_USERNAME",
+		"AUTH_ADMIN_PASSWORD", "LOG_LEVEL", "LOG_FORMAT", "TRACING_EXPORTER", "TRACING_ENDPOINT",
+		"TRACING_SAMPLE_RATIO",
+	} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	for name, value := range vars {
+		t.Setenv(name, value)
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setEnv(t, map[string]string{"AUTH_SECRET": secret})
+	cfg, args, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	want := Default()
+	want.Auth.Secret = secret
+	want.Clinic.Location = cfg.Clinic.Location
+	if cfg != want {
+		t.Errorf("Load: got %+v, want the defaults %+v", cfg, want)
+	}
+	if cfg.Clinic.Location == nil || cfg.Clinic.Location.String() != want.Clinic.Timezone {
+		t.Errorf("Load: got location %v, want %s", cfg.Clinic.Location, want.Clinic.Timezone)
+	}
+	if len(args) != 0 {
+		t.Errorf("Load: got arguments %q, want none", args)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, `
+server:
+  addr: ":7000"
+  mode: release
+  request_timeout: 2s
+database:
+  driver: sqlite
+  max_open_conns: 4
+log:
+  level: debug
+  format: text
+auth:
+  secret: "`+secret+`"
+`)
+	setEnv(t, map[string]string{
+		"HTTP_ADDR":         ":7001",
+		"DB_MAX_OPEN_CONNS": "8",
+		"LOG_FORMAT":        "json",
+		"MIGRATE_ON_START":  "false",
+	})
+	cfg, args, err := Load([]string{"-config", file, "-addr", ":7002", "-log-format", "text", "-migrate", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, test := range []struct {
+		setting string
+		got     any
+		want    any
+	}{
+		{"default", cfg.Server.WriteTimeout, 30 * time.Second},
+		{"file over default", cfg.Server.Mode, "release"},
+		{"file over default", cfg.Server.RequestTimeout, 2 * time.Second},
+		{"file over default", cfg.Database.Driver, "sqlite"},
+		{"file over default", cfg.Log.Level, "debug"},
+		{"file over default", cfg.Auth.Secret, secret},
+		{"env over file", cfg.Database.MaxOpenConns, 8},
+		{"flag over env and file", cfg.Server.Addr, ":7002"},
+		{"flag over env over file", cfg.Log.Format, "text"},
+		{"flag over env", cfg.Database.MigrateOnStart, true},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s: got %v, want %v", test.setting, test.got, test.want)
+		}
+	}
+	if !slices.Equal(args, []string{"migrate", "up"}) {
+		t.Errorf("Load: got arguments %q, want migrate up", args)
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	fromEnv := writeFile(t, "server:\n  mode: test\n")
+	fromFlag := writeFile(t, "server:\n  mode: release\n")
+
+	setEnv(t, map[string]string{"AUTH_SECRET": secret, "CONFIG_FILE": fromEnv})
+	cfg, _, err := Load(nil)
+	if err != nil || cfg.Server.Mode != "test" {
+		t.Errorf("Load with CONFIG_FILE: got mode %q and error %v, want test", cfg.Server.Mode, err)
+	}
+	cfg, _, err = Load([]string{"-config", fromFlag})
+	if err != nil || cfg.Server.Mode != "release" {
+		t.Errorf("Load with -config and CONFIG_FILE: got mode %q and error %v, want release", cfg.Server.Mode, err)
+	}
+
+	// An empty file leaves every default.
+	cfg, _, err = Load([]string{"-config", writeFile(t, "")})
+	if err != nil || cfg.Server.Mode != Default().Server.Mode {
+		t.Errorf("Load with an empty file: got mode %q and error %v, want the default", cfg.Server.Mode, err)
+	}
+
+	_, _, err = Load([]string{"-config", filepath.Join(t.TempDir(), "missing.yaml")})
+	if err == nil {
+		t.Error("Load with a missing file: got no error")
+	}
+}
+
+func TestLoadUnknownKeys(t *testing.T) {
+	setEnv(t, map[string]string{"AUTH_SECRET": secret})
+	for _, test := range []struct {
+		name    string
+		content string
+		key     string
+	}{
+		{"setting", "server:\n  adress: \":9090\"\n", "adress"},
+		{"section", "databse:\n  driver: sqlite\n", "databse"},
+		{"derived setting", "clinic:\n  location: UTC\n", "location"},
+	} {
+		_, _, err := Load([]string{"-config", writeFile(t, test.content)})
+		if err == nil || !strings.Contains(err.Error(), test.key) {
+			t.Errorf("Load with an unknown %s: got error %v, want one naming %q", test.name, err, test.key)
+		}
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{"env duration", map[string]string{"AUTH_SECRET": secret, "REQUEST_TIMEOUT": "5"}, nil, "REQUEST_TIMEOUT"},
+		{"env number", map[string]string{"AUTH_SECRET": secret, "DB_MAX_OPEN_CONNS": "many"}, nil, "DB_MAX_OPEN_CONNS"},
+		{"flag", map[string]string{"AUTH_SECRET": secret}, []string{"-read-timeout", "soon"}, "soon"},
+		{"unknown flag", map[string]string{"AUTH_SECRET": secret}, []string{"-port", "80"}, "port"},
+		{"no secret", nil, nil, "auth secret is empty"},
+		{"placeholder secret", map[string]string{"AUTH_SECRET": SecretPlaceholder}, nil, "placeholder"},
+		{"validation", map[string]string{"AUTH_SECRET": secret, "DB_DRIVER": "oracle", "CLINIC_TIMEZONE": "Mars/Olympus"}, nil, `unknown database driver "oracle"`},
+		{"every problem", map[string]string{"AUTH_SECRET": secret, "DB_DRIVER": "oracle", "CLINIC_TIMEZONE": "Mars/Olympus"}, nil, `invalid clinic timezone "Mars/Olympus"`},
+	} {
+		setEnv(t, test.env)
+		_, _, err := Load(test.args)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Load with an invalid %s: got error %v, want one with %q", test.name, err, test.want)
+		}
+	}
+}
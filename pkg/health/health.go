@@ -0,0 +1,69 @@
+// Package health runs the checks that tell whether the server can take
+// traffic and reports the result of each one.
+package health
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check reports why a dependency is not usable, nil if it is.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is up only when every check is up.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type named struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	timeout time.Duration
+	checks  []named
+}
+
+// New builds a checker that gives each check up to timeout to finish.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check under name.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, named{name, check})
+}
+
+// Run runs every check, in the order they were added.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Checks: map[string]Result{}}
+	for _, n := range c.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		start := time.Now()
+		err := n.check(checkCtx)
+		cancel()
+
+		result := Result{Status: StatusUp, Duration: time.Since(start).String()}
+		if err != nil {
+			result.Status = StatusDown
+			result.Error = err.Error()
+			report.Status = StatusDown
+		}
+		report.Checks[n.name] = result
+	}
+	return report
+}
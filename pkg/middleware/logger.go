@@ -1,24 +1,36 @@
 package middleware
 
 import (
-	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Logger() gin.HandlerFunc {
+// Logger logs every request once it is handled: errors for 5xx responses,
+// warnings for 4xx and info for the rest.
+func Logger(log *slog.Logger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		time := time.Now()
-		path := ctx.Request.URL.Path
-		verb := ctx.Request.Method
+		start := time.Now()
 
 		ctx.Next()
 
-		var size int
-		if ctx.Writer != nil {
-			size = ctx.Writer.Size()
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
 		}
-		fmt.Printf("time: %v \npath: localhost:8080%s\nverb: %s\nsize: %d\n", time, path, verb, size)
+		log.LogAttrs(ctx.Request.Context(), level, "request",
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", ctx.ClientIP()),
+			slog.Int("size", ctx.Writer.Size()),
+		)
 	}
 }
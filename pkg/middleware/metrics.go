@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records the count and latency of the requests by route template,
+// such as /appointments/:id, so the labels stay bounded.
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ctx.Writer.Status())
+		metrics.Requests.WithLabelValues(route, ctx.Request.Method, status).Inc()
+		metrics.RequestDuration.WithLabelValues(route, ctx.Request.Method, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsLabels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Metrics())
+	r.GET("/appointments/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	metrics.Requests.Reset()
+	metrics.RequestDuration.Reset()
+
+	for _, path := range []string{"/appointments/1", "/appointments/2", "/appointments/0", "/dentists/1", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	for _, test := range []struct {
+		route  string
+		status string
+		want   float64
+	}{
+		{"/appointments/:id", "200", 2},
+		{"/appointments/:id", "404", 1},
+		{"unmatched", "404", 2},
+	} {
+		got := testutil.ToFloat64(metrics.Requests.WithLabelValues(test.route, "GET", test.status))
+		if got != test.want {
+			t.Errorf("requests of %s with %s: got %v, want %v", test.route, test.status, got, test.want)
+		}
+	}
+	// Only the route templates are labels, not the paths requested.
+	if got := testutil.CollectAndCount(metrics.Requests); got != 3 {
+		t.Errorf("request series: got %d, want 3", got)
+	}
+	if got := testutil.CollectAndCount(metrics.RequestDuration); got != 3 {
+		t.Errorf("request duration series: got %d, want 3", got)
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/logging"
+	"github.com/gin-gonic/gin"
+)
+
+var generatedID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logged bytes.Buffer
+	log, err := logging.New(&logged, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen string // by the handler, in the context of the request
+	r := gin.New()
+	r.Use(RequestID(), Logger(log))
+	r.GET("/patients/:id", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusNotFound)
+	})
+
+	for _, test := range []struct {
+		name     string
+		incoming string
+		kept     bool
+	}{
+		{"incoming", "abc-123", true},
+		{"incoming uuid", "9b2f4c7e-1d3a-4e5f-8a6b-0c1d2e3f4a5b", true},
+		{"missing", "", false},
+		{"with spaces", "abc 123", false},
+		{"with a line break", "abc\ninjected", false},
+		{"too long", strings.Repeat("a", 129), false},
+	} {
+		logged.Reset()
+		seen = ""
+		req := httptest.NewRequest("GET", "/patients/7", nil)
+		if test.incoming != "" {
+			req.Header.Set(RequestIDHeader, test.incoming)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		id := w.Header().Get(RequestIDHeader)
+		if test.kept && id != test.incoming || !test.kept && !generatedID.MatchString(id) {
+			t.Errorf("%s: got response id %q, want kept %v from %q", test.name, id, test.kept, test.incoming)
+		}
+		if seen != id {
+			t.Errorf("%s: got id %q in the context, want %q as in the response", test.name, seen, id)
+		}
+		var line struct {
+			Level     string `json:"level"`
+			Msg       string `json:"msg"`
+			RequestId string `json:"request_id"`
+			Route     string `json:"route"`
+			Status    int    `json:"status"`
+		}
+		err := json.Unmarshal(logged.Bytes(), &line)
+		if err != nil {
+			t.Fatalf("%s: decoding the log %q: %v", test.name, logged.String(), err)
+		}
+		if line.Msg != "request" || line.RequestId != id || line.Route != "/patients/:id" || line.Status != 404 || line.Level != "WARN" {
+			t.Errorf("%s: got log %s, want a WARN request line with id %q", test.name, logged.String(), id)
+		}
+	}
+
+	// Each request without one gets a new id.
+	first, second := httptest.NewRecorder(), httptest.NewRecorder()
+	r.ServeHTTP(first, httptest.NewRequest("GET", "/patients/1", nil))
+	r.ServeHTTP(second, httptest.NewRequest("GET", "/patients/1", nil))
+	if first.Header().Get(RequestIDHeader) == second.Header().Get(RequestIDHeader) {
+		t.Errorf("two requests got the same generated id %q", first.Header().Get(RequestIDHeader))
+	}
+}
@@ -1,27 +1,96 @@
 package middleware
 
 import (
+	"context"
 	"errors"
-	"os"
+	"fmt"
+	"strings"
 
+	"github.com/JulietaAlfie/backendGo.git/internal/audit"
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 	"github.com/JulietaAlfie/backendGo.git/pkg/web"
 	"github.com/gin-gonic/gin"
 )
 
-func Authentication() gin.HandlerFunc {
+const (
+	principalKey = "principal"
+	// APIKeyHeader carries the API key of machine clients.
+	APIKeyHeader = "X-API-Key"
+)
+
+// Authenticator resolves the principal an access token or an API key was
+// issued to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (domain.Principal, error)
+}
+
+// Authentication rejects the requests without a valid API key in the
+// X-API-Key header or a valid access token in the Authorization header, as
+// "Bearer <token>", and puts the principal of the others in the gin context.
+// The principal is the actor of the changes recorded in the audit log.
+func Authentication(sessions Authenticator, keys Authenticator) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		token := ctx.GetHeader("TOKEN")
-		if token == "" {
-			web.Failure(ctx, 401, errors.New("token not found"))
-			ctx.Abort()
+		if key := ctx.GetHeader(APIKeyHeader); key != "" {
+			authenticate(ctx, keys, key)
+			return
+		}
+		scheme, token, ok := strings.Cut(ctx.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			unauthorized(ctx, errors.New("bearer token or api key not found"))
 			return
 		}
+		authenticate(ctx, sessions, token)
+	}
+}
 
-		if token != os.Getenv("TOKEN") {
-			web.Failure(ctx, 401, errors.New("token not valid"))
-			ctx.Abort()
+func authenticate(ctx *gin.Context, a Authenticator, token string) {
+	principal, err := a.Authenticate(ctx.Request.Context(), token)
+	if err != nil {
+		unauthorized(ctx, err)
+		return
+	}
+	ctx.Set(principalKey, principal)
+	ctx.Request = ctx.Request.WithContext(audit.WithActor(ctx.Request.Context(), principal.Actor()))
+	ctx.Next()
+}
+
+// Authorize lets through the requests of principals whose role, or whose
+// scopes for API keys, have any of the permissions and rejects the others with
+// 403. It goes after Authentication.
+func Authorize(permissions ...domain.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, ok := CurrentPrincipal(ctx)
+		if !ok {
+			unauthorized(ctx, errors.New("bearer token or api key not found"))
 			return
 		}
-		ctx.Next()
+		for _, permission := range permissions {
+			if principal.Can(permission) {
+				ctx.Next()
+				return
+			}
+		}
+		who := fmt.Sprintf("role %q", principal.Role)
+		if principal.KeyId != 0 {
+			who = "the api key"
+		}
+		web.Failure(ctx, 403, fmt.Errorf("%s is not allowed to %s %s", who, ctx.Request.Method, ctx.FullPath()))
+		ctx.Abort()
 	}
 }
+
+// CurrentPrincipal returns the principal the request was authenticated as.
+func CurrentPrincipal(ctx *gin.Context) (domain.Principal, bool) {
+	principal, ok := ctx.Get(principalKey)
+	if !ok {
+		return domain.Principal{}, false
+	}
+	p, ok := principal.(domain.Principal)
+	return p, ok
+}
+
+func unauthorized(ctx *gin.Context, err error) {
+	ctx.Header("WWW-Authenticate", `Bearer realm="clinic"`)
+	web.Failure(ctx, 401, err)
+	ctx.Abort()
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, d := range []time.Duration{50 * time.Millisecond, 0} {
+		var remaining time.Duration // until the deadline, when the handler starts
+		var bounded bool
+		var ctxErr error
+		r := gin.New()
+		r.Use(Timeout(d))
+		r.GET("/slow", func(c *gin.Context) {
+			ctx := c.Request.Context()
+			deadline, ok := ctx.Deadline()
+			remaining, bounded = time.Until(deadline), ok
+			select {
+			case <-ctx.Done():
+			case <-time.After(200 * time.Millisecond):
+			}
+			ctxErr = ctx.Err()
+			c.Status(http.StatusOK)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+
+		if d == 0 {
+			if bounded || ctxErr != nil {
+				t.Errorf("Timeout(0): got a deadline in %v and error %v, want none", remaining, ctxErr)
+			}
+			continue
+		}
+		if !bounded || remaining <= 0 || remaining > d {
+			t.Errorf("Timeout(%v): got a deadline in %v (set %v), want one within %v", d, remaining, bounded, d)
+		}
+		if ctxErr != context.DeadlineExceeded {
+			t.Errorf("Timeout(%v): got error %v once it passed, want context.DeadlineExceeded", d, ctxErr)
+		}
+	}
+}
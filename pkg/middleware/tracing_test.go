@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recorder gets the spans of the package tracer, which only delegates to the
+// first provider set, so it is set once for every test.
+var recorder = tracetest.NewSpanRecorder()
+
+func init() {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+}
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	var handled trace.SpanContext // the span in the context of the handler
+	r := gin.New()
+	r.Use(Tracing())
+	r.GET("/appointments/:id", func(c *gin.Context) {
+		handled = trace.SpanContextFromContext(c.Request.Context())
+		if c.Param("id") == "0" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	for _, test := range []struct {
+		path        string
+		traceparent string
+		name        string
+		status      int
+		failed      bool
+	}{
+		{"/appointments/1", "", "GET /appointments/:id", 200, false},
+		{"/appointments/0", "", "GET /appointments/:id", 500, true},
+		{"/appointments/2", traceparent, "GET /appointments/:id", 200, false},
+		{"/nope", "", "GET unmatched", 404, false},
+	} {
+		handled = trace.SpanContext{}
+		before := len(recorder.Ended())
+		req := httptest.NewRequest("GET", test.path, nil)
+		if test.traceparent != "" {
+			req.Header.Set("traceparent", test.traceparent)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := recorder.Ended()[before:]
+		if len(spans) != 1 {
+			t.Errorf("%s: got %d spans, want 1", test.path, len(spans))
+			continue
+		}
+		span := spans[0]
+		if span.Name() != test.name || span.SpanKind() != trace.SpanKindServer {
+			t.Errorf("%s: got span %q of kind %v, want server span %q", test.path, span.Name(), span.SpanKind(), test.name)
+		}
+		attributes := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attributes[kv.Key] = kv.Value
+		}
+		if attributes["url.path"].AsString() != test.path || attributes["http.response.status_code"].AsInt64() != int64(test.status) {
+			t.Errorf("%s: got attributes %v, want the path and status %d", test.path, span.Attributes(), test.status)
+		}
+		if failed := span.Status().Code == codes.Error; failed != test.failed {
+			t.Errorf("%s: got status %v, want failed %v", test.path, span.Status(), test.failed)
+		}
+		if test.path != "/nope" && handled.SpanID() != span.SpanContext().SpanID() {
+			t.Errorf("%s: the handler got span %v, want the one of the request %v", test.path, handled.SpanID(), span.SpanContext().SpanID())
+		}
+		// The trace of the caller is continued.
+		if test.traceparent != "" && (span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent().SpanID().String() != "00f067aa0ba902b7") {
+			t.Errorf("%s: got trace %v with parent %v, want the ones of traceparent", test.path, span.SpanContext().TraceID(), span.Parent().SpanID())
+		}
+		if test.traceparent == "" && span.Parent().IsValid() {
+			t.Errorf("%s: got parent %v, want a new trace", test.path, span.Parent().SpanID())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package migrate
+
+import (
+	"database/sql"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+// backfill is a data change of a migration written in Go, for what SQL cannot
+// express alike in every dialect. It runs after the statements of the up
+// script, in the same transaction; bind numbers the placeholders of a query.
+type backfill func(tx *sql.Tx, bind func(string) string) error
+
+// backfills are the backfills of the migrations by version.
+var backfills = map[int]backfill{
+	11: patientSearch,
+}
+
+// patientSearch fills in the search_text of the existing patients as the
+// stores write it, with domain.Patient.SearchText. Columns left NULL by the
+// first schema count as empty.
+func patientSearch(tx *sql.Tx, bind func(string) string) error {
+	rows, err := tx.Query("select id, name, lastname, residence, dni from patients")
+	if err != nil {
+		return err
+	}
+	texts := map[int]string{}
+	for rows.Next() {
+		var id int
+		var name, lastname, residence sql.NullString
+		var dni sql.NullInt64
+		err := rows.Scan(&id, &name, &lastname, &residence, &dni)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		patient := domain.Patient{Name: name.String, Lastname: lastname.String, Residence: residence.String, DNI: int(dni.Int64)}
+		texts[id] = patient.SearchText()
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	update := bind("update patients set search_text = ? where id = ?")
+	for id, text := range texts {
+		_, err := tx.Exec(update, text, id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
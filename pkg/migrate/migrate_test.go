@@ -0,0 +1,168 @@
+package migrate_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/migrate"
+	_ "modernc.org/sqlite"
+)
+
+func newDB(t *testing.T) (*sql.DB, *migrate.Migrator) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "clinic.db")+"?_pragma=foreign_keys(1)&_time_format=datetime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrate.New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, migrator
+}
+
+// TestPatientSearchBackfill starts from patients saved before the search, some
+// with the NULL columns the first schema allowed, and checks that the
+// migration fills in their search text as the stores write it.
+func TestPatientSearchBackfill(t *testing.T) {
+	db, migrator := newDB(t)
+	_, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	_, err = migrator.Down(1)
+	if err != nil {
+		t.Fatalf("Down to before the search: %v", err)
+	}
+	_, err = db.Exec(`insert into patients (id, name, lastname, residence, dni) values
+		(1, 'María Inés', 'Núñez-Ibáñez', 'Av. Mitre 10, 2do', 1234567),
+		(2, 'José', NULL, NULL, 30123456),
+		(3, NULL, NULL, NULL, NULL),
+		(4, 'Ana', 'O''Brien', 'Calle 9/B #3 (fondo)', 40555666)`)
+	if err != nil {
+		t.Fatalf("inserting patients: %v", err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatalf("Up with the search: %v", err)
+	}
+
+	for id, want := range map[int]string{
+		1: " maria ines nunez ibanez av mitre 10 2do 1234567",
+		2: " jose 30123456",
+		3: " 0",
+		4: " ana o brien calle 9 b 3 fondo 40555666",
+	} {
+		var got string
+		err := db.QueryRow("select search_text from patients where id = ?", id).Scan(&got)
+		if err != nil || got != want {
+			t.Errorf("search_text of patient %d: got %q, %v, want %q", id, got, err, want)
+		}
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	db, migrator := newDB(t)
+	ctx := context.Background()
+	latest := migrator.Latest()
+	if latest == 0 {
+		t.Fatal("Latest: no migrations embedded")
+	}
+	_, err := migrator.AppliedVersion(ctx)
+	if err == nil {
+		t.Error("AppliedVersion before any migration: got no error")
+	}
+
+	// check compares the versions in schema_migrations and in Status and the
+	// version applied with the first applied ones.
+	check := func(step string, applied int) {
+		t.Helper()
+		var versions []int
+		rows, err := db.Query("select version from schema_migrations order by version")
+		if err != nil {
+			t.Fatalf("%s: reading schema_migrations: %v", step, err)
+		}
+		for rows.Next() {
+			var version int
+			rows.Scan(&version)
+			versions = append(versions, version)
+		}
+		rows.Close()
+		statuses, err := migrator.Status()
+		if err != nil {
+			t.Fatalf("%s: Status: %v", step, err)
+		}
+		var want []int
+		for i, status := range statuses {
+			if i < applied {
+				want = append(want, status.Version)
+			}
+			if (status.AppliedAt != nil) != (i < applied) {
+				t.Errorf("%s: got status %+v, want applied %v", step, status, i < applied)
+			}
+		}
+		if !slices.Equal(versions, want) {
+			t.Errorf("%s: got versions %v in schema_migrations, want %v", step, versions, want)
+		}
+		wantVersion := 0
+		if applied > 0 {
+			wantVersion = want[applied-1]
+		}
+		version, err := migrator.AppliedVersion(ctx)
+		if err != nil || version != wantVersion {
+			t.Errorf("%s: got AppliedVersion %d, %v, want %d", step, version, err, wantVersion)
+		}
+		version, err = migrator.Version()
+		if err != nil || version != wantVersion {
+			t.Errorf("%s: got Version %d, %v, want %d", step, version, err, wantVersion)
+		}
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	total := len(statuses)
+	check("before Up", 0)
+
+	count, err := migrator.Up()
+	if err != nil || count != total {
+		t.Fatalf("Up: got %d, %v, want %d", count, err, total)
+	}
+	check("after Up", total)
+	count, err = migrator.Up()
+	if err != nil || count != 0 {
+		t.Errorf("Up again: got %d, %v, want 0", count, err)
+	}
+
+	count, err = migrator.Down(2)
+	if err != nil || count != 2 {
+		t.Fatalf("Down 2: got %d, %v, want 2", count, err)
+	}
+	check("after Down 2", total-2)
+
+	count, err = migrator.Up()
+	if err != nil || count != 2 {
+		t.Fatalf("Up after Down: got %d, %v, want 2", count, err)
+	}
+	check("after Up again", total)
+	_, err = db.Exec("insert into patients (name, lastname, residence, dni, search_text) values ('Ana', 'Perez', 'Mitre 1', 1, ' ana perez mitre 1 1')")
+	if err != nil {
+		t.Errorf("inserting a patient in the latest schema: %v", err)
+	}
+
+	count, err = migrator.Down(total + 1)
+	if err != nil || count != total {
+		t.Fatalf("Down all: got %d, %v, want %d", count, err, total)
+	}
+	check("after Down all", 0)
+	count, err = migrator.Up()
+	if err != nil || count != total {
+		t.Fatalf("Up from scratch: got %d, %v, want %d", count, err, total)
+	}
+	check("after Up from scratch", total)
+}
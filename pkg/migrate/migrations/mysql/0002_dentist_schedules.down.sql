@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `dentist_breaks`;
+DROP TABLE IF EXISTS `dentist_schedules`;
@@ -0,0 +1,22 @@
+CREATE TABLE `dentist_schedules` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `dentist_id` int NOT NULL,
+  `weekday` tinyint NOT NULL,
+  `start_time` varchar(5) NOT NULL,
+  `end_time` varchar(5) NOT NULL,
+  `slot_minutes` int NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `dentist_weekday_UNIQUE` (`dentist_id`,`weekday`),
+  CONSTRAINT `fk_dentist_schedules_dentist` FOREIGN KEY (`dentist_id`) REFERENCES `dentists` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE `dentist_breaks` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `dentist_id` int NOT NULL,
+  `weekday` tinyint NOT NULL,
+  `start_time` varchar(5) NOT NULL,
+  `end_time` varchar(5) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `dentist_weekday_idx` (`dentist_id`,`weekday`),
+  CONSTRAINT `fk_dentist_breaks_dentist` FOREIGN KEY (`dentist_id`) REFERENCES `dentists` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
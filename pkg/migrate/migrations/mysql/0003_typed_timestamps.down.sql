@@ -0,0 +1,17 @@
+ALTER TABLE `appointments`
+  ADD COLUMN `day` varchar(45) DEFAULT NULL AFTER `date`,
+  ADD COLUMN `time` varchar(45) DEFAULT NULL AFTER `day`;
+
+UPDATE `appointments` SET `day` = DATE_FORMAT(`date`, '%d-%m-%Y'), `time` = DATE_FORMAT(`date`, '%H:%i') WHERE `date` IS NOT NULL;
+
+ALTER TABLE `appointments`
+  DROP COLUMN `date`,
+  RENAME COLUMN `day` TO `date`;
+
+ALTER TABLE `patients` ADD COLUMN `discharged_on` varchar(45) DEFAULT NULL AFTER `discharge_date`;
+
+UPDATE `patients` SET `discharged_on` = DATE_FORMAT(`discharge_date`, '%d-%m-%Y') WHERE `discharge_date` IS NOT NULL;
+
+ALTER TABLE `patients`
+  DROP COLUMN `discharge_date`,
+  RENAME COLUMN `discharged_on` TO `discharge_date`;
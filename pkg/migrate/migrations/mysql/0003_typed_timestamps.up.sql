@@ -0,0 +1,34 @@
+-- appointments.date + appointments.time and patients.discharge_date become
+-- DATETIME columns holding wall-clock time in the clinic timezone.
+--
+-- Both dd-mm-yyyy and yyyy-mm-dd values are converted. Rows in any other
+-- format are left with a NULL date and have to be fixed by hand.
+
+ALTER TABLE `appointments` ADD COLUMN `starts_at` datetime DEFAULT NULL AFTER `dentist_id`;
+
+UPDATE `appointments`
+SET `starts_at` = STR_TO_DATE(CONCAT(`date`, ' ', `time`), '%d-%m-%Y %H:%i')
+WHERE `date` REGEXP '^[0-9]{2}-[0-9]{2}-[0-9]{4}$' AND `time` REGEXP '^[0-9]{1,2}:[0-9]{2}$';
+
+UPDATE `appointments`
+SET `starts_at` = STR_TO_DATE(CONCAT(`date`, ' ', `time`), '%Y-%m-%d %H:%i')
+WHERE `date` REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}$' AND `time` REGEXP '^[0-9]{1,2}:[0-9]{2}$';
+
+ALTER TABLE `appointments`
+  DROP COLUMN `date`,
+  DROP COLUMN `time`,
+  RENAME COLUMN `starts_at` TO `date`;
+
+ALTER TABLE `patients` ADD COLUMN `discharged_at` datetime DEFAULT NULL AFTER `discharge_date`;
+
+UPDATE `patients`
+SET `discharged_at` = STR_TO_DATE(`discharge_date`, '%d-%m-%Y')
+WHERE `discharge_date` REGEXP '^[0-9]{2}-[0-9]{2}-[0-9]{4}$';
+
+UPDATE `patients`
+SET `discharged_at` = STR_TO_DATE(`discharge_date`, '%Y-%m-%d')
+WHERE `discharge_date` REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}$';
+
+ALTER TABLE `patients`
+  DROP COLUMN `discharge_date`,
+  RENAME COLUMN `discharged_at` TO `discharge_date`;
@@ -0,0 +1,3 @@
+ALTER TABLE `appointments`
+  DROP COLUMN `end_date`,
+  DROP COLUMN `treatment`;
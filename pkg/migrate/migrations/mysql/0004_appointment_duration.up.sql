@@ -0,0 +1,7 @@
+-- Existing appointments get the default duration of 30 minutes.
+
+ALTER TABLE `appointments`
+  ADD COLUMN `end_date` datetime DEFAULT NULL AFTER `date`,
+  ADD COLUMN `treatment` varchar(45) NOT NULL DEFAULT '' AFTER `end_date`;
+
+UPDATE `appointments` SET `end_date` = DATE_ADD(`date`, INTERVAL 30 MINUTE) WHERE `date` IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE `dentists` DROP INDEX `license_UNIQUE`;
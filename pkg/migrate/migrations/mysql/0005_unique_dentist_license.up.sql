@@ -0,0 +1,3 @@
+-- Fails while two dentists share a license; merge them before migrating.
+
+ALTER TABLE `dentists` ADD UNIQUE KEY `license_UNIQUE` (`license`);
@@ -0,0 +1,8 @@
+ALTER TABLE `users`
+  DROP FOREIGN KEY `users_dentist_fk`,
+  DROP FOREIGN KEY `users_patient_fk`;
+
+ALTER TABLE `users`
+  DROP COLUMN `role`,
+  DROP COLUMN `dentist_id`,
+  DROP COLUMN `patient_id`;
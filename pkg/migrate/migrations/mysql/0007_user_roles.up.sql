@@ -0,0 +1,9 @@
+-- Users created before roles existed could do everything, so they become
+-- admins; new users always get an explicit role.
+
+ALTER TABLE `users`
+  ADD COLUMN `role` varchar(20) NOT NULL DEFAULT 'admin' AFTER `password_hash`,
+  ADD COLUMN `dentist_id` int DEFAULT NULL AFTER `role`,
+  ADD COLUMN `patient_id` int DEFAULT NULL AFTER `dentist_id`,
+  ADD CONSTRAINT `users_dentist_fk` FOREIGN KEY (`dentist_id`) REFERENCES `dentists` (`id`) ON DELETE SET NULL,
+  ADD CONSTRAINT `users_patient_fk` FOREIGN KEY (`patient_id`) REFERENCES `patients` (`id`) ON DELETE SET NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE `audit_log` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `actor` varchar(64) NOT NULL,
+  `action` varchar(16) NOT NULL,
+  `entity` varchar(32) NOT NULL,
+  `entity_id` int NOT NULL,
+  `changes` text NOT NULL,
+  `request_id` varchar(64) NOT NULL DEFAULT '',
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `entity_idx` (`entity`, `entity_id`),
+  KEY `actor_idx` (`actor`),
+  KEY `created_at_idx` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -0,0 +1,13 @@
+-- Soft-deleted rows are purged before the columns are dropped.
+
+DELETE FROM `appointments` WHERE `deleted_at` IS NOT NULL;
+
+DELETE FROM `patients` WHERE `deleted_at` IS NOT NULL;
+
+DELETE FROM `dentists` WHERE `deleted_at` IS NOT NULL;
+
+ALTER TABLE `patients` DROP COLUMN `deleted_at`;
+
+ALTER TABLE `dentists` DROP COLUMN `deleted_at`;
+
+ALTER TABLE `appointments` DROP COLUMN `deleted_at`;
@@ -0,0 +1,9 @@
+DROP TABLE dentist_breaks;
+
+DROP TABLE dentist_schedules;
+
+DROP TABLE appointments;
+
+DROP TABLE dentists;
+
+DROP TABLE patients;
@@ -0,0 +1,54 @@
+-- PostgreSQL starts at the schema MySQL has after migration 0005, so versions
+-- match across databases from here on.
+
+CREATE TABLE patients (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(45) DEFAULT NULL,
+  lastname VARCHAR(45) DEFAULT NULL,
+  residence VARCHAR(45) DEFAULT NULL,
+  dni INT DEFAULT NULL,
+  discharge_date TIMESTAMPTZ DEFAULT NULL,
+  CONSTRAINT patients_dni_unique UNIQUE (dni)
+);
+
+CREATE TABLE dentists (
+  id SERIAL PRIMARY KEY,
+  lastname VARCHAR(45) DEFAULT NULL,
+  name VARCHAR(45) DEFAULT NULL,
+  license VARCHAR(45) DEFAULT NULL,
+  CONSTRAINT dentists_license_unique UNIQUE (license)
+);
+
+CREATE TABLE appointments (
+  id SERIAL PRIMARY KEY,
+  patient_id INT DEFAULT NULL REFERENCES patients (id) ON DELETE CASCADE,
+  dentist_id INT DEFAULT NULL REFERENCES dentists (id) ON DELETE CASCADE,
+  date TIMESTAMPTZ DEFAULT NULL,
+  end_date TIMESTAMPTZ DEFAULT NULL,
+  treatment VARCHAR(45) NOT NULL DEFAULT '',
+  description VARCHAR(45) DEFAULT NULL
+);
+
+CREATE INDEX appointments_patient_idx ON appointments (patient_id);
+
+CREATE INDEX appointments_dentist_idx ON appointments (dentist_id, date);
+
+CREATE TABLE dentist_schedules (
+  id SERIAL PRIMARY KEY,
+  dentist_id INT NOT NULL REFERENCES dentists (id) ON DELETE CASCADE,
+  weekday SMALLINT NOT NULL,
+  start_time VARCHAR(5) NOT NULL,
+  end_time VARCHAR(5) NOT NULL,
+  slot_minutes INT NOT NULL,
+  CONSTRAINT dentist_schedules_weekday_unique UNIQUE (dentist_id, weekday)
+);
+
+CREATE TABLE dentist_breaks (
+  id SERIAL PRIMARY KEY,
+  dentist_id INT NOT NULL REFERENCES dentists (id) ON DELETE CASCADE,
+  weekday SMALLINT NOT NULL,
+  start_time VARCHAR(5) NOT NULL,
+  end_time VARCHAR(5) NOT NULL
+);
+
+CREATE INDEX dentist_breaks_dentist_idx ON dentist_breaks (dentist_id, weekday);
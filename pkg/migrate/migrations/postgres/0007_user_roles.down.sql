@@ -0,0 +1,4 @@
+ALTER TABLE users
+  DROP COLUMN role,
+  DROP COLUMN dentist_id,
+  DROP COLUMN patient_id;
@@ -0,0 +1,7 @@
+-- Users created before roles existed could do everything, so they become
+-- admins; new users always get an explicit role.
+
+ALTER TABLE users
+  ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'admin',
+  ADD COLUMN dentist_id INT DEFAULT NULL REFERENCES dentists (id) ON DELETE SET NULL,
+  ADD COLUMN patient_id INT DEFAULT NULL REFERENCES patients (id) ON DELETE SET NULL;
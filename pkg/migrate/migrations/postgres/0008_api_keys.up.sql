@@ -0,0 +1,11 @@
+CREATE TABLE api_keys (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(45) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  hash CHAR(64) NOT NULL,
+  scopes VARCHAR(255) NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ DEFAULT NULL,
+  last_used_at TIMESTAMPTZ DEFAULT NULL,
+  CONSTRAINT api_keys_hash_unique UNIQUE (hash)
+);
@@ -0,0 +1,16 @@
+CREATE TABLE audit_log (
+  id SERIAL PRIMARY KEY,
+  actor VARCHAR(64) NOT NULL,
+  action VARCHAR(16) NOT NULL,
+  entity VARCHAR(32) NOT NULL,
+  entity_id INT NOT NULL,
+  changes TEXT NOT NULL,
+  request_id VARCHAR(64) NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity, entity_id);
+
+CREATE INDEX audit_log_actor_idx ON audit_log (actor);
+
+CREATE INDEX audit_log_created_at_idx ON audit_log (created_at);
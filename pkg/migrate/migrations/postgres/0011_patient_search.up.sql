@@ -0,0 +1,7 @@
+-- The patients keep the words of their name, lastname and residence,
+-- lowercased and without accents, and their DNI, each after a space, so that
+-- searches are filtered in the database. The server writes it on every insert
+-- and update; the migrator fills it in for the existing rows, in Go, as SQL
+-- cannot split the words alike in every dialect.
+
+ALTER TABLE patients ADD COLUMN search_text VARCHAR(200) NOT NULL DEFAULT '';
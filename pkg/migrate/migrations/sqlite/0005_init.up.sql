@@ -0,0 +1,55 @@
+-- SQLite starts at the schema MySQL has after migration 0005, so versions
+-- match across databases from here on. Dates are DATETIME text holding
+-- wall-clock time in the clinic timezone.
+
+CREATE TABLE patients (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(45) DEFAULT NULL,
+  lastname VARCHAR(45) DEFAULT NULL,
+  residence VARCHAR(45) DEFAULT NULL,
+  dni INT DEFAULT NULL,
+  discharge_date DATETIME DEFAULT NULL,
+  CONSTRAINT dni_UNIQUE UNIQUE (dni)
+);
+
+CREATE TABLE dentists (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  lastname VARCHAR(45) DEFAULT NULL,
+  name VARCHAR(45) DEFAULT NULL,
+  license VARCHAR(45) DEFAULT NULL,
+  CONSTRAINT license_UNIQUE UNIQUE (license)
+);
+
+CREATE TABLE appointments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  patient_id INT DEFAULT NULL REFERENCES patients (id) ON DELETE CASCADE,
+  dentist_id INT DEFAULT NULL REFERENCES dentists (id) ON DELETE CASCADE,
+  date DATETIME DEFAULT NULL,
+  end_date DATETIME DEFAULT NULL,
+  treatment VARCHAR(45) NOT NULL DEFAULT '',
+  description VARCHAR(45) DEFAULT NULL
+);
+
+CREATE INDEX paciente_id_idx ON appointments (patient_id);
+
+CREATE INDEX odontologo_id_idx ON appointments (dentist_id);
+
+CREATE TABLE dentist_schedules (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  dentist_id INT NOT NULL REFERENCES dentists (id) ON DELETE CASCADE,
+  weekday TINYINT NOT NULL,
+  start_time VARCHAR(5) NOT NULL,
+  end_time VARCHAR(5) NOT NULL,
+  slot_minutes INT NOT NULL,
+  CONSTRAINT dentist_weekday_UNIQUE UNIQUE (dentist_id, weekday)
+);
+
+CREATE TABLE dentist_breaks (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  dentist_id INT NOT NULL REFERENCES dentists (id) ON DELETE CASCADE,
+  weekday TINYINT NOT NULL,
+  start_time VARCHAR(5) NOT NULL,
+  end_time VARCHAR(5) NOT NULL
+);
+
+CREATE INDEX dentist_weekday_idx ON dentist_breaks (dentist_id, weekday);
@@ -0,0 +1,13 @@
+CREATE TABLE users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  username VARCHAR(45) NOT NULL,
+  password_hash VARCHAR(255) NOT NULL,
+  CONSTRAINT username_UNIQUE UNIQUE (username)
+);
+
+CREATE TABLE revoked_tokens (
+  jti VARCHAR(64) PRIMARY KEY,
+  expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX expires_at_idx ON revoked_tokens (expires_at);
@@ -0,0 +1,14 @@
+-- SQLite cannot drop columns with a foreign key, so the table is rebuilt.
+
+CREATE TABLE users_0006 (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  username VARCHAR(45) NOT NULL,
+  password_hash VARCHAR(255) NOT NULL,
+  CONSTRAINT username_UNIQUE UNIQUE (username)
+);
+
+INSERT INTO users_0006 (id, username, password_hash) SELECT id, username, password_hash FROM users;
+
+DROP TABLE users;
+
+ALTER TABLE users_0006 RENAME TO users;
@@ -0,0 +1,11 @@
+CREATE TABLE api_keys (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(45) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  hash CHAR(64) NOT NULL,
+  scopes VARCHAR(255) NOT NULL,
+  created_at DATETIME NOT NULL,
+  expires_at DATETIME DEFAULT NULL,
+  last_used_at DATETIME DEFAULT NULL,
+  CONSTRAINT hash_UNIQUE UNIQUE (hash)
+);
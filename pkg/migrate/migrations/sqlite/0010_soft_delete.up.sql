@@ -0,0 +1,8 @@
+-- Deleted patients, dentists and appointments keep their row, with the time
+-- they were deleted, until they are purged.
+
+ALTER TABLE patients ADD COLUMN deleted_at DATETIME DEFAULT NULL;
+
+ALTER TABLE dentists ADD COLUMN deleted_at DATETIME DEFAULT NULL;
+
+ALTER TABLE appointments ADD COLUMN deleted_at DATETIME DEFAULT NULL;
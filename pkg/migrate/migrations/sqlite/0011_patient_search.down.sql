@@ -0,0 +1 @@
+ALTER TABLE patients DROP COLUMN search_text;
@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStatements(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"one", "CREATE TABLE a (id INT);\n", []string{"CREATE TABLE a (id INT)"}},
+		{"several", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n\nDROP TABLE c;", []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)", "DROP TABLE c"}},
+		{"over lines", "CREATE TABLE a (\n  id INT,\n  name TEXT\n);\n", []string{"CREATE TABLE a (\n  id INT,\n  name TEXT\n)"}},
+		{"comments", "-- the first table\nCREATE TABLE a (id INT);\n  -- indented; with a semicolon;\nDROP TABLE b;\n", []string{"CREATE TABLE a (id INT)", "DROP TABLE b"}},
+		{"without the last semicolon", "DROP TABLE a;\nDROP TABLE b\n", []string{"DROP TABLE a", "DROP TABLE b"}},
+		{"semicolon inside a line", "INSERT INTO a VALUES ('x;y'), ('z');\n", []string{"INSERT INTO a VALUES ('x;y'), ('z')"}},
+		{"quoted quote", "UPDATE a SET s = replace(s, '''', ' ');\nUPDATE a SET t = 'it''s';\n", []string{"UPDATE a SET s = replace(s, '''', ' ')", "UPDATE a SET t = 'it''s'"}},
+		{"trailing spaces", "DROP TABLE a;   \r\n", []string{"DROP TABLE a"}},
+		{"only comments", "-- nothing to do\n\n", nil},
+		{"empty", "", nil},
+	} {
+		got := statements(test.script)
+		if !slices.Equal(got, test.want) {
+			t.Errorf("statements of %s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+// TestDialects checks that every dialect has statements in both scripts of
+// its migrations and, from the first of sqlite, which starts with the schema
+// of the others at that version, the same versions.
+func TestDialects(t *testing.T) {
+	sqlite, err := load("sqlite")
+	if err != nil {
+		t.Fatalf("load sqlite: %v", err)
+	}
+	for _, dialect := range []string{"sqlite", "mysql", "postgres"} {
+		migrations, err := load(dialect)
+		if err != nil {
+			t.Fatalf("load %s: %v", dialect, err)
+		}
+		var got, want []int
+		for _, migration := range migrations {
+			if migration.Version >= sqlite[0].Version {
+				got = append(got, migration.Version)
+			}
+			if statements(migration.Up) == nil || statements(migration.Down) == nil {
+				t.Errorf("%s %04d_%s: got a script without statements", dialect, migration.Version, migration.Name)
+			}
+		}
+		for _, migration := range sqlite {
+			want = append(want, migration.Version)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: got versions %v, want the ones of sqlite %v", dialect, got, want)
+		}
+	}
+	_, err = load("oracle")
+	if err == nil {
+		t.Error("load oracle: got no error")
+	}
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/JulietaAlfie/backendGo.git/pkg/store")
+
+// conn is the database of the SQL stores. It traces every statement in a span
+// and logs it, with its duration and error, at debug level.
+type conn struct {
+	*sql.DB
+	log *slog.Logger
+}
+
+// connTx is a transaction begun by a conn, tracing and logging its statements
+// the same way.
+type connTx struct {
+	*sql.Tx
+	log *slog.Logger
+}
+
+func (c conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (connTx, error) {
+	t, err := c.DB.BeginTx(ctx, opts)
+	return connTx{t, c.log}, err
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, done := statement(ctx, c.log, query)
+	res, err := c.DB.ExecContext(ctx, query, args...)
+	done(err)
+	return res, err
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, done := statement(ctx, c.log, query)
+	rows, err := c.DB.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (c conn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, done := statement(ctx, c.log, query)
+	row := c.DB.QueryRowContext(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+func (t connTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, done := statement(ctx, t.log, query)
+	res, err := t.Tx.ExecContext(ctx, query, args...)
+	done(err)
+	return res, err
+}
+
+func (t connTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, done := statement(ctx, t.log, query)
+	rows, err := t.Tx.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (t connTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, done := statement(ctx, t.log, query)
+	row := t.Tx.QueryRowContext(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+// statement starts the span of a query, named after its operation such as
+// "sql select", and returns the function that ends and logs it.
+func statement(ctx context.Context, log *slog.Logger, query string) (context.Context, func(error)) {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	ctx, span := tracer.Start(ctx, "sql "+strings.ToLower(operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.query.text", query)),
+	)
+	start := time.Now()
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if !log.Enabled(ctx, slog.LevelDebug) {
+			return
+		}
+		attrs := []slog.Attr{slog.String("sql", query), slog.Duration("duration", time.Since(start))}
+		if err != nil {
+			attrs = append(attrs, slog.String("err", err.Error()))
+		}
+		log.LogAttrs(ctx, slog.LevelDebug, "statement", attrs...)
+	}
+}
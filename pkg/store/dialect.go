@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dialect holds what the SQL stores do differently on each database.
+type dialect struct {
+	// numbered placeholders are written $1, $2... instead of ?.
+	numbered bool
+	// returning reads the id of inserted rows with "returning id", as
+	// LastInsertId is not supported.
+	returning bool
+	// forUpdate locks the rows selected inside a transaction. SQLite has no
+	// row locks: its transactions take the write lock when they begin.
+	forUpdate string
+}
+
+var (
+	mysqlDialect    = dialect{forUpdate: " for update"}
+	sqliteDialect   = dialect{}
+	postgresDialect = dialect{numbered: true, returning: true, forUpdate: " for update"}
+)
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// rebind rewrites the ? placeholders of a query for the database. Queries
+// must not hold ? in string literals.
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// insert runs an insert statement and returns the id of the new row.
+func (d dialect) insert(ctx context.Context, q querier, query string, args ...interface{}) (int, error) {
+	if d.returning {
+		var id int
+		err := q.QueryRowContext(ctx, d.rebind(query+" returning id"), args...).Scan(&id)
+		return id, sqlError(err)
+	}
+	res, err := q.ExecContext(ctx, d.rebind(query), args...)
+	if err != nil {
+		return 0, sqlError(err)
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// checkUpdated returns ErrNotFound if an update matched no row. MySQL does not
+// count rows left unchanged as affected, so the id is looked up again with
+// lookup, which selects the id of the rows the update could match.
+func (d dialect) checkUpdated(ctx context.Context, q querier, res sql.Result, lookup string, id int) error {
+	affected, err := res.RowsAffected()
+	if err != nil || affected > 0 {
+		return err
+	}
+	return sqlError(q.QueryRowContext(ctx, d.rebind(lookup), id).Scan(&id))
+}
+
+// softDelete marks the row of the table with the id as deleted at the time,
+// or returns ErrNotFound if there is no such row or it is already deleted.
+func (d dialect) softDelete(ctx context.Context, q querier, table string, id int, at time.Time) error {
+	res, err := q.ExecContext(ctx, d.rebind("update "+table+" set deleted_at = ? where id = ? and deleted_at is null"), at, id)
+	return checkAffected(res, err)
+}
+
+// restore clears the deleted mark of the row of the table with the id, or
+// returns ErrNotFound if there is no such row or it is not deleted.
+func (d dialect) restore(ctx context.Context, q querier, table string, id int) error {
+	res, err := q.ExecContext(ctx, d.rebind("update "+table+" set deleted_at = null where id = ? and deleted_at is not null"), id)
+	return checkAffected(res, err)
+}
+
+// purge removes the deleted row of the table with the id for good, or returns
+// ErrNotFound if there is no such row or it is not deleted.
+func (d dialect) purge(ctx context.Context, q querier, table string, id int) error {
+	res, err := q.ExecContext(ctx, d.rebind("delete from "+table+" where id = ? and deleted_at is not null"), id)
+	return checkAffected(res, err)
+}
+
+// checkAffected returns ErrNotFound if a statement that always changes the
+// rows it matches matched none.
+func checkAffected(res sql.Result, err error) error {
+	if err != nil {
+		return sqlError(err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
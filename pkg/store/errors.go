@@ -0,0 +1,68 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// The errors of the stores carry the codes of the domain errors, so they keep
+// them when the repositories pass them on.
+var (
+	ErrNotFound = domain.NotFound("not found")
+	// ErrDuplicate is wrapped by the errors of every unique key violation.
+	ErrDuplicate         = &domain.Error{Code: domain.CodeDuplicate, Message: "duplicate"}
+	ErrDuplicateDNI      = &domain.Error{Code: domain.CodeDuplicateDNI, Message: "duplicate dni", Err: ErrDuplicate}
+	ErrDuplicateLicense  = &domain.Error{Code: domain.CodeDuplicateLicense, Message: "duplicate license", Err: ErrDuplicate}
+	ErrDuplicateUsername = &domain.Error{Code: domain.CodeDuplicateUsername, Message: "duplicate username", Err: ErrDuplicate}
+)
+
+const (
+	// mysqlDuplicateEntry is the MySQL error number of a unique key violation.
+	mysqlDuplicateEntry = 1062
+	// postgresUniqueViolation is the PostgreSQL SQLSTATE of a unique key
+	// violation.
+	postgresUniqueViolation = "23505"
+)
+
+// sqlError translates the errors of the database drivers into the errors of
+// the store interfaces and returns any other error as is.
+func sqlError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return duplicateError(mysqlErr.Message, err)
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY) {
+		return duplicateError(sqliteErr.Error(), err)
+	}
+	var postgresErr *pgconn.PgError
+	if errors.As(err, &postgresErr) && postgresErr.Code == postgresUniqueViolation {
+		return duplicateError(postgresErr.ConstraintName, err)
+	}
+	return err
+}
+
+// duplicateError tells which unique column a violation is about from the
+// message of the database, which names the column or its index.
+func duplicateError(message string, err error) error {
+	switch {
+	case strings.Contains(message, "dni"):
+		return ErrDuplicateDNI
+	case strings.Contains(message, "license"):
+		return ErrDuplicateLicense
+	case strings.Contains(message, "username"):
+		return ErrDuplicateUsername
+	}
+	return fmt.Errorf("%w: %w", ErrDuplicate, err)
+}
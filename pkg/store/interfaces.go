@@ -1,32 +1,102 @@
 package store
 
-import "github.com/JulietaAlfie/backendGo.git/internal/domain"
+import (
+	"context"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+// The stores of dentists, patients and appointments soft delete: Delete marks
+// the record as deleted, which hides it from every read, Restore brings it
+// back and Purge removes a deleted record for good. Deleting a dentist or a
+// patient deletes their appointments too and returns them. Restoring them
+// restores the appointments deleted at the same time, which MySQL and SQLite
+// keep to the second, except those whose other participant is deleted or whose
+// interval was booked meanwhile, and returns the restored ones. The three
+// return ErrNotFound when there is no record to delete, restore or purge.
+//
+// Search returns the page of the records that match the query, without the
+// deleted ones, and how many match in total.
+//
+// Find returns the page of the patients that match the text of a search, the
+// best matches first, and how many match in total; see domain.MatchPatient.
+// The SQL stores keep only the patients that may match, see domain.FilterTerm,
+// and the matching runs in Go over those, so it behaves the same on every
+// database.
 
 type StoreInterfaceDentist interface {
-	Read(id int) (domain.Dentist, error)
-	ReadAll() ([]domain.Dentist, error)
-	Create(dentist domain.Dentist) (int, error)
-	Update(dentist domain.Dentist) error
-	Delete(id int) error
-	Exists(license string) bool
+	Read(ctx context.Context, id int) (domain.Dentist, error)
+	ReadAll(ctx context.Context) ([]domain.Dentist, error)
+	Search(ctx context.Context, query domain.DentistQuery) ([]domain.Dentist, int, error)
+	Create(ctx context.Context, dentist domain.Dentist) (int, error)
+	Update(ctx context.Context, dentist domain.Dentist) error
+	Delete(ctx context.Context, id int) ([]domain.Appointment, error)
+	Restore(ctx context.Context, id int) ([]domain.Appointment, error)
+	Purge(ctx context.Context, id int) error
+	Exists(ctx context.Context, license string) bool
+	ReadByLicense(ctx context.Context, license string) (domain.Dentist, error)
+	ReadSchedule(ctx context.Context, dentistId int) (domain.Schedule, error)
+	UpdateSchedule(ctx context.Context, schedule domain.Schedule) error
 }
 
 type StoreInterfacePatient interface {
-	Read(id int) (domain.Patient, error)
-	ReadByDNI(dni int) (domain.Patient, error)
-	ReadAll() ([]domain.Patient, error)
-	Create(patient domain.Patient) (int, error)
-	Update(patient domain.Patient) error
-	Delete(id int) error
-	Exists(dni int) bool
+	Read(ctx context.Context, id int) (domain.Patient, error)
+	ReadByDNI(ctx context.Context, dni int) (domain.Patient, error)
+	ReadAll(ctx context.Context) ([]domain.Patient, error)
+	Search(ctx context.Context, query domain.PatientQuery) ([]domain.Patient, int, error)
+	Find(ctx context.Context, text string, page domain.Page) ([]domain.Patient, int, error)
+	Create(ctx context.Context, patient domain.Patient) (int, error)
+	Update(ctx context.Context, patient domain.Patient) error
+	Delete(ctx context.Context, id int) ([]domain.Appointment, error)
+	Restore(ctx context.Context, id int) ([]domain.Appointment, error)
+	Purge(ctx context.Context, id int) error
+	Exists(ctx context.Context, dni int) bool
 }
 
 type StoreInterfaceAppointment interface {
-	Read(id int) (domain.Appointment, error)
-	ReadByDNI(dni int) (domain.Appointment, error)
-	ReadAll() ([]domain.Appointment, error)
-	Create(appointment domain.Appointment) (int, error)
-	CreateByDniAndLicence(dni int, license string, date string, time string, description string) (domain.Appointment, error)
-	Update(appointment domain.Appointment) error
-	Delete(id int) error
+	Read(ctx context.Context, id int) (domain.Appointment, error)
+	ReadByDentist(ctx context.Context, dentistId int, from time.Time, to time.Time) ([]domain.Appointment, error)
+	ReadAll(ctx context.Context) ([]domain.Appointment, error)
+	Search(ctx context.Context, query domain.AppointmentQuery) ([]domain.Appointment, int, error)
+	Create(ctx context.Context, appointment domain.Appointment) (int, error)
+	CreateByDniAndLicence(ctx context.Context, dni int, license string, appointment domain.Appointment) (domain.Appointment, error)
+	Update(ctx context.Context, appointment domain.Appointment) error
+	Delete(ctx context.Context, id int) error
+	// Restore fails like Create if the patient or the dentist is deleted or
+	// if the appointment overlaps another one.
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, id int) error
+}
+
+type StoreInterfaceUser interface {
+	Read(ctx context.Context, id int) (domain.User, error)
+	ReadByUsername(ctx context.Context, username string) (domain.User, error)
+	Create(ctx context.Context, user domain.User) (int, error)
+}
+
+// StoreInterfaceToken keeps the ids of the revoked tokens until they expire.
+// Revoke reports whether it revoked the token, false if it already was, so
+// that of two concurrent revocations only one succeeds.
+type StoreInterfaceToken interface {
+	Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
+
+// StoreInterfaceAPIKey keeps the API keys, looked up by the hash of the key.
+type StoreInterfaceAPIKey interface {
+	Read(ctx context.Context, id int) (domain.APIKey, error)
+	ReadByHash(ctx context.Context, hash string) (domain.APIKey, error)
+	ReadAll(ctx context.Context) ([]domain.APIKey, error)
+	Create(ctx context.Context, key domain.APIKey) (int, error)
+	Update(ctx context.Context, key domain.APIKey) error
+	Delete(ctx context.Context, id int) error
+	Touch(ctx context.Context, id int, at time.Time) error
+}
+
+// StoreInterfaceAudit appends to the audit log and searches it, newest entries
+// first. Entries are never changed nor deleted.
+type StoreInterfaceAudit interface {
+	Create(ctx context.Context, entry domain.AuditEntry) (int, error)
+	Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error)
 }
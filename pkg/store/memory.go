@@ -0,0 +1,140 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+// MemoryDB holds the tables shared by the in-memory stores, so appointments can
+// be joined with their patient and dentist. It is safe for concurrent use and
+// mirrors the MySQL schema: ids given by table, unique DNI, license and
+// username, deleted rows kept until they are purged, and purging a patient or
+// a dentist purges their appointments.
+type MemoryDB struct {
+	mu           sync.RWMutex
+	lastIds      map[string]int // the last id given in each table
+	dentists     map[int]domain.Dentist
+	schedules    map[int]domain.Schedule
+	patients     map[int]domain.Patient
+	appointments map[int]domain.Appointment
+	deleted      map[string]map[int]time.Time // when the dentists, patients and appointments were deleted, by table
+	users        map[int]domain.User
+	revoked      map[string]time.Time // expiry of the revoked tokens
+	apiKeys      map[int]domain.APIKey
+	audit        []domain.AuditEntry // in insertion order
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		lastIds:      map[string]int{},
+		dentists:     map[int]domain.Dentist{},
+		schedules:    map[int]domain.Schedule{},
+		patients:     map[int]domain.Patient{},
+		appointments: map[int]domain.Appointment{},
+		deleted: map[string]map[int]time.Time{
+			"dentists":     {},
+			"patients":     {},
+			"appointments": {},
+		},
+		users:   map[int]domain.User{},
+		revoked: map[string]time.Time{},
+		apiKeys: map[int]domain.APIKey{},
+	}
+}
+
+// rlock takes the read lock, unless ctx is done.
+func (db *MemoryDB) rlock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.RLock()
+	return nil
+}
+
+// lock takes the write lock, unless ctx is done.
+func (db *MemoryDB) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	return nil
+}
+
+// nextId returns a new id of the table, which has its own sequence like an
+// auto-increment column; the caller must hold the write lock.
+func (db *MemoryDB) nextId(table string) int {
+	db.lastIds[table]++
+	return db.lastIds[table]
+}
+
+// join fills in the patient, the dentist and the duration of a stored
+// appointment; the caller must hold the lock.
+func (db *MemoryDB) join(appointment domain.Appointment) domain.Appointment {
+	appointment.Patient = db.patients[appointment.Patient.Id]
+	appointment.Dentist = db.dentists[appointment.Dentist.Id]
+	appointment.Duration = int(appointment.End.Sub(appointment.Date).Minutes())
+	return appointment
+}
+
+// isDeleted reports whether the row of the table (dentists, patients or
+// appointments) with the id is deleted; the caller must hold the lock.
+func (db *MemoryDB) isDeleted(table string, id int) bool {
+	_, ok := db.deleted[table][id]
+	return ok
+}
+
+// deleteAppointments deletes at the time the appointments that match, as when
+// their patient or dentist is deleted, and returns them joined; the caller
+// must hold the write lock.
+func (db *MemoryDB) deleteAppointments(at time.Time, match func(domain.Appointment) bool) []domain.Appointment {
+	cancelled := (&memoryStoreAppointment{db: db}).filter(match)
+	for _, appointment := range cancelled {
+		db.deleted["appointments"][appointment.Id] = at
+	}
+	return cancelled
+}
+
+// restoreAppointments restores the appointments that match and were deleted at
+// the time, as when their patient or dentist is restored, and returns them
+// joined. The ones whose other participant is deleted or whose interval was
+// booked meanwhile stay deleted. The caller must hold the write lock.
+func (db *MemoryDB) restoreAppointments(at time.Time, match func(domain.Appointment) bool) []domain.Appointment {
+	appointments := &memoryStoreAppointment{db: db}
+	cancelled := []domain.Appointment{}
+	for id, appointment := range db.appointments {
+		if deletedAt, ok := db.deleted["appointments"][id]; ok && deletedAt.Equal(at) && match(appointment) {
+			cancelled = append(cancelled, appointment)
+		}
+	}
+	sort.Slice(cancelled, func(i, j int) bool {
+		if cancelled[i].Date.Equal(cancelled[j].Date) {
+			return cancelled[i].Id < cancelled[j].Id
+		}
+		return cancelled[i].Date.Before(cancelled[j].Date)
+	})
+
+	restored := []domain.Appointment{}
+	for _, appointment := range cancelled {
+		if appointments.check(appointment) != nil {
+			continue
+		}
+		delete(db.deleted["appointments"], appointment.Id)
+		restored = append(restored, db.join(appointment))
+	}
+	return restored
+}
+
+// purgeAppointments removes the appointments that match, deleted or not; the
+// caller must hold the write lock.
+func (db *MemoryDB) purgeAppointments(match func(domain.Appointment) bool) {
+	for id, appointment := range db.appointments {
+		if match(appointment) {
+			delete(db.appointments, id)
+			delete(db.deleted["appointments"], id)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+type memoryStoreAPIKey struct {
+	db *MemoryDB
+}
+
+func NewMemoryStoreAPIKey(db *MemoryDB) StoreInterfaceAPIKey {
+	return &memoryStoreAPIKey{
+		db: db,
+	}
+}
+
+func (s *memoryStoreAPIKey) Read(ctx context.Context, id int) (domain.APIKey, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.APIKey{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	key, ok := s.db.apiKeys[id]
+	if !ok {
+		return domain.APIKey{}, ErrNotFound
+	}
+	return copyAPIKey(key), nil
+}
+
+func (s *memoryStoreAPIKey) ReadByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.APIKey{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	for _, key := range s.db.apiKeys {
+		if key.Hash == hash {
+			return copyAPIKey(key), nil
+		}
+	}
+	return domain.APIKey{}, ErrNotFound
+}
+
+func (s *memoryStoreAPIKey) ReadAll(ctx context.Context) ([]domain.APIKey, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.db.mu.RUnlock()
+
+	var keys []domain.APIKey
+	for _, key := range s.db.apiKeys {
+		keys = append(keys, copyAPIKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id < keys[j].Id })
+	return keys, nil
+}
+
+func (s *memoryStoreAPIKey) Create(ctx context.Context, key domain.APIKey) (int, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.db.mu.Unlock()
+
+	if s.hashTaken(key.Hash, 0) {
+		return 0, ErrDuplicate
+	}
+	key.Id = s.db.nextId("api_keys")
+	key.LastUsedAt = time.Time{}
+	s.db.apiKeys[key.Id] = copyAPIKey(key)
+	return key.Id, nil
+}
+
+func (s *memoryStoreAPIKey) Update(ctx context.Context, key domain.APIKey) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	current, ok := s.db.apiKeys[key.Id]
+	if !ok {
+		return ErrNotFound
+	}
+	if s.hashTaken(key.Hash, key.Id) {
+		return ErrDuplicate
+	}
+	current.Name = key.Name
+	current.Prefix = key.Prefix
+	current.Hash = key.Hash
+	current.Scopes = slices.Clone(key.Scopes)
+	current.ExpiresAt = key.ExpiresAt
+	s.db.apiKeys[key.Id] = current
+	return nil
+}
+
+func (s *memoryStoreAPIKey) Delete(ctx context.Context, id int) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.apiKeys[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.db.apiKeys, id)
+	return nil
+}
+
+func (s *memoryStoreAPIKey) Touch(ctx context.Context, id int, at time.Time) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	key, ok := s.db.apiKeys[id]
+	if ok {
+		key.LastUsedAt = at
+		s.db.apiKeys[id] = key
+	}
+	return nil
+}
+
+// hashTaken reports whether a key other than id has the hash; the caller must
+// hold the lock.
+func (s *memoryStoreAPIKey) hashTaken(hash string, id int) bool {
+	for _, key := range s.db.apiKeys {
+		if key.Hash == hash && key.Id != id {
+			return true
+		}
+	}
+	return false
+}
+
+// copyAPIKey copies the scopes too, so callers cannot change the stored key.
+func copyAPIKey(key domain.APIKey) domain.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	return key
+}
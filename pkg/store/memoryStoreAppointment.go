@@ -0,0 +1,228 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+type memoryStoreAppointment struct {
+	db *MemoryDB
+}
+
+func NewMemoryStoreAppointment(db *MemoryDB) StoreInterfaceAppointment {
+	return &memoryStoreAppointment{
+		db: db,
+	}
+}
+
+func (s *memoryStoreAppointment) ReadAll(ctx context.Context) ([]domain.Appointment, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Appointment{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	return s.filter(func(domain.Appointment) bool { return true }), nil
+}
+
+func (s *memoryStoreAppointment) Search(ctx context.Context, query domain.AppointmentQuery) ([]domain.Appointment, int, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Appointment{}, 0, err
+	}
+	defer s.db.mu.RUnlock()
+
+	list := s.filter(func(appointment domain.Appointment) bool {
+		switch {
+		case query.DentistId != 0 && appointment.Dentist.Id != query.DentistId,
+			query.PatientId != 0 && appointment.Patient.Id != query.PatientId,
+			query.PatientDNI != 0 && s.db.patients[appointment.Patient.Id].DNI != query.PatientDNI,
+			!query.From.IsZero() && appointment.Date.Before(query.From),
+			!query.To.IsZero() && !appointment.Date.Before(query.To):
+			return false
+		}
+		return true
+	})
+	if query.SortBy(domain.AppointmentSortKeys) == "id" {
+		slices.SortFunc(list, func(a, b domain.Appointment) int {
+			return cmp.Compare(a.Id, b.Id)
+		})
+	}
+	if query.Desc {
+		slices.Reverse(list)
+	}
+	return paginate(list, query.Page), len(list), nil
+}
+
+func (s *memoryStoreAppointment) Read(ctx context.Context, id int) (domain.Appointment, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.Appointment{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	appointment, ok := s.db.appointments[id]
+	if !ok || s.db.isDeleted("appointments", id) {
+		return domain.Appointment{}, ErrNotFound
+	}
+	return s.db.join(appointment), nil
+}
+
+func (s *memoryStoreAppointment) ReadByDentist(ctx context.Context, dentistId int, from time.Time, to time.Time) ([]domain.Appointment, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Appointment{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	return s.filter(func(appointment domain.Appointment) bool {
+		return appointment.Dentist.Id == dentistId && appointment.End.After(from) && appointment.Date.Before(to)
+	}), nil
+}
+
+func (s *memoryStoreAppointment) Create(ctx context.Context, appointment domain.Appointment) (int, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.db.mu.Unlock()
+
+	appointment.Id = 0
+	err := s.check(appointment)
+	if err != nil {
+		return 0, err
+	}
+	appointment.Id = s.db.nextId("appointments")
+	s.db.appointments[appointment.Id] = appointment
+	return appointment.Id, nil
+}
+
+func (s *memoryStoreAppointment) CreateByDniAndLicence(ctx context.Context, dni int, license string, appointment domain.Appointment) (domain.Appointment, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return domain.Appointment{}, err
+	}
+	defer s.db.mu.Unlock()
+
+	appointment.Patient.Id = 0
+	for _, patient := range s.db.patients {
+		if patient.DNI == dni {
+			appointment.Patient = patient
+		}
+	}
+	appointment.Dentist.Id = 0
+	for _, dentist := range s.db.dentists {
+		if dentist.License == license {
+			appointment.Dentist = dentist
+		}
+	}
+	appointment.Id = 0
+	err := s.check(appointment)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	appointment.Id = s.db.nextId("appointments")
+	s.db.appointments[appointment.Id] = appointment
+	return appointment, nil
+}
+
+func (s *memoryStoreAppointment) Update(ctx context.Context, appointment domain.Appointment) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.appointments[appointment.Id]; !ok || s.db.isDeleted("appointments", appointment.Id) {
+		return ErrNotFound
+	}
+	err := s.check(appointment)
+	if err != nil {
+		return err
+	}
+	s.db.appointments[appointment.Id] = appointment
+	return nil
+}
+
+func (s *memoryStoreAppointment) Delete(ctx context.Context, id int) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.appointments[id]; !ok || s.db.isDeleted("appointments", id) {
+		return ErrNotFound
+	}
+	s.db.deleted["appointments"][id] = time.Now()
+	return nil
+}
+
+func (s *memoryStoreAppointment) Restore(ctx context.Context, id int) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	appointment, ok := s.db.appointments[id]
+	if !ok || !s.db.isDeleted("appointments", id) {
+		return ErrNotFound
+	}
+	err := s.check(appointment)
+	if err != nil {
+		return err
+	}
+	delete(s.db.deleted["appointments"], id)
+	return nil
+}
+
+func (s *memoryStoreAppointment) Purge(ctx context.Context, id int) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.appointments[id]; !ok || !s.db.isDeleted("appointments", id) {
+		return ErrNotFound
+	}
+	delete(s.db.appointments, id)
+	delete(s.db.deleted["appointments"], id)
+	return nil
+}
+
+// check verifies the patient and the dentist exist and are not deleted and
+// returns a *domain.ConflictError if either of them already has an overlapping
+// appointment; the caller must hold the write lock.
+func (s *memoryStoreAppointment) check(appointment domain.Appointment) error {
+	if _, ok := s.db.dentists[appointment.Dentist.Id]; !ok || s.db.isDeleted("dentists", appointment.Dentist.Id) {
+		return fmt.Errorf("dentist %d: %w", appointment.Dentist.Id, ErrNotFound)
+	}
+	if _, ok := s.db.patients[appointment.Patient.Id]; !ok || s.db.isDeleted("patients", appointment.Patient.Id) {
+		return fmt.Errorf("patient %d: %w", appointment.Patient.Id, ErrNotFound)
+	}
+	existing := s.filter(func(other domain.Appointment) bool {
+		return other.Id != appointment.Id &&
+			(other.Dentist.Id == appointment.Dentist.Id || other.Patient.Id == appointment.Patient.Id) &&
+			other.Overlaps(appointment)
+	})
+	if len(existing) > 0 {
+		return &domain.ConflictError{Appointment: existing[0], Dentist: existing[0].Dentist.Id == appointment.Dentist.Id}
+	}
+	return nil
+}
+
+// filter returns the joined appointments that match, sorted by date and
+// leaving out the deleted ones; the caller must hold the lock.
+func (s *memoryStoreAppointment) filter(match func(domain.Appointment) bool) []domain.Appointment {
+	list := []domain.Appointment{}
+	for _, appointment := range s.db.appointments {
+		if !s.db.isDeleted("appointments", appointment.Id) && match(appointment) {
+			list = append(list, s.db.join(appointment))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Date.Equal(list[j].Date) {
+			return list[i].Id < list[j].Id
+		}
+		return list[i].Date.Before(list[j].Date)
+	})
+	return list
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"context"
+	"slices"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+type memoryStoreAudit struct {
+	db *MemoryDB
+}
+
+func NewMemoryStoreAudit(db *MemoryDB) StoreInterfaceAudit {
+	return &memoryStoreAudit{
+		db: db,
+	}
+}
+
+func (s *memoryStoreAudit) Create(ctx context.Context, entry domain.AuditEntry) (int, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.db.mu.Unlock()
+
+	entry.Id = s.db.nextId("audit_log")
+	entry.Changes = slices.Clone(entry.Changes)
+	s.db.audit = append(s.db.audit, entry)
+	return entry.Id, nil
+}
+
+func (s *memoryStoreAudit) Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.db.mu.RUnlock()
+
+	entries := []domain.AuditEntry{}
+	for i := len(s.db.audit) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(entries) == query.Limit {
+			break
+		}
+		entry := s.db.audit[i]
+		switch {
+		case query.Entity != "" && entry.Entity != query.Entity,
+			query.EntityId != 0 && entry.EntityId != query.EntityId,
+			query.Actor != "" && entry.Actor != query.Actor,
+			!query.From.IsZero() && entry.CreatedAt.Before(query.From),
+			!query.To.IsZero() && !entry.CreatedAt.Before(query.To):
+			continue
+		}
+		entry.Changes = slices.Clone(entry.Changes)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
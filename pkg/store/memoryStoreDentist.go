@@ -0,0 +1,239 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+// compareDentists compares dentists by each sort key.
+var compareDentists = map[string]func(a, b domain.Dentist) int{
+	"id":       func(a, b domain.Dentist) int { return cmp.Compare(a.Id, b.Id) },
+	"lastname": func(a, b domain.Dentist) int { return strings.Compare(a.Lastname, b.Lastname) },
+	"name":     func(a, b domain.Dentist) int { return strings.Compare(a.Name, b.Name) },
+	"license":  func(a, b domain.Dentist) int { return strings.Compare(a.License, b.License) },
+}
+
+type memoryStoreDentist struct {
+	db *MemoryDB
+}
+
+func NewMemoryStoreDentist(db *MemoryDB) StoreInterfaceDentist {
+	return &memoryStoreDentist{
+		db: db,
+	}
+}
+
+func (s *memoryStoreDentist) ReadAll(ctx context.Context) ([]domain.Dentist, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Dentist{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	list := []domain.Dentist{}
+	for _, dentist := range s.db.dentists {
+		if !s.db.isDeleted("dentists", dentist.Id) {
+			list = append(list, dentist)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+	return list, nil
+}
+
+func (s *memoryStoreDentist) Search(ctx context.Context, query domain.DentistQuery) ([]domain.Dentist, int, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Dentist{}, 0, err
+	}
+	defer s.db.mu.RUnlock()
+
+	prefix := strings.ToLower(query.Lastname)
+	list := []domain.Dentist{}
+	for _, dentist := range s.db.dentists {
+		if !s.db.isDeleted("dentists", dentist.Id) && strings.HasPrefix(strings.ToLower(dentist.Lastname), prefix) {
+			list = append(list, dentist)
+		}
+	}
+	compare := compareDentists[query.SortBy(domain.DentistSortKeys)]
+	slices.SortFunc(list, func(a, b domain.Dentist) int {
+		return cmp.Or(compare(a, b), cmp.Compare(a.Id, b.Id))
+	})
+	if query.Desc {
+		slices.Reverse(list)
+	}
+	return paginate(list, query.Page), len(list), nil
+}
+
+func (s *memoryStoreDentist) Read(ctx context.Context, id int) (domain.Dentist, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.Dentist{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	dentist, ok := s.db.dentists[id]
+	if !ok || s.db.isDeleted("dentists", id) {
+		return domain.Dentist{}, ErrNotFound
+	}
+	return dentist, nil
+}
+
+func (s *memoryStoreDentist) ReadByLicense(ctx context.Context, license string) (domain.Dentist, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.Dentist{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	dentist, ok := s.byLicense(license)
+	if !ok || s.db.isDeleted("dentists", dentist.Id) {
+		return domain.Dentist{}, ErrNotFound
+	}
+	return dentist, nil
+}
+
+func (s *memoryStoreDentist) Create(ctx context.Context, dentist domain.Dentist) (int, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.byLicense(dentist.License); ok {
+		return 0, ErrDuplicateLicense
+	}
+	dentist.Id = s.db.nextId("dentists")
+	s.db.dentists[dentist.Id] = dentist
+	return dentist.Id, nil
+}
+
+func (s *memoryStoreDentist) Update(ctx context.Context, dentist domain.Dentist) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.dentists[dentist.Id]; !ok || s.db.isDeleted("dentists", dentist.Id) {
+		return ErrNotFound
+	}
+	if other, ok := s.byLicense(dentist.License); ok && other.Id != dentist.Id {
+		return ErrDuplicateLicense
+	}
+	s.db.dentists[dentist.Id] = dentist
+	return nil
+}
+
+func (s *memoryStoreDentist) Delete(ctx context.Context, id int) ([]domain.Appointment, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return []domain.Appointment{}, err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.dentists[id]; !ok || s.db.isDeleted("dentists", id) {
+		return []domain.Appointment{}, ErrNotFound
+	}
+	now := time.Now()
+	s.db.deleted["dentists"][id] = now
+	return s.db.deleteAppointments(now, func(appointment domain.Appointment) bool {
+		return appointment.Dentist.Id == id
+	}), nil
+}
+
+func (s *memoryStoreDentist) Restore(ctx context.Context, id int) ([]domain.Appointment, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return []domain.Appointment{}, err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.dentists[id]; !ok || !s.db.isDeleted("dentists", id) {
+		return []domain.Appointment{}, ErrNotFound
+	}
+	deletedAt := s.db.deleted["dentists"][id]
+	delete(s.db.deleted["dentists"], id)
+	return s.db.restoreAppointments(deletedAt, func(appointment domain.Appointment) bool {
+		return appointment.Dentist.Id == id
+	}), nil
+}
+
+func (s *memoryStoreDentist) Purge(ctx context.Context, id int) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.dentists[id]; !ok || !s.db.isDeleted("dentists", id) {
+		return ErrNotFound
+	}
+	delete(s.db.dentists, id)
+	delete(s.db.schedules, id)
+	delete(s.db.deleted["dentists"], id)
+	s.db.purgeAppointments(func(appointment domain.Appointment) bool {
+		return appointment.Dentist.Id == id
+	})
+	for userId, user := range s.db.users {
+		if user.DentistId == id {
+			user.DentistId = 0
+			s.db.users[userId] = user
+		}
+	}
+	return nil
+}
+
+func (s *memoryStoreDentist) Exists(ctx context.Context, license string) bool {
+	if s.db.rlock(ctx) != nil {
+		return false
+	}
+	defer s.db.mu.RUnlock()
+
+	dentist, ok := s.byLicense(license)
+	return ok && !s.db.isDeleted("dentists", dentist.Id)
+}
+
+func (s *memoryStoreDentist) ReadSchedule(ctx context.Context, dentistId int) (domain.Schedule, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.Schedule{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	schedule, ok := s.db.schedules[dentistId]
+	if !ok {
+		return domain.Schedule{DentistId: dentistId, Days: []domain.WorkingDay{}}, nil
+	}
+	return schedule, nil
+}
+
+func (s *memoryStoreDentist) UpdateSchedule(ctx context.Context, schedule domain.Schedule) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.dentists[schedule.DentistId]; !ok || s.db.isDeleted("dentists", schedule.DentistId) {
+		return ErrNotFound
+	}
+	days := []domain.WorkingDay{}
+	for _, day := range schedule.Days {
+		day.Breaks = append([]domain.Break{}, day.Breaks...)
+		days = append(days, day)
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Weekday < days[j].Weekday
+	})
+	schedule.Days = days
+	s.db.schedules[schedule.DentistId] = schedule
+	return nil
+}
+
+// byLicense looks a dentist up by license, deleted or not, as licenses stay
+// unique; the caller must hold the lock.
+func (s *memoryStoreDentist) byLicense(license string) (domain.Dentist, bool) {
+	for _, dentist := range s.db.dentists {
+		if dentist.License == license {
+			return dentist, true
+		}
+	}
+	return domain.Dentist{}, false
+}
@@ -0,0 +1,219 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+// comparePatients compares patients by each sort key.
+var comparePatients = map[string]func(a, b domain.Patient) int{
+	"id":       func(a, b domain.Patient) int { return cmp.Compare(a.Id, b.Id) },
+	"lastname": func(a, b domain.Patient) int { return strings.Compare(a.Lastname, b.Lastname) },
+	"name":     func(a, b domain.Patient) int { return strings.Compare(a.Name, b.Name) },
+	"dni":      func(a, b domain.Patient) int { return cmp.Compare(a.DNI, b.DNI) },
+}
+
+type memoryStorePatient struct {
+	db *MemoryDB
+}
+
+func NewMemoryStorePatient(db *MemoryDB) StoreInterfacePatient {
+	return &memoryStorePatient{
+		db: db,
+	}
+}
+
+func (s *memoryStorePatient) ReadAll(ctx context.Context) ([]domain.Patient, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Patient{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	list := []domain.Patient{}
+	for _, patient := range s.db.patients {
+		if !s.db.isDeleted("patients", patient.Id) {
+			list = append(list, patient)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+	return list, nil
+}
+
+func (s *memoryStorePatient) Search(ctx context.Context, query domain.PatientQuery) ([]domain.Patient, int, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Patient{}, 0, err
+	}
+	defer s.db.mu.RUnlock()
+
+	prefix := strings.ToLower(query.Lastname)
+	list := []domain.Patient{}
+	for _, patient := range s.db.patients {
+		if !s.db.isDeleted("patients", patient.Id) && strings.HasPrefix(strings.ToLower(patient.Lastname), prefix) {
+			list = append(list, patient)
+		}
+	}
+	compare := comparePatients[query.SortBy(domain.PatientSortKeys)]
+	slices.SortFunc(list, func(a, b domain.Patient) int {
+		return cmp.Or(compare(a, b), cmp.Compare(a.Id, b.Id))
+	})
+	if query.Desc {
+		slices.Reverse(list)
+	}
+	return paginate(list, query.Page), len(list), nil
+}
+
+func (s *memoryStorePatient) Find(ctx context.Context, text string, page domain.Page) ([]domain.Patient, int, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return []domain.Patient{}, 0, err
+	}
+	defer s.db.mu.RUnlock()
+
+	list := []domain.Patient{}
+	for _, patient := range s.db.patients {
+		if !s.db.isDeleted("patients", patient.Id) {
+			list = append(list, patient)
+		}
+	}
+	list = domain.RankPatients(list, domain.SearchTerms(text))
+	return paginate(list, page), len(list), nil
+}
+
+func (s *memoryStorePatient) Read(ctx context.Context, id int) (domain.Patient, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.Patient{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	patient, ok := s.db.patients[id]
+	if !ok || s.db.isDeleted("patients", id) {
+		return domain.Patient{}, ErrNotFound
+	}
+	return patient, nil
+}
+
+func (s *memoryStorePatient) ReadByDNI(ctx context.Context, dni int) (domain.Patient, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.Patient{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	patient, ok := s.byDNI(dni)
+	if !ok || s.db.isDeleted("patients", patient.Id) {
+		return domain.Patient{}, ErrNotFound
+	}
+	return patient, nil
+}
+
+func (s *memoryStorePatient) Create(ctx context.Context, patient domain.Patient) (int, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.byDNI(patient.DNI); ok {
+		return 0, ErrDuplicateDNI
+	}
+	patient.Id = s.db.nextId("patients")
+	s.db.patients[patient.Id] = patient
+	return patient.Id, nil
+}
+
+func (s *memoryStorePatient) Update(ctx context.Context, patient domain.Patient) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.patients[patient.Id]; !ok || s.db.isDeleted("patients", patient.Id) {
+		return ErrNotFound
+	}
+	if other, ok := s.byDNI(patient.DNI); ok && other.Id != patient.Id {
+		return ErrDuplicateDNI
+	}
+	s.db.patients[patient.Id] = patient
+	return nil
+}
+
+func (s *memoryStorePatient) Delete(ctx context.Context, id int) ([]domain.Appointment, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return []domain.Appointment{}, err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.patients[id]; !ok || s.db.isDeleted("patients", id) {
+		return []domain.Appointment{}, ErrNotFound
+	}
+	now := time.Now()
+	s.db.deleted["patients"][id] = now
+	return s.db.deleteAppointments(now, func(appointment domain.Appointment) bool {
+		return appointment.Patient.Id == id
+	}), nil
+}
+
+func (s *memoryStorePatient) Restore(ctx context.Context, id int) ([]domain.Appointment, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return []domain.Appointment{}, err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.patients[id]; !ok || !s.db.isDeleted("patients", id) {
+		return []domain.Appointment{}, ErrNotFound
+	}
+	deletedAt := s.db.deleted["patients"][id]
+	delete(s.db.deleted["patients"], id)
+	return s.db.restoreAppointments(deletedAt, func(appointment domain.Appointment) bool {
+		return appointment.Patient.Id == id
+	}), nil
+}
+
+func (s *memoryStorePatient) Purge(ctx context.Context, id int) error {
+	if err := s.db.lock(ctx); err != nil {
+		return err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.patients[id]; !ok || !s.db.isDeleted("patients", id) {
+		return ErrNotFound
+	}
+	delete(s.db.patients, id)
+	delete(s.db.deleted["patients"], id)
+	s.db.purgeAppointments(func(appointment domain.Appointment) bool {
+		return appointment.Patient.Id == id
+	})
+	for userId, user := range s.db.users {
+		if user.PatientId == id {
+			user.PatientId = 0
+			s.db.users[userId] = user
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorePatient) Exists(ctx context.Context, dni int) bool {
+	if s.db.rlock(ctx) != nil {
+		return false
+	}
+	defer s.db.mu.RUnlock()
+
+	patient, ok := s.byDNI(dni)
+	return ok && !s.db.isDeleted("patients", patient.Id)
+}
+
+// byDNI looks a patient up by DNI, deleted or not, as DNIs stay unique; the
+// caller must hold the lock.
+func (s *memoryStorePatient) byDNI(dni int) (domain.Patient, bool) {
+	for _, patient := range s.db.patients {
+		if patient.DNI == dni {
+			return patient, true
+		}
+	}
+	return domain.Patient{}, false
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type memoryStoreToken struct {
+	db *MemoryDB
+}
+
+func NewMemoryStoreToken(db *MemoryDB) StoreInterfaceToken {
+	return &memoryStoreToken{
+		db: db,
+	}
+}
+
+func (s *memoryStoreToken) Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return false, err
+	}
+	defer s.db.mu.Unlock()
+
+	now := time.Now()
+	for revoked, expires := range s.db.revoked {
+		if expires.Before(now) {
+			delete(s.db.revoked, revoked)
+		}
+	}
+	if _, ok := s.db.revoked[id]; ok {
+		return false, nil
+	}
+	s.db.revoked[id] = expiresAt
+	return true, nil
+}
+
+func (s *memoryStoreToken) IsRevoked(ctx context.Context, id string) (bool, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return false, err
+	}
+	defer s.db.mu.RUnlock()
+
+	_, ok := s.db.revoked[id]
+	return ok, nil
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+type memoryStoreUser struct {
+	db *MemoryDB
+}
+
+func NewMemoryStoreUser(db *MemoryDB) StoreInterfaceUser {
+	return &memoryStoreUser{
+		db: db,
+	}
+}
+
+func (s *memoryStoreUser) Read(ctx context.Context, id int) (domain.User, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.User{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	user, ok := s.db.users[id]
+	if !ok {
+		return domain.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *memoryStoreUser) ReadByUsername(ctx context.Context, username string) (domain.User, error) {
+	if err := s.db.rlock(ctx); err != nil {
+		return domain.User{}, err
+	}
+	defer s.db.mu.RUnlock()
+
+	user, ok := s.byUsername(username)
+	if !ok {
+		return domain.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (s *memoryStoreUser) Create(ctx context.Context, user domain.User) (int, error) {
+	if err := s.db.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.byUsername(user.Username); ok {
+		return 0, ErrDuplicateUsername
+	}
+	user.Id = s.db.nextId("users")
+	s.db.users[user.Id] = user
+	return user.Id, nil
+}
+
+// byUsername looks a user up; the caller must hold the lock.
+func (s *memoryStoreUser) byUsername(username string) (domain.User, bool) {
+	for _, user := range s.db.users {
+		if user.Username == username {
+			return user, true
+		}
+	}
+	return domain.User{}, false
+}
@@ -0,0 +1,44 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store/storetest"
+)
+
+func TestMemoryStores(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := store.NewMemoryDB()
+		return storetest.Stores{
+			Dentists:     store.NewMemoryStoreDentist(db),
+			Patients:     store.NewMemoryStorePatient(db),
+			Appointments: store.NewMemoryStoreAppointment(db),
+			Users:        store.NewMemoryStoreUser(db),
+			Tokens:       store.NewMemoryStoreToken(db),
+			APIKeys:      store.NewMemoryStoreAPIKey(db),
+			Audit:        store.NewMemoryStoreAudit(db),
+		}
+	})
+}
+
+// TestMemoryIdsPerTable checks that every table numbers its rows from 1, like
+// the auto-increment columns of a new database, so that the contract runs with
+// ids shared across tables.
+func TestMemoryIdsPerTable(t *testing.T) {
+	db := store.NewMemoryDB()
+	ctx := context.Background()
+	dentistId, err := store.NewMemoryStoreDentist(db).Create(ctx, domain.Dentist{Name: "Ana", Lastname: "Perez", License: "0001-1111"})
+	if err != nil {
+		t.Fatalf("Create dentist: %v", err)
+	}
+	patientId, err := store.NewMemoryStorePatient(db).Create(ctx, domain.Patient{Name: "Juan", Lastname: "Gomez", DNI: 1})
+	if err != nil {
+		t.Fatalf("Create patient: %v", err)
+	}
+	if dentistId != 1 || patientId != 1 {
+		t.Errorf("first ids: got dentist %d and patient %d, want 1 and 1", dentistId, patientId)
+	}
+}
@@ -0,0 +1,53 @@
+package store_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/migrate"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store/storetest"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// TestMySQLStores runs the contract against the database in MYSQL_TEST_DSN,
+// e.g. "root:root@tcp(localhost:3306)/test_db?parseTime=true". Its tables are
+// emptied before every subtest.
+func TestMySQLStores(t *testing.T) {
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN is not set")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	migrator, err := migrate.New(db, "mysql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		for _, table := range []string{"appointments", "dentist_breaks", "dentist_schedules", "dentists", "patients", "users", "revoked_tokens", "api_keys", "audit_log"} {
+			_, err := db.Exec("delete from " + table)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return storetest.Stores{
+			Dentists:     store.NewSqlStoreDentist(db, discard),
+			Patients:     store.NewSqlStorePatient(db, discard),
+			Appointments: store.NewSqlStoreAppointment(db, discard),
+			Users:        store.NewSqlStoreUser(db, discard),
+			Tokens:       store.NewSqlStoreToken(db, discard),
+			APIKeys:      store.NewSqlStoreAPIKey(db, discard),
+			Audit:        store.NewSqlStoreAudit(db, discard),
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+// orderBy returns the order by and limit clauses of a page: the column of the
+// sort key of the page in columns, then id, both in the order of the page. A
+// zero limit returns every row after the offset.
+func orderBy(page domain.Page, keys []string, columns map[string]string, id string) string {
+	direction := ""
+	if page.Desc {
+		direction = " desc"
+	}
+	clause := " order by " + columns[page.SortBy(keys)] + direction
+	if columns[page.SortBy(keys)] != id {
+		clause += ", " + id + direction
+	}
+	switch {
+	case page.Limit > 0:
+		clause += " limit " + strconv.Itoa(page.Limit)
+	case page.Offset > 0:
+		// MySQL has no offset without a limit.
+		clause += " limit " + strconv.Itoa(math.MaxInt32)
+	}
+	if page.Offset > 0 {
+		clause += " offset " + strconv.Itoa(page.Offset)
+	}
+	return clause
+}
+
+// likePrefix returns the pattern of a "like ? escape '!'" condition matching
+// the values that start with prefix.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(prefix) + "%"
+}
+
+// paginate returns the items of a sorted list that fall in the page.
+func paginate[T any](list []T, page domain.Page) []T {
+	start := min(max(page.Offset, 0), len(list))
+	end := len(list)
+	if page.Limit > 0 {
+		end = min(start+page.Limit, end)
+	}
+	return list[start:end]
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// The PostgreSQL stores run the SQL of the MySQL ones with numbered
+// placeholders, reading the ids of new rows with "returning id".
+
+func NewPostgresStoreDentist(db *sql.DB, log *slog.Logger) StoreInterfaceDentist {
+	return &sqlStoreDentist{
+		db:      conn{db, log},
+		dialect: postgresDialect,
+	}
+}
+
+func NewPostgresStorePatient(db *sql.DB, log *slog.Logger) StoreInterfacePatient {
+	return &sqlStorePatient{
+		db:      conn{db, log},
+		dialect: postgresDialect,
+	}
+}
+
+func NewPostgresStoreAppointment(db *sql.DB, log *slog.Logger) StoreInterfaceAppointment {
+	return &sqlStoreAppointment{
+		db:      conn{db, log},
+		dialect: postgresDialect,
+	}
+}
+
+func NewPostgresStoreUser(db *sql.DB, log *slog.Logger) StoreInterfaceUser {
+	return &sqlStoreUser{
+		db:      conn{db, log},
+		dialect: postgresDialect,
+	}
+}
+
+func NewPostgresStoreToken(db *sql.DB, log *slog.Logger) StoreInterfaceToken {
+	return &sqlStoreToken{
+		db:      conn{db, log},
+		dialect: postgresDialect,
+	}
+}
+
+func NewPostgresStoreAPIKey(db *sql.DB, log *slog.Logger) StoreInterfaceAPIKey {
+	return &sqlStoreAPIKey{
+		db:      conn{db, log},
+		dialect: postgresDialect,
+	}
+}
+
+func NewPostgresStoreAudit(db *sql.DB, log *slog.Logger) StoreInterfaceAudit {
+	return &sqlStoreAudit{
+		db:      conn{db, log},
+		dialect: postgresDialect,
+	}
+}
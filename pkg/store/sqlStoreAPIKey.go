@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+const selectAPIKeys = "select id, name, prefix, hash, scopes, created_at, expires_at, last_used_at from api_keys"
+
+type sqlStoreAPIKey struct {
+	db      conn
+	dialect dialect
+}
+
+func NewSqlStoreAPIKey(db *sql.DB, log *slog.Logger) StoreInterfaceAPIKey {
+	return &sqlStoreAPIKey{
+		db:      conn{db, log},
+		dialect: mysqlDialect,
+	}
+}
+
+func (s *sqlStoreAPIKey) Read(ctx context.Context, id int) (domain.APIKey, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectAPIKeys+" where id = ?"), id)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return domain.APIKey{}, sqlError(err)
+	}
+	return key, nil
+}
+
+func (s *sqlStoreAPIKey) ReadByHash(ctx context.Context, hash string) (domain.APIKey, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectAPIKeys+" where hash = ?"), hash)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return domain.APIKey{}, sqlError(err)
+	}
+	return key, nil
+}
+
+func (s *sqlStoreAPIKey) ReadAll(ctx context.Context) ([]domain.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, selectAPIKeys+" order by id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []domain.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *sqlStoreAPIKey) Create(ctx context.Context, key domain.APIKey) (int, error) {
+	query := "insert into api_keys (name, prefix, hash, scopes, created_at, expires_at) values (?, ?, ?, ?, ?, ?)"
+	return s.dialect.insert(ctx, s.db, query, key.Name, key.Prefix, key.Hash, joinScopes(key.Scopes), key.CreatedAt, nullTime(key.ExpiresAt))
+}
+
+// Update replaces the name, the secret and the expiry of a key.
+func (s *sqlStoreAPIKey) Update(ctx context.Context, key domain.APIKey) error {
+	query := "update api_keys set name = ?, prefix = ?, hash = ?, scopes = ?, expires_at = ? where id = ?"
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind(query), key.Name, key.Prefix, key.Hash, joinScopes(key.Scopes), nullTime(key.ExpiresAt), key.Id)
+	if err != nil {
+		return sqlError(err)
+	}
+	return s.dialect.checkUpdated(ctx, s.db, res, "select id from api_keys where id = ?", key.Id)
+}
+
+func (s *sqlStoreAPIKey) Delete(ctx context.Context, id int) error {
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind("delete from api_keys where id = ?"), id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *sqlStoreAPIKey) Touch(ctx context.Context, id int, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, s.dialect.rebind("update api_keys set last_used_at = ? where id = ?"), at, id)
+	return err
+}
+
+func scanAPIKey(row scanner) (domain.APIKey, error) {
+	var key domain.APIKey
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullTime
+	err := row.Scan(&key.Id, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.CreatedAt, &expiresAt, &lastUsedAt)
+	key.Scopes = splitScopes(scopes)
+	key.ExpiresAt = expiresAt.Time
+	key.LastUsedAt = lastUsedAt.Time
+	return key, err
+}
+
+// joinScopes stores the scopes of a key as a space separated list.
+func joinScopes(scopes []domain.Permission) string {
+	list := make([]string, len(scopes))
+	for i, scope := range scopes {
+		list[i] = string(scope)
+	}
+	return strings.Join(list, " ")
+}
+
+func splitScopes(scopes string) []domain.Permission {
+	list := []domain.Permission{}
+	for _, scope := range strings.Fields(scopes) {
+		list = append(list, domain.Permission(scope))
+	}
+	return list
+}
+
+// nullTime stores the zero time, which means never, as NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -1,135 +1,331 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 )
 
+// joinAppointments reads the appointments, deleted or not, joined with their
+// patient and dentist, in the order expected by scanAppointment.
+const joinAppointments = "select t.id, t.patient_id, p.name, p.lastname, p.residence, p.dni, p.discharge_date, t.dentist_id, o.name, o.lastname, o.license, t.date, t.end_date, t.treatment, t.description from appointments t inner join dentists o on t.dentist_id = o.id inner join patients p on t.patient_id = p.id"
+
+// selectAppointments reads the appointments that are not deleted.
+const selectAppointments = joinAppointments + " where t.deleted_at is null"
+
+// appointmentSortColumns maps the sort keys of the appointments to their
+// column.
+var appointmentSortColumns = map[string]string{"date": "t.date", "id": "t.id"}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
 type sqlStoreAppointment struct {
-	db *sql.DB
+	db      conn
+	dialect dialect
 }
 
-func NewSqlStoreAppointment(db *sql.DB) StoreInterfaceAppointment {
+func NewSqlStoreAppointment(db *sql.DB, log *slog.Logger) StoreInterfaceAppointment {
 	return &sqlStoreAppointment{
-		db: db,
+		db:      conn{db, log},
+		dialect: mysqlDialect,
 	}
 }
 
-func (s *sqlStoreAppointment) ReadAll() ([]domain.Appointment, error) {
-	list := []domain.Appointment{}
-
-	rows, err := s.db.Query("select t.id, t.patient_id, p.name, p.lastname, p.residence, p.dni, p.discharge_date, t.dentist_id, o.name, o.lastname, o.license, t.date, t.time, t.description from appointments t	inner join dentists o on t.dentist_id = o.id 	inner join patients p on t.patient_id = p.id ")
+func (s *sqlStoreAppointment) ReadAll(ctx context.Context) ([]domain.Appointment, error) {
+	rows, err := s.db.QueryContext(ctx, selectAppointments+" order by t.date, t.id")
 	if err != nil {
-		return list, err
-	}
-
-	for rows.Next() {
-		var appointment domain.Appointment
-		err := rows.Scan(&appointment.Id, &appointment.Patient.Id, &appointment.Patient.Name, &appointment.Patient.Lastname, &appointment.Patient.Residence, &appointment.Patient.DNI, &appointment.Patient.DischargeDate, &appointment.Dentist.Id, &appointment.Dentist.Name, &appointment.Dentist.Lastname, &appointment.Dentist.License, &appointment.Date, &appointment.Time, &appointment.Description)
-		if err != nil {
-			return []domain.Appointment{}, err
-		}
-		list = append(list, appointment)
+		return []domain.Appointment{}, err
 	}
-	return list, nil
+	return scanAppointments(rows)
 }
 
-func (s *sqlStoreAppointment) Read(id int) (domain.Appointment, error) {
-	var appointment domain.Appointment
-	row := s.db.QueryRow("select t.id, t.patient_id, p.name, p.lastname, p.residence, p.dni, p.discharge_date, t.dentist_id, o.name, o.lastname, o.license, t.date, t.time, t.description from appointments t	inner join dentists o on t.dentist_id = o.id 	inner join patients p on t.patient_id = p.id where t.id= ?", id)
-	err := row.Scan(&appointment.Id, &appointment.Patient.Id, &appointment.Patient.Name, &appointment.Patient.Lastname, &appointment.Patient.Residence, &appointment.Patient.DNI, &appointment.Patient.DischargeDate, &appointment.Dentist.Id, &appointment.Dentist.Name, &appointment.Dentist.Lastname, &appointment.Dentist.License, &appointment.Date, &appointment.Time, &appointment.Description)
+func (s *sqlStoreAppointment) Search(ctx context.Context, query domain.AppointmentQuery) ([]domain.Appointment, int, error) {
+	where := ""
+	var args []interface{}
+	if query.DentistId != 0 {
+		where += " and t.dentist_id = ?"
+		args = append(args, query.DentistId)
+	}
+	if query.PatientId != 0 {
+		where += " and t.patient_id = ?"
+		args = append(args, query.PatientId)
+	}
+	if query.PatientDNI != 0 {
+		where += " and p.dni = ?"
+		args = append(args, query.PatientDNI)
+	}
+	if !query.From.IsZero() {
+		where += " and t.date >= ?"
+		args = append(args, query.From)
+	}
+	if !query.To.IsZero() {
+		where += " and t.date < ?"
+		args = append(args, query.To)
+	}
+	var total int
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("select count(*) from appointments t inner join patients p on t.patient_id = p.id where t.deleted_at is null"+where), args...).Scan(&total)
 	if err != nil {
-		return domain.Appointment{}, err
+		return []domain.Appointment{}, 0, err
 	}
-	return appointment, nil
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(selectAppointments+where+orderBy(query.Page, domain.AppointmentSortKeys, appointmentSortColumns, "t.id")), args...)
+	if err != nil {
+		return []domain.Appointment{}, 0, err
+	}
+	list, err := scanAppointments(rows)
+	return list, total, err
 }
 
-func (s *sqlStoreAppointment) ReadByDNI(dni int) (domain.Appointment, error) {
-	var appointment domain.Appointment
-	row := s.db.QueryRow("select t.id, t.patient_id, p.name, p.lastname, p.residence, p.dni, p.discharge_date, t.dentist_id, o.name, o.lastname, o.license, t.date, t.time, t.description from appointments t 	inner join dentists o on t.dentist_id = o.id inner join patients p on t.patient_id = p.id where dni= ?", dni)
-	err := row.Scan(&appointment.Id, &appointment.Patient.Id, &appointment.Patient.Name, &appointment.Patient.Lastname, &appointment.Patient.Residence, &appointment.Patient.DNI, &appointment.Patient.DischargeDate, &appointment.Dentist.Id, &appointment.Dentist.Name, &appointment.Dentist.Lastname, &appointment.Dentist.License, &appointment.Date, &appointment.Time, &appointment.Description)
+func (s *sqlStoreAppointment) Read(ctx context.Context, id int) (domain.Appointment, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectAppointments+" and t.id = ?"), id)
+	appointment, err := scanAppointment(row)
+	return appointment, sqlError(err)
+}
+
+func (s *sqlStoreAppointment) ReadByDentist(ctx context.Context, dentistId int, from time.Time, to time.Time) ([]domain.Appointment, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(selectAppointments+" and t.dentist_id = ? and t.end_date > ? and t.date < ? order by t.date"), dentistId, from, to)
 	if err != nil {
-		return domain.Appointment{}, err
+		return []domain.Appointment{}, err
 	}
-	return appointment, nil
+	return scanAppointments(rows)
 }
 
-func (s *sqlStoreAppointment) Create(appointment domain.Appointment) (int, error) {
-	query := "insert into appointments (patient_id, dentist_id, date, time, description) values (?, ?, ?, ?, ?)"
-	st, err := s.db.Prepare(query)
+func (s *sqlStoreAppointment) Create(ctx context.Context, appointment domain.Appointment) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
-	res, err := st.Exec(&appointment.Patient.Id, &appointment.Dentist.Id, &appointment.Date, &appointment.Time, &appointment.Description)
+	defer tx.Rollback()
+
+	err = s.lockParticipants(ctx, tx, appointment)
 	if err != nil {
 		return 0, err
 	}
-
-	id, err := res.LastInsertId()
+	err = s.checkConflicts(ctx, tx, appointment)
 	if err != nil {
 		return 0, err
 	}
 
-	_, err = res.RowsAffected()
+	query := "insert into appointments (patient_id, dentist_id, date, end_date, treatment, description) values (?, ?, ?, ?, ?, ?)"
+	id, err := s.dialect.insert(ctx, tx, query, appointment.Patient.Id, appointment.Dentist.Id, appointment.Date, appointment.End, appointment.Treatment, appointment.Description)
 	if err != nil {
 		return 0, err
 	}
-	return int(id), nil
+	return id, tx.Commit()
 }
 
-func (s *sqlStoreAppointment) CreateByDniAndLicence(dni int, license string, date string, time string, description string) (domain.Appointment, error) {
-	var appointment domain.Appointment
-	patient := s.db.QueryRow("select * from patients where dni = ?", dni)
-	err := patient.Scan(&appointment.Patient.Id, &appointment.Patient.Name, &appointment.Patient.Lastname, &appointment.Patient.Residence, &appointment.Patient.DNI, &appointment.Patient.DischargeDate)
+func (s *sqlStoreAppointment) CreateByDniAndLicence(ctx context.Context, dni int, license string, appointment domain.Appointment) (domain.Appointment, error) {
+	var err error
+	appointment.Patient, err = scanPatient(s.db.QueryRowContext(ctx, s.dialect.rebind(selectPatients+" and dni = ?"), dni))
 	if err != nil {
-		return domain.Appointment{}, err
+		return domain.Appointment{}, sqlError(err)
 	}
-	dentist := s.db.QueryRow("select * from dentists where license = ?", license)
-	err2 := dentist.Scan(&appointment.Dentist.Id, &appointment.Dentist.Lastname, &appointment.Dentist.Name, &appointment.Dentist.License)
-	if err2 != nil {
-		return domain.Appointment{}, err
+	appointment.Dentist, err = scanDentist(s.db.QueryRowContext(ctx, s.dialect.rebind(selectDentists+" and license = ?"), license))
+	if err != nil {
+		return domain.Appointment{}, sqlError(err)
 	}
-	query := "insert into appointments (patient_id, dentist_id, date, time, description) values (?, ?, ?, ?, ?)"
-	st, err := s.db.Prepare(query)
+
+	id, err := s.Create(ctx, appointment)
 	if err != nil {
 		return domain.Appointment{}, err
 	}
-	res, err := st.Exec(appointment.Patient.Id, appointment.Dentist.Id, date, time, description)
+	appointment.Id = id
+	return appointment, nil
+}
+
+func (s *sqlStoreAppointment) Update(ctx context.Context, appointment domain.Appointment) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return domain.Appointment{}, err
+		return err
 	}
+	defer tx.Rollback()
 
-	id, err := res.LastInsertId()
+	var id int
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("select id from appointments where id = ? and deleted_at is null"+s.dialect.forUpdate), appointment.Id).Scan(&id)
 	if err != nil {
-		return domain.Appointment{}, err
+		return sqlError(err)
 	}
-	appointment.Id = int(id)
-	_, err = res.RowsAffected()
+	err = s.lockParticipants(ctx, tx, appointment)
 	if err != nil {
-		return domain.Appointment{}, err
+		return err
 	}
-	return appointment, nil
+	err = s.checkConflicts(ctx, tx, appointment)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, s.dialect.rebind("UPDATE appointments SET patient_id = ?, dentist_id = ?, date = ?, end_date = ?, treatment = ?, description = ? WHERE id = ?"), appointment.Patient.Id, appointment.Dentist.Id, appointment.Date, appointment.End, appointment.Treatment, appointment.Description, appointment.Id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *sqlStoreAppointment) Update(appointment domain.Appointment) error {
-	stmt, err := s.db.Prepare("UPDATE appointments SET patient_id = ?, dentist_id = ?, date = ?, time = ?, description = ? WHERE id = ?")
+func (s *sqlStoreAppointment) Delete(ctx context.Context, id int) error {
+	return s.dialect.softDelete(ctx, s.db, "appointments", id, time.Now())
+}
+
+func (s *sqlStoreAppointment) Restore(ctx context.Context, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(&appointment.Patient.Id, &appointment.Dentist.Id, &appointment.Date, &appointment.Time, &appointment.Description, appointment.Id)
+	defer tx.Rollback()
+
+	var appointment domain.Appointment
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("select id, patient_id, dentist_id, date, end_date from appointments where id = ? and deleted_at is not null"+s.dialect.forUpdate), id).Scan(&appointment.Id, &appointment.Patient.Id, &appointment.Dentist.Id, &appointment.Date, &appointment.End)
+	if err != nil {
+		return sqlError(err)
+	}
+	err = s.lockParticipants(ctx, tx, appointment)
 	if err != nil {
 		return err
 	}
+	err = s.checkConflicts(ctx, tx, appointment)
+	if err != nil {
+		return err
+	}
+
+	err = s.dialect.restore(ctx, tx, "appointments", id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStoreAppointment) Purge(ctx context.Context, id int) error {
+	return s.dialect.purge(ctx, s.db, "appointments", id)
+}
+
+// cancelAll deletes at the time the appointments of the patient or the
+// dentist whose id is in column, and returns them.
+func (s *sqlStoreAppointment) cancelAll(ctx context.Context, tx querier, column string, id int, at time.Time) ([]domain.Appointment, error) {
+	rows, err := tx.QueryContext(ctx, s.dialect.rebind(selectAppointments+" and t."+column+" = ? order by t.date, t.id"), id)
+	if err != nil {
+		return []domain.Appointment{}, err
+	}
+	cancelled, err := scanAppointments(rows)
+	if err != nil {
+		return []domain.Appointment{}, err
+	}
+	_, err = tx.ExecContext(ctx, s.dialect.rebind("update appointments set deleted_at = ? where "+column+" = ? and deleted_at is null"), at, id)
+	if err != nil {
+		return []domain.Appointment{}, err
+	}
+	return cancelled, nil
+}
+
+// restoreAll restores the appointments that were deleted along with the
+// deleted patient or dentist of the table whose id is in column, and returns
+// them. It first locks the patient or dentist, or returns ErrNotFound if it
+// is not deleted. The appointments whose other participant is deleted or
+// whose interval was booked meanwhile stay deleted.
+func (s *sqlStoreAppointment) restoreAll(ctx context.Context, tx querier, table string, column string, id int) ([]domain.Appointment, error) {
+	restored := []domain.Appointment{}
+	err := tx.QueryRowContext(ctx, s.dialect.rebind("select id from "+table+" where id = ? and deleted_at is not null"+s.dialect.forUpdate), id).Scan(&id)
+	if err != nil {
+		return restored, sqlError(err)
+	}
+	rows, err := tx.QueryContext(ctx, s.dialect.rebind(joinAppointments+" where t."+column+" = ? and t.deleted_at = (select deleted_at from "+table+" where id = ?) order by t.date, t.id"), id, id)
+	if err != nil {
+		return restored, err
+	}
+	cancelled, err := scanAppointments(rows)
+	if err != nil {
+		return restored, err
+	}
+	err = s.dialect.restore(ctx, tx, table, id)
+	if err != nil {
+		return restored, err
+	}
+
+	for _, appointment := range cancelled {
+		err = s.lockParticipants(ctx, tx, appointment)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return []domain.Appointment{}, err
+		}
+		err = s.checkConflicts(ctx, tx, appointment)
+		var conflict *domain.ConflictError
+		if errors.As(err, &conflict) {
+			continue
+		}
+		if err != nil {
+			return []domain.Appointment{}, err
+		}
+		err = s.dialect.restore(ctx, tx, "appointments", appointment.Id)
+		if err != nil {
+			return []domain.Appointment{}, err
+		}
+		restored = append(restored, appointment)
+	}
+	return restored, nil
+}
+
+// lockParticipants takes a row lock on the dentist and the patient of the
+// appointment, which must not be deleted, so concurrent bookings for either of
+// them are serialized until the transaction ends. The dentist is always locked
+// first to avoid deadlocks.
+func (s *sqlStoreAppointment) lockParticipants(ctx context.Context, tx querier, appointment domain.Appointment) error {
+	var id int
+	err := tx.QueryRowContext(ctx, s.dialect.rebind("select id from dentists where id = ? and deleted_at is null"+s.dialect.forUpdate), appointment.Dentist.Id).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("dentist %d: %w", appointment.Dentist.Id, sqlError(err))
+	}
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("select id from patients where id = ? and deleted_at is null"+s.dialect.forUpdate), appointment.Patient.Id).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("patient %d: %w", appointment.Patient.Id, sqlError(err))
+	}
 	return nil
 }
 
-func (s *sqlStoreAppointment) Delete(id int) error {
-	stmt := "delete from appointments where id = ?"
-	_, err := s.db.Exec(stmt, id)
+// checkConflicts returns a *domain.ConflictError naming the first appointment,
+// other than the given one, that the dentist or the patient already has
+// overlapping its interval.
+func (s *sqlStoreAppointment) checkConflicts(ctx context.Context, tx querier, appointment domain.Appointment) error {
+	rows, err := tx.QueryContext(ctx, s.dialect.rebind(selectAppointments+" and t.id <> ? and (t.dentist_id = ? or t.patient_id = ?) and t.date < ? and t.end_date > ? order by t.date"), appointment.Id, appointment.Dentist.Id, appointment.Patient.Id, appointment.End, appointment.Date)
+	if err != nil {
+		return err
+	}
+	existing, err := scanAppointments(rows)
 	if err != nil {
 		return err
 	}
+	for _, e := range existing {
+		if e.Overlaps(appointment) {
+			return &domain.ConflictError{Appointment: e, Dentist: e.Dentist.Id == appointment.Dentist.Id}
+		}
+	}
 	return nil
 }
+
+func scanAppointment(row scanner) (domain.Appointment, error) {
+	var appointment domain.Appointment
+	err := row.Scan(&appointment.Id, &appointment.Patient.Id, &appointment.Patient.Name, &appointment.Patient.Lastname, &appointment.Patient.Residence, &appointment.Patient.DNI, &appointment.Patient.DischargeDate, &appointment.Dentist.Id, &appointment.Dentist.Name, &appointment.Dentist.Lastname, &appointment.Dentist.License, &appointment.Date, &appointment.End, &appointment.Treatment, &appointment.Description)
+	if err != nil {
+		return domain.Appointment{}, err
+	}
+	appointment.Duration = int(appointment.End.Sub(appointment.Date) / time.Minute)
+	return appointment, nil
+}
+
+func scanAppointments(rows *sql.Rows) ([]domain.Appointment, error) {
+	defer rows.Close()
+	list := []domain.Appointment{}
+	for rows.Next() {
+		appointment, err := scanAppointment(rows)
+		if err != nil {
+			return []domain.Appointment{}, err
+		}
+		list = append(list, appointment)
+	}
+	return list, rows.Err()
+}
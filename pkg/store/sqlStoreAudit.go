@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+const selectAudit = "select id, actor, action, entity, entity_id, changes, request_id, created_at from audit_log"
+
+type sqlStoreAudit struct {
+	db      conn
+	dialect dialect
+}
+
+func NewSqlStoreAudit(db *sql.DB, log *slog.Logger) StoreInterfaceAudit {
+	return &sqlStoreAudit{
+		db:      conn{db, log},
+		dialect: mysqlDialect,
+	}
+}
+
+func (s *sqlStoreAudit) Create(ctx context.Context, entry domain.AuditEntry) (int, error) {
+	query := "insert into audit_log (actor, action, entity, entity_id, changes, request_id, created_at) values (?, ?, ?, ?, ?, ?, ?)"
+	return s.dialect.insert(ctx, s.db, query, entry.Actor, entry.Action, entry.Entity, entry.EntityId, string(entry.Changes), entry.RequestId, entry.CreatedAt)
+}
+
+func (s *sqlStoreAudit) Search(ctx context.Context, query domain.AuditQuery) ([]domain.AuditEntry, error) {
+	var where []string
+	var args []interface{}
+	if query.Entity != "" {
+		where = append(where, "entity = ?")
+		args = append(args, query.Entity)
+	}
+	if query.EntityId != 0 {
+		where = append(where, "entity_id = ?")
+		args = append(args, query.EntityId)
+	}
+	if query.Actor != "" {
+		where = append(where, "actor = ?")
+		args = append(args, query.Actor)
+	}
+	if !query.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, query.From)
+	}
+	if !query.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, query.To)
+	}
+	stmt := selectAudit
+	if len(where) > 0 {
+		stmt += " where " + strings.Join(where, " and ")
+	}
+	stmt += " order by id desc"
+	if query.Limit > 0 {
+		stmt += " limit " + strconv.Itoa(query.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(stmt), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var changes string
+		err := rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &entry.Entity, &entry.EntityId, &changes, &entry.RequestId, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entry.Changes = []byte(changes)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
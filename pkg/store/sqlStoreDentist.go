@@ -1,97 +1,139 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 )
 
+// selectDentists reads the dentists that are not deleted, in the order
+// expected by scanDentist.
+const selectDentists = "select id, lastname, name, license from dentists where deleted_at is null"
+
+// dentistSortColumns maps the sort keys of the dentists to their column.
+var dentistSortColumns = map[string]string{"id": "id", "lastname": "lastname", "name": "name", "license": "license"}
+
 type sqlStoreDentist struct {
-	db *sql.DB
+	db      conn
+	dialect dialect
 }
 
-func NewSqlStoreDentist(db *sql.DB) StoreInterfaceDentist {
+func NewSqlStoreDentist(db *sql.DB, log *slog.Logger) StoreInterfaceDentist {
 	return &sqlStoreDentist{
-		db: db,
+		db:      conn{db, log},
+		dialect: mysqlDialect,
 	}
 }
 
-func (s *sqlStoreDentist) ReadAll() ([]domain.Dentist, error) {
+func (s *sqlStoreDentist) ReadAll(ctx context.Context) ([]domain.Dentist, error) {
 	list := []domain.Dentist{}
 
-	rows, err := s.db.Query("select * from dentists;")
+	rows, err := s.db.QueryContext(ctx, selectDentists+" order by id")
 	if err != nil {
 		return list, err
 	}
+	return scanDentists(rows)
+}
 
-	for rows.Next() {
-		var dentist domain.Dentist
-		err := rows.Scan(&dentist.Id, &dentist.Lastname, &dentist.Name, &dentist.License)
-		if err != nil {
-			return []domain.Dentist{}, err
-		}
-		list = append(list, dentist)
+func (s *sqlStoreDentist) Search(ctx context.Context, query domain.DentistQuery) ([]domain.Dentist, int, error) {
+	where := ""
+	var args []interface{}
+	if query.Lastname != "" {
+		where += " and lower(lastname) like ? escape '!'"
+		args = append(args, likePrefix(strings.ToLower(query.Lastname)))
 	}
-	return list, nil
+	var total int
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("select count(*) from dentists where deleted_at is null"+where), args...).Scan(&total)
+	if err != nil {
+		return []domain.Dentist{}, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(selectDentists+where+orderBy(query.Page, domain.DentistSortKeys, dentistSortColumns, "id")), args...)
+	if err != nil {
+		return []domain.Dentist{}, 0, err
+	}
+	list, err := scanDentists(rows)
+	return list, total, err
 }
 
-func (s *sqlStoreDentist) Read(id int) (domain.Dentist, error) {
-	var dentist domain.Dentist
-	row := s.db.QueryRow("select * from dentists where id = ?", id)
-	err := row.Scan(&dentist.Id, &dentist.Lastname, &dentist.Name, &dentist.License)
+func (s *sqlStoreDentist) Read(ctx context.Context, id int) (domain.Dentist, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectDentists+" and id = ?"), id)
+	dentist, err := scanDentist(row)
 	if err != nil {
-		return domain.Dentist{}, err
+		return domain.Dentist{}, sqlError(err)
 	}
 	return dentist, nil
 }
 
-func (s *sqlStoreDentist) Create(dentist domain.Dentist) (int, error) {
+func (s *sqlStoreDentist) Create(ctx context.Context, dentist domain.Dentist) (int, error) {
 	query := "insert into dentists (lastname, name, license) values (?, ?, ?)"
-	st, err := s.db.Prepare(query)
+	return s.dialect.insert(ctx, s.db, query, dentist.Lastname, dentist.Name, dentist.License)
+}
+
+func (s *sqlStoreDentist) Update(ctx context.Context, dentist domain.Dentist) error {
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind("UPDATE dentists SET lastname = ?, name = ?, license = ? WHERE id = ? AND deleted_at IS NULL"), dentist.Lastname, dentist.Name, dentist.License, dentist.Id)
 	if err != nil {
-		return 0, err
+		return sqlError(err)
 	}
-	res, err := st.Exec(&dentist.Lastname, &dentist.Name, &dentist.License)
+	return s.dialect.checkUpdated(ctx, s.db, res, "select id from dentists where id = ? and deleted_at is null", dentist.Id)
+}
+
+func (s *sqlStoreDentist) Delete(ctx context.Context, id int) ([]domain.Appointment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return []domain.Appointment{}, err
 	}
+	defer tx.Rollback()
 
-	id, err := res.LastInsertId()
+	now := time.Now()
+	err = s.dialect.softDelete(ctx, tx, "dentists", id, now)
 	if err != nil {
-		return 0, err
+		return []domain.Appointment{}, err
 	}
-	_, err = res.RowsAffected()
+	cancelled, err := s.appointments().cancelAll(ctx, tx, "dentist_id", id, now)
 	if err != nil {
-		return 0, err
+		return []domain.Appointment{}, err
 	}
-	return int(id), nil
+	err = tx.Commit()
+	if err != nil {
+		return []domain.Appointment{}, err
+	}
+	return cancelled, nil
 }
 
-func (s *sqlStoreDentist) Update(dentist domain.Dentist) error {
-	stmt, err := s.db.Prepare("UPDATE dentists SET lastname = ?, name = ?, license = ? WHERE id = ?")
+func (s *sqlStoreDentist) Restore(ctx context.Context, id int) ([]domain.Appointment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return []domain.Appointment{}, err
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(dentist.Lastname, dentist.Name, dentist.License, dentist.Id)
+	defer tx.Rollback()
+
+	restored, err := s.appointments().restoreAll(ctx, tx, "dentists", "dentist_id", id)
 	if err != nil {
-		return err
+		return []domain.Appointment{}, err
 	}
-	return nil
-}
-
-func (s *sqlStoreDentist) Delete(id int) error {
-	stmt := "delete from dentists where id = ?"
-	_, err := s.db.Exec(stmt, id)
+	err = tx.Commit()
 	if err != nil {
-		return err
+		return []domain.Appointment{}, err
 	}
-	return nil
+	return restored, nil
 }
 
-func (s *sqlStoreDentist) Exists(license string) bool {
+// appointments returns the store of the appointments on the same database.
+func (s *sqlStoreDentist) appointments() *sqlStoreAppointment {
+	return &sqlStoreAppointment{db: s.db, dialect: s.dialect}
+}
+
+func (s *sqlStoreDentist) Purge(ctx context.Context, id int) error {
+	return s.dialect.purge(ctx, s.db, "dentists", id)
+}
+
+func (s *sqlStoreDentist) Exists(ctx context.Context, license string) bool {
 	var id int
-	row := s.db.QueryRow("select id from dentists where license = ?", license)
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind("select id from dentists where license = ? and deleted_at is null"), license)
 	err := row.Scan(&id)
 	if err != nil {
 		return false
@@ -103,3 +145,107 @@ func (s *sqlStoreDentist) Exists(license string) bool {
 
 	return false
 }
+
+func (s *sqlStoreDentist) ReadByLicense(ctx context.Context, license string) (domain.Dentist, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectDentists+" and license = ?"), license)
+	dentist, err := scanDentist(row)
+	if err != nil {
+		return domain.Dentist{}, sqlError(err)
+	}
+	return dentist, nil
+}
+
+func (s *sqlStoreDentist) ReadSchedule(ctx context.Context, dentistId int) (domain.Schedule, error) {
+	schedule := domain.Schedule{DentistId: dentistId, Days: []domain.WorkingDay{}}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind("select weekday, start_time, end_time, slot_minutes from dentist_schedules where dentist_id = ? order by weekday"), dentistId)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		day := domain.WorkingDay{Breaks: []domain.Break{}}
+		err := rows.Scan(&day.Weekday, &day.Start, &day.End, &day.SlotMinutes)
+		if err != nil {
+			return domain.Schedule{}, err
+		}
+		schedule.Days = append(schedule.Days, day)
+	}
+	if err := rows.Err(); err != nil {
+		return domain.Schedule{}, err
+	}
+
+	breaks, err := s.db.QueryContext(ctx, s.dialect.rebind("select weekday, start_time, end_time from dentist_breaks where dentist_id = ? order by weekday, start_time"), dentistId)
+	if err != nil {
+		return domain.Schedule{}, err
+	}
+	defer breaks.Close()
+	for breaks.Next() {
+		var weekday int
+		var b domain.Break
+		err := breaks.Scan(&weekday, &b.Start, &b.End)
+		if err != nil {
+			return domain.Schedule{}, err
+		}
+		for i := range schedule.Days {
+			if int(schedule.Days[i].Weekday) == weekday {
+				schedule.Days[i].Breaks = append(schedule.Days[i].Breaks, b)
+			}
+		}
+	}
+	return schedule, breaks.Err()
+}
+
+func (s *sqlStoreDentist) UpdateSchedule(ctx context.Context, schedule domain.Schedule) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, s.dialect.rebind("select id from dentists where id = ? and deleted_at is null"+s.dialect.forUpdate), schedule.DentistId).Scan(&id)
+	if err != nil {
+		return sqlError(err)
+	}
+	_, err = tx.ExecContext(ctx, s.dialect.rebind("delete from dentist_breaks where dentist_id = ?"), schedule.DentistId)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, s.dialect.rebind("delete from dentist_schedules where dentist_id = ?"), schedule.DentistId)
+	if err != nil {
+		return err
+	}
+	for _, day := range schedule.Days {
+		_, err = tx.ExecContext(ctx, s.dialect.rebind("insert into dentist_schedules (dentist_id, weekday, start_time, end_time, slot_minutes) values (?, ?, ?, ?, ?)"), schedule.DentistId, int(day.Weekday), day.Start, day.End, day.SlotMinutes)
+		if err != nil {
+			return err
+		}
+		for _, b := range day.Breaks {
+			_, err = tx.ExecContext(ctx, s.dialect.rebind("insert into dentist_breaks (dentist_id, weekday, start_time, end_time) values (?, ?, ?, ?)"), schedule.DentistId, int(day.Weekday), b.Start, b.End)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func scanDentists(rows *sql.Rows) ([]domain.Dentist, error) {
+	defer rows.Close()
+	list := []domain.Dentist{}
+	for rows.Next() {
+		dentist, err := scanDentist(rows)
+		if err != nil {
+			return []domain.Dentist{}, err
+		}
+		list = append(list, dentist)
+	}
+	return list, rows.Err()
+}
+
+func scanDentist(row scanner) (domain.Dentist, error) {
+	var dentist domain.Dentist
+	err := row.Scan(&dentist.Id, &dentist.Lastname, &dentist.Name, &dentist.License)
+	return dentist, err
+}
@@ -1,108 +1,182 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 )
 
+// selectPatients reads the patients that are not deleted, in the order
+// expected by scanPatient.
+const selectPatients = "select id, name, lastname, residence, dni, discharge_date from patients where deleted_at is null"
+
+// patientSortColumns maps the sort keys of the patients to their column.
+var patientSortColumns = map[string]string{"id": "id", "lastname": "lastname", "name": "name", "dni": "dni"}
+
 type sqlStorePatient struct {
-	db *sql.DB
+	db      conn
+	dialect dialect
 }
 
-func NewSqlStorePatient(db *sql.DB) StoreInterfacePatient {
+func NewSqlStorePatient(db *sql.DB, log *slog.Logger) StoreInterfacePatient {
 	return &sqlStorePatient{
-		db: db,
+		db:      conn{db, log},
+		dialect: mysqlDialect,
 	}
 }
 
-func (s *sqlStorePatient) ReadAll() ([]domain.Patient, error) {
+func (s *sqlStorePatient) ReadAll(ctx context.Context) ([]domain.Patient, error) {
 	list := []domain.Patient{}
 
-	rows, err := s.db.Query("select * from patients;")
+	rows, err := s.db.QueryContext(ctx, selectPatients+" order by id")
 	if err != nil {
 		return list, err
 	}
+	return scanPatients(rows)
+}
 
-	for rows.Next() {
-		var patient domain.Patient
-		err := rows.Scan(&patient.Id, &patient.Name, &patient.Lastname, &patient.Residence, &patient.DNI, &patient.DischargeDate)
-		if err != nil {
-			return []domain.Patient{}, err
+func (s *sqlStorePatient) Search(ctx context.Context, query domain.PatientQuery) ([]domain.Patient, int, error) {
+	where := ""
+	var args []interface{}
+	if query.Lastname != "" {
+		where += " and lower(lastname) like ? escape '!'"
+		args = append(args, likePrefix(strings.ToLower(query.Lastname)))
+	}
+	var total int
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("select count(*) from patients where deleted_at is null"+where), args...).Scan(&total)
+	if err != nil {
+		return []domain.Patient{}, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(selectPatients+where+orderBy(query.Page, domain.PatientSortKeys, patientSortColumns, "id")), args...)
+	if err != nil {
+		return []domain.Patient{}, 0, err
+	}
+	list, err := scanPatients(rows)
+	return list, total, err
+}
+
+// Find keeps in SQL the patients whose search_text may match every term, see
+// domain.FilterTerm, and ranks only those.
+func (s *sqlStorePatient) Find(ctx context.Context, text string, page domain.Page) ([]domain.Patient, int, error) {
+	terms := domain.SearchTerms(text)
+	if len(terms) == 0 {
+		return []domain.Patient{}, 0, nil
+	}
+	where := ""
+	var args []interface{}
+	for _, term := range terms {
+		filter := domain.FilterTerm(term)
+		var conditions []string
+		if filter.Contains != "" {
+			conditions = append(conditions, "search_text like ?")
+			args = append(args, "%"+filter.Contains+"%")
 		}
-		list = append(list, patient)
+		if filter.WordPrefix != "" {
+			conditions = append(conditions, "search_text like ?")
+			args = append(args, "% "+filter.WordPrefix+"%")
+		}
+		where += " and (" + strings.Join(conditions, " or ") + ")"
+	}
+	rows, err := s.db.QueryContext(ctx, s.dialect.rebind(selectPatients+where), args...)
+	if err != nil {
+		return []domain.Patient{}, 0, err
 	}
-	return list, nil
+	list, err := scanPatients(rows)
+	if err != nil {
+		return []domain.Patient{}, 0, err
+	}
+	list = domain.RankPatients(list, terms)
+	return paginate(list, page), len(list), nil
 }
 
-func (s *sqlStorePatient) Read(id int) (domain.Patient, error) {
-	var patient domain.Patient 
-	row := s.db.QueryRow("select * from patients where id = ?", id)
-	err := row.Scan(&patient.Id, &patient.Name, &patient.Lastname, &patient.Residence, &patient.DNI, &patient.DischargeDate)
+func (s *sqlStorePatient) Read(ctx context.Context, id int) (domain.Patient, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectPatients+" and id = ?"), id)
+	patient, err := scanPatient(row)
 	if err != nil {
-		return domain.Patient{}, err
+		return domain.Patient{}, sqlError(err)
 	}
 	return patient, nil
 }
 
-func (s *sqlStorePatient) ReadByDNI(dni int) (domain.Patient, error) {
-	var patient domain.Patient 
-	row := s.db.QueryRow("select * from patients where dni = ?", dni)
-	err := row.Scan(&patient.Id, &patient.Name, &patient.Lastname, &patient.Residence, &patient.DNI, &patient.DischargeDate)
+func (s *sqlStorePatient) ReadByDNI(ctx context.Context, dni int) (domain.Patient, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectPatients+" and dni = ?"), dni)
+	patient, err := scanPatient(row)
 	if err != nil {
-		return domain.Patient{}, err
+		return domain.Patient{}, sqlError(err)
 	}
 	return patient, nil
 }
 
-func (s *sqlStorePatient) Create(patient domain.Patient) (int, error) {
-	query := "insert into patients (name, lastname, residence, dni, discharge_date) values (?, ?, ?, ?, ?)"
-	st, err := s.db.Prepare(query)
+func (s *sqlStorePatient) Create(ctx context.Context, patient domain.Patient) (int, error) {
+	query := "insert into patients (name, lastname, residence, dni, discharge_date, search_text) values (?, ?, ?, ?, ?, ?)"
+	return s.dialect.insert(ctx, s.db, query, patient.Name, patient.Lastname, patient.Residence, patient.DNI, patient.DischargeDate, patient.SearchText())
+}
+
+func (s *sqlStorePatient) Update(ctx context.Context, patient domain.Patient) error {
+	res, err := s.db.ExecContext(ctx, s.dialect.rebind("UPDATE patients SET name = ?, lastname = ?, residence = ?, dni = ?, discharge_date = ?, search_text = ? WHERE id = ? AND deleted_at IS NULL"), patient.Name, patient.Lastname, patient.Residence, patient.DNI, patient.DischargeDate, patient.SearchText(), patient.Id)
 	if err != nil {
-		return 0, err
+		return sqlError(err)
 	}
-	res, err := st.Exec(&patient.Name, &patient.Lastname, &patient.Residence, &patient.DNI, &patient.DischargeDate)
+	return s.dialect.checkUpdated(ctx, s.db, res, "select id from patients where id = ? and deleted_at is null", patient.Id)
+}
+
+func (s *sqlStorePatient) Delete(ctx context.Context, id int) ([]domain.Appointment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return []domain.Appointment{}, err
 	}
+	defer tx.Rollback()
 
-	id, err := res.LastInsertId()
+	now := time.Now()
+	err = s.dialect.softDelete(ctx, tx, "patients", id, now)
 	if err != nil {
-		return 0, err
+		return []domain.Appointment{}, err
 	}
-
-	_, err = res.RowsAffected()
+	cancelled, err := s.appointments().cancelAll(ctx, tx, "patient_id", id, now)
 	if err != nil {
-		return 0, err
+		return []domain.Appointment{}, err
 	}
-	return int(id), nil
+	err = tx.Commit()
+	if err != nil {
+		return []domain.Appointment{}, err
+	}
+	return cancelled, nil
 }
 
-func (s *sqlStorePatient) Update(patient domain.Patient) error {
-	stmt, err := s.db.Prepare("UPDATE patients SET name = ?, lastname = ?, residence = ?, dni = ?, discharge_date = ? WHERE id = ?")
+func (s *sqlStorePatient) Restore(ctx context.Context, id int) ([]domain.Appointment, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return []domain.Appointment{}, err
+	}
+	defer tx.Rollback()
+
+	restored, err := s.appointments().restoreAll(ctx, tx, "patients", "patient_id", id)
 	if err != nil {
-		return err
+		return []domain.Appointment{}, err
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec(patient.Name, patient.Lastname, patient.Residence, patient.DNI, patient.DischargeDate, patient.Id)
+	err = tx.Commit()
 	if err != nil {
-		return err
+		return []domain.Appointment{}, err
 	}
-	return nil
+	return restored, nil
 }
 
-func (s *sqlStorePatient) Delete(id int) error {
-	stmt := "delete from patients where id = ?"
-	_, err := s.db.Exec(stmt, id)
-	if err != nil {
-		return err
-	}
-	return nil
+// appointments returns the store of the appointments on the same database.
+func (s *sqlStorePatient) appointments() *sqlStoreAppointment {
+	return &sqlStoreAppointment{db: s.db, dialect: s.dialect}
+}
+
+func (s *sqlStorePatient) Purge(ctx context.Context, id int) error {
+	return s.dialect.purge(ctx, s.db, "patients", id)
 }
 
-func (s *sqlStorePatient) Exists(dni int) bool {
+func (s *sqlStorePatient) Exists(ctx context.Context, dni int) bool {
 	var id int
-	row := s.db.QueryRow("select id from patients where dni = ?", dni)
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind("select id from patients where dni = ? and deleted_at is null"), dni)
 	err := row.Scan(&id)
 	if err != nil {
 		return false
@@ -114,3 +188,22 @@ func (s *sqlStorePatient) Exists(dni int) bool {
 
 	return false
 }
+
+func scanPatients(rows *sql.Rows) ([]domain.Patient, error) {
+	defer rows.Close()
+	list := []domain.Patient{}
+	for rows.Next() {
+		patient, err := scanPatient(rows)
+		if err != nil {
+			return []domain.Patient{}, err
+		}
+		list = append(list, patient)
+	}
+	return list, rows.Err()
+}
+
+func scanPatient(row scanner) (domain.Patient, error) {
+	var patient domain.Patient
+	err := row.Scan(&patient.Id, &patient.Name, &patient.Lastname, &patient.Residence, &patient.DNI, &patient.DischargeDate)
+	return patient, err
+}
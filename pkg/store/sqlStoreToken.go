@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+type sqlStoreToken struct {
+	db      conn
+	dialect dialect
+}
+
+func NewSqlStoreToken(db *sql.DB, log *slog.Logger) StoreInterfaceToken {
+	return &sqlStoreToken{
+		db:      conn{db, log},
+		dialect: mysqlDialect,
+	}
+}
+
+// Revoke records the token, dropping the ones already expired. The primary
+// key on jti makes the insert fail for a token already revoked, which is
+// reported as false and not as an error.
+func (s *sqlStoreToken) Revoke(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	_, err := s.db.ExecContext(ctx, s.dialect.rebind("delete from revoked_tokens where expires_at < ?"), time.Now())
+	if err != nil {
+		return false, err
+	}
+	_, err = s.db.ExecContext(ctx, s.dialect.rebind("insert into revoked_tokens (jti, expires_at) values (?, ?)"), id, expiresAt)
+	if errors.Is(sqlError(err), ErrDuplicate) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *sqlStoreToken) IsRevoked(ctx context.Context, id string) (bool, error) {
+	var jti string
+	err := s.db.QueryRowContext(ctx, s.dialect.rebind("select jti from revoked_tokens where jti = ?"), id).Scan(&jti)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
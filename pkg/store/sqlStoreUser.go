@@ -0,0 +1,60 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+)
+
+const selectUsers = "select id, username, password_hash, role, dentist_id, patient_id from users"
+
+type sqlStoreUser struct {
+	db      conn
+	dialect dialect
+}
+
+func NewSqlStoreUser(db *sql.DB, log *slog.Logger) StoreInterfaceUser {
+	return &sqlStoreUser{
+		db:      conn{db, log},
+		dialect: mysqlDialect,
+	}
+}
+
+func (s *sqlStoreUser) Read(ctx context.Context, id int) (domain.User, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectUsers+" where id = ?"), id)
+	user, err := scanUser(row)
+	if err != nil {
+		return domain.User{}, sqlError(err)
+	}
+	return user, nil
+}
+
+func (s *sqlStoreUser) ReadByUsername(ctx context.Context, username string) (domain.User, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.rebind(selectUsers+" where username = ?"), username)
+	user, err := scanUser(row)
+	if err != nil {
+		return domain.User{}, sqlError(err)
+	}
+	return user, nil
+}
+
+func (s *sqlStoreUser) Create(ctx context.Context, user domain.User) (int, error) {
+	query := "insert into users (username, password_hash, role, dentist_id, patient_id) values (?, ?, ?, ?, ?)"
+	return s.dialect.insert(ctx, s.db, query, user.Username, user.PasswordHash, user.Role, nullId(user.DentistId), nullId(user.PatientId))
+}
+
+func scanUser(row scanner) (domain.User, error) {
+	var user domain.User
+	var dentistId, patientId sql.NullInt64
+	err := row.Scan(&user.Id, &user.Username, &user.PasswordHash, &user.Role, &dentistId, &patientId)
+	user.DentistId = int(dentistId.Int64)
+	user.PatientId = int(patientId.Int64)
+	return user, err
+}
+
+// nullId stores the zero id, which links to nothing, as NULL.
+func nullId(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"database/sql"
+	"log/slog"
+)
+
+// The SQLite stores run the same SQL as the MySQL ones. The database must be
+// opened with foreign keys enabled, so deletes cascade, and with
+// "_txlock=immediate", so bookings are serialized as row locks do on MySQL.
+
+func NewSqliteStoreDentist(db *sql.DB, log *slog.Logger) StoreInterfaceDentist {
+	return &sqlStoreDentist{
+		db:      conn{db, log},
+		dialect: sqliteDialect,
+	}
+}
+
+func NewSqliteStorePatient(db *sql.DB, log *slog.Logger) StoreInterfacePatient {
+	return &sqlStorePatient{
+		db:      conn{db, log},
+		dialect: sqliteDialect,
+	}
+}
+
+func NewSqliteStoreAppointment(db *sql.DB, log *slog.Logger) StoreInterfaceAppointment {
+	return &sqlStoreAppointment{
+		db:      conn{db, log},
+		dialect: sqliteDialect,
+	}
+}
+
+func NewSqliteStoreUser(db *sql.DB, log *slog.Logger) StoreInterfaceUser {
+	return &sqlStoreUser{
+		db:      conn{db, log},
+		dialect: sqliteDialect,
+	}
+}
+
+func NewSqliteStoreToken(db *sql.DB, log *slog.Logger) StoreInterfaceToken {
+	return &sqlStoreToken{
+		db:      conn{db, log},
+		dialect: sqliteDialect,
+	}
+}
+
+func NewSqliteStoreAPIKey(db *sql.DB, log *slog.Logger) StoreInterfaceAPIKey {
+	return &sqlStoreAPIKey{
+		db:      conn{db, log},
+		dialect: sqliteDialect,
+	}
+}
+
+func NewSqliteStoreAudit(db *sql.DB, log *slog.Logger) StoreInterfaceAudit {
+	return &sqlStoreAudit{
+		db:      conn{db, log},
+		dialect: sqliteDialect,
+	}
+}
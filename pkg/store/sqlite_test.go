@@ -0,0 +1,44 @@
+package store_test
+
+import (
+	"database/sql"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/JulietaAlfie/backendGo.git/pkg/migrate"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store/storetest"
+	_ "modernc.org/sqlite"
+)
+
+// discard drops the statements logged by the SQL stores.
+var discard = slog.New(slog.DiscardHandler)
+
+func TestSqliteStores(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		dsn := filepath.Join(t.TempDir(), "clinic.db") + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate&_time_format=datetime&_timezone=UTC"
+		db, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		migrator, err := migrate.New(db, "sqlite")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = migrator.Up()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return storetest.Stores{
+			Dentists:     store.NewSqliteStoreDentist(db, discard),
+			Patients:     store.NewSqliteStorePatient(db, discard),
+			Appointments: store.NewSqliteStoreAppointment(db, discard),
+			Users:        store.NewSqliteStoreUser(db, discard),
+			Tokens:       store.NewSqliteStoreToken(db, discard),
+			APIKeys:      store.NewSqliteStoreAPIKey(db, discard),
+			Audit:        store.NewSqliteStoreAudit(db, discard),
+		}
+	})
+}
@@ -0,0 +1,1160 @@
+// Package storetest holds the contract every implementation of the store
+// interfaces must honour, so every backend behaves alike.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
+	"github.com/JulietaAlfie/backendGo.git/pkg/store"
+)
+
+// Stores groups the stores under test, which must share the same database.
+type Stores struct {
+	Dentists     store.StoreInterfaceDentist
+	Patients     store.StoreInterfacePatient
+	Appointments store.StoreInterfaceAppointment
+	Users        store.StoreInterfaceUser
+	Tokens       store.StoreInterfaceToken
+	APIKeys      store.StoreInterfaceAPIKey
+	Audit        store.StoreInterfaceAudit
+}
+
+// Run runs the contract against the stores returned by newStores, which is
+// called once per subtest and must return empty stores.
+func Run(t *testing.T, newStores func(t *testing.T) Stores) {
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newStores(t)) })
+	t.Run("Dentists", func(t *testing.T) { testDentists(t, newStores(t)) })
+	t.Run("Patients", func(t *testing.T) { testPatients(t, newStores(t)) })
+	t.Run("Schedule", func(t *testing.T) { testSchedule(t, newStores(t)) })
+	t.Run("AppointmentJoins", func(t *testing.T) { testAppointmentJoins(t, newStores(t)) })
+	t.Run("CreateByDniAndLicence", func(t *testing.T) { testCreateByDniAndLicence(t, newStores(t)) })
+	t.Run("Conflicts", func(t *testing.T) { testConflicts(t, newStores(t)) })
+	t.Run("ConcurrentBookings", func(t *testing.T) { testConcurrentBookings(t, newStores(t)) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, newStores(t)) })
+	t.Run("Find", func(t *testing.T) { testFind(t, newStores(t)) })
+	t.Run("IdsPerTable", func(t *testing.T) { testIdsPerTable(t, newStores(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStores(t)) })
+	t.Run("Restore", func(t *testing.T) { testRestore(t, newStores(t)) })
+	t.Run("Purge", func(t *testing.T) { testPurge(t, newStores(t)) })
+	t.Run("Canceled", func(t *testing.T) { testCanceled(t, newStores(t)) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, newStores(t)) })
+	t.Run("Tokens", func(t *testing.T) { testTokens(t, newStores(t)) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, newStores(t)) })
+	t.Run("Audit", func(t *testing.T) { testAudit(t, newStores(t)) })
+}
+
+var (
+	ctx   = context.Background()
+	start = time.Date(2030, time.March, 4, 10, 0, 0, 0, time.UTC)
+)
+
+func newDentist(license string) domain.Dentist {
+	return domain.Dentist{Lastname: "Perez", Name: "Ana", License: license}
+}
+
+func newPatient(dni int) domain.Patient {
+	return domain.Patient{Name: "Juan", Lastname: "Gomez", Residence: "Calle 123", DNI: dni, DischargeDate: time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)}
+}
+
+func patientIds(list []domain.Patient) []int {
+	ids := []int{}
+	for _, patient := range list {
+		ids = append(ids, patient.Id)
+	}
+	return ids
+}
+
+func appointmentIds(list []domain.Appointment) []int {
+	ids := []int{}
+	for _, appointment := range list {
+		ids = append(ids, appointment.Id)
+	}
+	return ids
+}
+
+func newAppointment(patientId, dentistId int, date time.Time, minutes int) domain.Appointment {
+	appointment := domain.Appointment{
+		Patient:     domain.Patient{Id: patientId},
+		Dentist:     domain.Dentist{Id: dentistId},
+		Date:        date,
+		Duration:    minutes,
+		Treatment:   "cleaning",
+		Description: "limpieza",
+	}
+	appointment.SetEnd()
+	return appointment
+}
+
+func createDentist(t *testing.T, s Stores, license string) domain.Dentist {
+	t.Helper()
+	dentist := newDentist(license)
+	id, err := s.Dentists.Create(ctx, dentist)
+	if err != nil {
+		t.Fatalf("creating dentist %s: %v", license, err)
+	}
+	dentist.Id = id
+	return dentist
+}
+
+func createPatient(t *testing.T, s Stores, dni int) domain.Patient {
+	t.Helper()
+	patient := newPatient(dni)
+	id, err := s.Patients.Create(ctx, patient)
+	if err != nil {
+		t.Fatalf("creating patient %d: %v", dni, err)
+	}
+	patient.Id = id
+	return patient
+}
+
+func createAppointment(t *testing.T, s Stores, appointment domain.Appointment) domain.Appointment {
+	t.Helper()
+	id, err := s.Appointments.Create(ctx, appointment)
+	if err != nil {
+		t.Fatalf("creating appointment: %v", err)
+	}
+	appointment.Id = id
+	return appointment
+}
+
+func expectNotFound(t *testing.T, what string, err error) {
+	t.Helper()
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("%s: got error %v, want store.ErrNotFound", what, err)
+	} else if code := domain.ErrorCode(err); code != domain.CodeNotFound {
+		t.Errorf("%s: got code %q, want %q", what, code, domain.CodeNotFound)
+	}
+}
+
+func testNotFound(t *testing.T, s Stores) {
+	_, err := s.Dentists.Read(ctx, 1)
+	expectNotFound(t, "Dentists.Read", err)
+	_, err = s.Dentists.ReadByLicense(ctx, "0000-0000")
+	expectNotFound(t, "Dentists.ReadByLicense", err)
+	err = s.Dentists.Update(ctx, domain.Dentist{Id: 1, Lastname: "Perez", Name: "Ana", License: "0000-0000"})
+	expectNotFound(t, "Dentists.Update", err)
+	err = s.Dentists.UpdateSchedule(ctx, domain.Schedule{DentistId: 1})
+	expectNotFound(t, "Dentists.UpdateSchedule", err)
+
+	_, err = s.Patients.Read(ctx, 1)
+	expectNotFound(t, "Patients.Read", err)
+	_, err = s.Patients.ReadByDNI(ctx, 1)
+	expectNotFound(t, "Patients.ReadByDNI", err)
+	err = s.Patients.Update(ctx, domain.Patient{Id: 1, DNI: 1})
+	expectNotFound(t, "Patients.Update", err)
+
+	_, err = s.Appointments.Read(ctx, 1)
+	expectNotFound(t, "Appointments.Read", err)
+	list, total, err := s.Appointments.Search(ctx, domain.AppointmentQuery{PatientDNI: 1})
+	if err != nil || len(list) != 0 || total != 0 {
+		t.Errorf("Appointments.Search by dni: got %+v, %d, %v, want no appointments", list, total, err)
+	}
+	_, err = s.Appointments.Create(ctx, newAppointment(1, 1, start, 30))
+	expectNotFound(t, "Appointments.Create", err)
+	_, err = s.Appointments.CreateByDniAndLicence(ctx, 1, "0000-0000", newAppointment(0, 0, start, 30))
+	expectNotFound(t, "Appointments.CreateByDniAndLicence", err)
+	err = s.Appointments.Update(ctx, newAppointment(1, 1, start, 30))
+	expectNotFound(t, "Appointments.Update", err)
+
+	for _, id := range []int{1, 1000} {
+		_, err = s.Dentists.Delete(ctx, id)
+		expectNotFound(t, "Dentists.Delete", err)
+		_, err = s.Dentists.Restore(ctx, id)
+		expectNotFound(t, "Dentists.Restore", err)
+		expectNotFound(t, "Dentists.Purge", s.Dentists.Purge(ctx, id))
+		_, err = s.Patients.Delete(ctx, id)
+		expectNotFound(t, "Patients.Delete", err)
+		_, err = s.Patients.Restore(ctx, id)
+		expectNotFound(t, "Patients.Restore", err)
+		expectNotFound(t, "Patients.Purge", s.Patients.Purge(ctx, id))
+		expectNotFound(t, "Appointments.Delete", s.Appointments.Delete(ctx, id))
+		expectNotFound(t, "Appointments.Restore", s.Appointments.Restore(ctx, id))
+		expectNotFound(t, "Appointments.Purge", s.Appointments.Purge(ctx, id))
+	}
+
+	dentists, err := s.Dentists.ReadAll(ctx)
+	if err != nil || len(dentists) != 0 {
+		t.Errorf("Dentists.ReadAll: got %v, %v, want no dentists", dentists, err)
+	}
+	patients, err := s.Patients.ReadAll(ctx)
+	if err != nil || len(patients) != 0 {
+		t.Errorf("Patients.ReadAll: got %v, %v, want no patients", patients, err)
+	}
+	appointments, err := s.Appointments.ReadAll(ctx)
+	if err != nil || len(appointments) != 0 {
+		t.Errorf("Appointments.ReadAll: got %v, %v, want no appointments", appointments, err)
+	}
+}
+
+func testDentists(t *testing.T, s Stores) {
+	first := createDentist(t, s, "0001-1111")
+	second := createDentist(t, s, "0002-2222")
+
+	got, err := s.Dentists.Read(ctx, first.Id)
+	if err != nil || got != first {
+		t.Errorf("Read: got %+v, %v, want %+v", got, err, first)
+	}
+	got, err = s.Dentists.ReadByLicense(ctx, second.License)
+	if err != nil || got != second {
+		t.Errorf("ReadByLicense: got %+v, %v, want %+v", got, err, second)
+	}
+	if !s.Dentists.Exists(ctx, first.License) || s.Dentists.Exists(ctx, "9999-9999") {
+		t.Errorf("Exists does not match the stored licenses")
+	}
+	list, err := s.Dentists.ReadAll(ctx)
+	if err != nil || len(list) != 2 || list[0] != first || list[1] != second {
+		t.Errorf("ReadAll: got %+v, %v, want [%+v %+v]", list, err, first, second)
+	}
+
+	_, err = s.Dentists.Create(ctx, newDentist(first.License))
+	if !errors.Is(err, store.ErrDuplicateLicense) || domain.ErrorCode(err) != domain.CodeDuplicateLicense {
+		t.Errorf("Create with a taken license: got error %v, want store.ErrDuplicateLicense", err)
+	}
+	second.License = first.License
+	err = s.Dentists.Update(ctx, second)
+	if !errors.Is(err, store.ErrDuplicateLicense) {
+		t.Errorf("Update with a taken license: got error %v, want store.ErrDuplicateLicense", err)
+	}
+
+	first.Name, first.License = "Maria", "0003-3333"
+	err = s.Dentists.Update(ctx, first)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	err = s.Dentists.Update(ctx, first)
+	if err != nil {
+		t.Errorf("Update without changes: %v", err)
+	}
+	got, err = s.Dentists.Read(ctx, first.Id)
+	if err != nil || got != first {
+		t.Errorf("Read after Update: got %+v, %v, want %+v", got, err, first)
+	}
+	if s.Dentists.Exists(ctx, "0001-1111") || !s.Dentists.Exists(ctx, first.License) {
+		t.Errorf("Exists after Update does not match the new license")
+	}
+}
+
+func testPatients(t *testing.T, s Stores) {
+	first := createPatient(t, s, 1111)
+	second := createPatient(t, s, 2222)
+
+	got, err := s.Patients.Read(ctx, first.Id)
+	if err != nil || !samePatient(got, first) {
+		t.Errorf("Read: got %+v, %v, want %+v", got, err, first)
+	}
+	got, err = s.Patients.ReadByDNI(ctx, second.DNI)
+	if err != nil || !samePatient(got, second) {
+		t.Errorf("ReadByDNI: got %+v, %v, want %+v", got, err, second)
+	}
+	if !s.Patients.Exists(ctx, first.DNI) || s.Patients.Exists(ctx, 9999) {
+		t.Errorf("Exists does not match the stored DNIs")
+	}
+	list, err := s.Patients.ReadAll(ctx)
+	if err != nil || len(list) != 2 || !samePatient(list[0], first) || !samePatient(list[1], second) {
+		t.Errorf("ReadAll: got %+v, %v, want [%+v %+v]", list, err, first, second)
+	}
+
+	_, err = s.Patients.Create(ctx, newPatient(first.DNI))
+	if !errors.Is(err, store.ErrDuplicateDNI) || domain.ErrorCode(err) != domain.CodeDuplicateDNI {
+		t.Errorf("Create with a taken DNI: got error %v, want store.ErrDuplicateDNI", err)
+	}
+	second.DNI = first.DNI
+	err = s.Patients.Update(ctx, second)
+	if !errors.Is(err, store.ErrDuplicateDNI) {
+		t.Errorf("Update with a taken DNI: got error %v, want store.ErrDuplicateDNI", err)
+	}
+
+	first.Residence = "Calle 456"
+	err = s.Patients.Update(ctx, first)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = s.Patients.Read(ctx, first.Id)
+	if err != nil || !samePatient(got, first) {
+		t.Errorf("Read after Update: got %+v, %v, want %+v", got, err, first)
+	}
+}
+
+func testSchedule(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+
+	schedule, err := s.Dentists.ReadSchedule(ctx, dentist.Id)
+	if err != nil || schedule.DentistId != dentist.Id || len(schedule.Days) != 0 {
+		t.Errorf("ReadSchedule without days: got %+v, %v", schedule, err)
+	}
+
+	want := domain.Schedule{DentistId: dentist.Id, Days: []domain.WorkingDay{
+		{Weekday: time.Monday, Start: "09:00", End: "13:00", SlotMinutes: 30, Breaks: []domain.Break{{Start: "11:00", End: "11:30"}}},
+		{Weekday: time.Wednesday, Start: "14:00", End: "18:00", SlotMinutes: 15, Breaks: []domain.Break{}},
+	}}
+	err = s.Dentists.UpdateSchedule(ctx, want)
+	if err != nil {
+		t.Fatalf("UpdateSchedule: %v", err)
+	}
+	schedule, err = s.Dentists.ReadSchedule(ctx, dentist.Id)
+	if err != nil || !sameSchedule(schedule, want) {
+		t.Errorf("ReadSchedule: got %+v, %v, want %+v", schedule, err, want)
+	}
+
+	want.Days = want.Days[1:]
+	err = s.Dentists.UpdateSchedule(ctx, want)
+	if err != nil {
+		t.Fatalf("UpdateSchedule replacing days: %v", err)
+	}
+	schedule, err = s.Dentists.ReadSchedule(ctx, dentist.Id)
+	if err != nil || !sameSchedule(schedule, want) {
+		t.Errorf("ReadSchedule after replacing days: got %+v, %v, want %+v", schedule, err, want)
+	}
+}
+
+func testAppointmentJoins(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	other := createDentist(t, s, "0002-2222")
+	patient := createPatient(t, s, 1111)
+	later := createAppointment(t, s, newAppointment(patient.Id, dentist.Id, start.Add(2*time.Hour), 45))
+	earlier := createAppointment(t, s, newAppointment(patient.Id, dentist.Id, start, 30))
+	createAppointment(t, s, newAppointment(patient.Id, other.Id, start.Add(4*time.Hour), 30))
+
+	got, err := s.Appointments.Read(ctx, later.Id)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.Dentist != dentist || !samePatient(got.Patient, patient) {
+		t.Errorf("Read: got dentist %+v and patient %+v, want %+v and %+v", got.Dentist, got.Patient, dentist, patient)
+	}
+	if !got.Date.Equal(later.Date) || !got.End.Equal(later.End) || got.Duration != 45 || got.Treatment != later.Treatment || got.Description != later.Description {
+		t.Errorf("Read: got %+v, want %+v", got, later)
+	}
+
+	list, total, err := s.Appointments.Search(ctx, domain.AppointmentQuery{PatientDNI: patient.DNI})
+	if err != nil || total != 3 || len(list) != 3 || list[0].Id != earlier.Id || list[1].Id != later.Id || !samePatient(list[2].Patient, patient) {
+		t.Errorf("Search by dni: got %+v, %d, %v, want the 3 appointments of the patient ordered by date", list, total, err)
+	}
+
+	list, err = s.Appointments.ReadAll(ctx)
+	if err != nil || len(list) != 3 || list[0].Id != earlier.Id || list[1].Id != later.Id {
+		t.Errorf("ReadAll: got %+v, %v, want 3 appointments ordered by date", list, err)
+	}
+
+	list, err = s.Appointments.ReadByDentist(ctx, dentist.Id, start.Add(15*time.Minute), start.Add(2*time.Hour))
+	if err != nil || len(list) != 1 || list[0].Id != earlier.Id || list[0].Dentist != dentist {
+		t.Errorf("ReadByDentist: got %+v, %v, want only appointment %d", list, err, earlier.Id)
+	}
+	list, err = s.Appointments.ReadByDentist(ctx, dentist.Id, start, start.Add(24*time.Hour))
+	if err != nil || len(list) != 2 {
+		t.Errorf("ReadByDentist over the day: got %+v, %v, want 2 appointments", list, err)
+	}
+}
+
+func testCreateByDniAndLicence(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	patient := createPatient(t, s, 1111)
+
+	created, err := s.Appointments.CreateByDniAndLicence(ctx, patient.DNI, dentist.License, newAppointment(0, 0, start, 30))
+	if err != nil {
+		t.Fatalf("CreateByDniAndLicence: %v", err)
+	}
+	if created.Id == 0 || created.Dentist != dentist || !samePatient(created.Patient, patient) {
+		t.Errorf("CreateByDniAndLicence: got %+v", created)
+	}
+	got, err := s.Appointments.Read(ctx, created.Id)
+	if err != nil || got.Dentist.Id != dentist.Id || got.Patient.Id != patient.Id {
+		t.Errorf("Read: got %+v, %v", got, err)
+	}
+
+	_, err = s.Appointments.CreateByDniAndLicence(ctx, patient.DNI, "9999-9999", newAppointment(0, 0, start.Add(time.Hour), 30))
+	expectNotFound(t, "CreateByDniAndLicence with an unknown license", err)
+	_, err = s.Appointments.CreateByDniAndLicence(ctx, 9999, dentist.License, newAppointment(0, 0, start.Add(time.Hour), 30))
+	expectNotFound(t, "CreateByDniAndLicence with an unknown DNI", err)
+}
+
+func testConflicts(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	other := createDentist(t, s, "0002-2222")
+	patient := createPatient(t, s, 1111)
+	otherPatient := createPatient(t, s, 2222)
+	booked := createAppointment(t, s, newAppointment(patient.Id, dentist.Id, start, 60))
+
+	var conflict *domain.ConflictError
+	_, err := s.Appointments.Create(ctx, newAppointment(otherPatient.Id, dentist.Id, start.Add(30*time.Minute), 30))
+	if !errors.As(err, &conflict) || !conflict.Dentist || conflict.Appointment.Id != booked.Id {
+		t.Errorf("Create overlapping the dentist: got error %v, want a dentist conflict with %d", err, booked.Id)
+	}
+	_, err = s.Appointments.Create(ctx, newAppointment(patient.Id, other.Id, start.Add(-30*time.Minute), 45))
+	if !errors.As(err, &conflict) || conflict.Dentist || conflict.Appointment.Id != booked.Id {
+		t.Errorf("Create overlapping the patient: got error %v, want a patient conflict with %d", err, booked.Id)
+	}
+
+	next := createAppointment(t, s, newAppointment(otherPatient.Id, dentist.Id, start.Add(time.Hour), 30))
+	moved := next
+	moved.Date = start.Add(45 * time.Minute)
+	moved.SetEnd()
+	err = s.Appointments.Update(ctx, moved)
+	if !errors.As(err, &conflict) || conflict.Appointment.Id != booked.Id {
+		t.Errorf("Update overlapping another appointment: got error %v, want a conflict with %d", err, booked.Id)
+	}
+
+	booked.Description = "control"
+	err = s.Appointments.Update(ctx, booked)
+	if err != nil {
+		t.Errorf("Update not moving the appointment: %v", err)
+	}
+	got, err := s.Appointments.Read(ctx, booked.Id)
+	if err != nil || got.Description != "control" {
+		t.Errorf("Read after Update: got %+v, %v", got, err)
+	}
+}
+
+func testConcurrentBookings(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	var patients []domain.Patient
+	for dni := 1; dni <= 8; dni++ {
+		patients = append(patients, createPatient(t, s, dni))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(patients))
+	for _, patient := range patients {
+		wg.Add(1)
+		go func(patient domain.Patient) {
+			defer wg.Done()
+			_, err := s.Appointments.Create(ctx, newAppointment(patient.Id, dentist.Id, start, 30))
+			errs <- err
+		}(patient)
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		var conflict *domain.ConflictError
+		switch {
+		case err == nil:
+			created++
+		case !errors.As(err, &conflict):
+			t.Errorf("concurrent Create: got error %v, want a conflict", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("concurrent Create of the same slot: %d succeeded, want 1", created)
+	}
+}
+
+func testSearch(t *testing.T, s Stores) {
+	var patients []domain.Patient
+	for i, lastname := range []string{"Gomez", "Perez", "Gonzalez", "G_mez"} {
+		patient := newPatient(1000 + i)
+		patient.Lastname = lastname
+		id, err := s.Patients.Create(ctx, patient)
+		if err != nil {
+			t.Fatalf("creating patient %s: %v", lastname, err)
+		}
+		patient.Id = id
+		patients = append(patients, patient)
+	}
+	for _, tc := range []struct {
+		query domain.PatientQuery
+		want  []int
+		total int
+	}{
+		{domain.PatientQuery{}, []int{patients[0].Id, patients[1].Id, patients[2].Id, patients[3].Id}, 4},
+		{domain.PatientQuery{Lastname: "go"}, []int{patients[0].Id, patients[2].Id}, 2},
+		{domain.PatientQuery{Lastname: "G_"}, []int{patients[3].Id}, 1},
+		{domain.PatientQuery{Page: domain.Page{Sort: "lastname"}}, []int{patients[3].Id, patients[0].Id, patients[2].Id, patients[1].Id}, 4},
+		{domain.PatientQuery{Page: domain.Page{Sort: "dni", Desc: true, Limit: 2}}, []int{patients[3].Id, patients[2].Id}, 4},
+		{domain.PatientQuery{Page: domain.Page{Limit: 2, Offset: 3}}, []int{patients[3].Id}, 4},
+		{domain.PatientQuery{Page: domain.Page{Offset: 4}}, []int{}, 4},
+	} {
+		got, total, err := s.Patients.Search(ctx, tc.query)
+		if err != nil || !slices.Equal(patientIds(got), tc.want) || total != tc.total {
+			t.Errorf("Patients.Search(%+v): got %v, %d, %v, want %v, %d", tc.query, patientIds(got), total, err, tc.want, tc.total)
+		}
+	}
+
+	first := createDentist(t, s, "0001-1111")
+	second := newDentist("0002-2222")
+	second.Lastname = "Alvarez"
+	id, err := s.Dentists.Create(ctx, second)
+	if err != nil {
+		t.Fatalf("creating dentist: %v", err)
+	}
+	second.Id = id
+	dentists, total, err := s.Dentists.Search(ctx, domain.DentistQuery{Page: domain.Page{Sort: "lastname"}})
+	if err != nil || total != 2 || len(dentists) != 2 || dentists[0] != second || dentists[1] != first {
+		t.Errorf("Dentists.Search by lastname: got %+v, %d, %v, want %d then %d", dentists, total, err, second.Id, first.Id)
+	}
+	dentists, total, err = s.Dentists.Search(ctx, domain.DentistQuery{Lastname: "PER"})
+	if err != nil || total != 1 || len(dentists) != 1 || dentists[0] != first {
+		t.Errorf("Dentists.Search of PER: got %+v, %d, %v, want %d", dentists, total, err, first.Id)
+	}
+
+	appointments := []domain.Appointment{
+		createAppointment(t, s, newAppointment(patients[0].Id, first.Id, start.Add(2*time.Hour), 30)),
+		createAppointment(t, s, newAppointment(patients[1].Id, second.Id, start, 30)),
+		createAppointment(t, s, newAppointment(patients[0].Id, first.Id, start.Add(time.Hour), 30)),
+		createAppointment(t, s, newAppointment(patients[1].Id, first.Id, start.Add(3*time.Hour), 30)),
+	}
+	removed := createAppointment(t, s, newAppointment(patients[0].Id, first.Id, start.Add(4*time.Hour), 30))
+	if err := s.Appointments.Delete(ctx, removed.Id); err != nil {
+		t.Fatalf("Appointments.Delete: %v", err)
+	}
+	appointmentIds := func(list []domain.Appointment) []int {
+		ids := []int{}
+		for _, appointment := range list {
+			ids = append(ids, appointment.Id)
+		}
+		return ids
+	}
+	for _, tc := range []struct {
+		query domain.AppointmentQuery
+		want  []int
+		total int
+	}{
+		{domain.AppointmentQuery{}, []int{appointments[1].Id, appointments[2].Id, appointments[0].Id, appointments[3].Id}, 4},
+		{domain.AppointmentQuery{DentistId: first.Id}, []int{appointments[2].Id, appointments[0].Id, appointments[3].Id}, 3},
+		{domain.AppointmentQuery{DentistId: first.Id, PatientId: patients[0].Id}, []int{appointments[2].Id, appointments[0].Id}, 2},
+		{domain.AppointmentQuery{From: start.Add(time.Hour), To: start.Add(3 * time.Hour)}, []int{appointments[2].Id, appointments[0].Id}, 2},
+		{domain.AppointmentQuery{Page: domain.Page{Sort: "id", Desc: true, Limit: 3}}, []int{appointments[3].Id, appointments[2].Id, appointments[1].Id}, 4},
+		{domain.AppointmentQuery{Page: domain.Page{Desc: true, Limit: 2, Offset: 1}}, []int{appointments[0].Id, appointments[2].Id}, 4},
+	} {
+		got, total, err := s.Appointments.Search(ctx, tc.query)
+		if err != nil || !slices.Equal(appointmentIds(got), tc.want) || total != tc.total {
+			t.Errorf("Appointments.Search(%+v): got %v, %d, %v, want %v, %d", tc.query, appointmentIds(got), total, err, tc.want, tc.total)
+		}
+	}
+	got, _, err := s.Appointments.Search(ctx, domain.AppointmentQuery{PatientId: patients[1].Id, Page: domain.Page{Limit: 1}})
+	if err != nil || len(got) != 1 || got[0].Patient.Lastname != "Perez" || got[0].Dentist.License != second.License {
+		t.Errorf("Appointments.Search joins: got %+v, %v", got, err)
+	}
+}
+
+func testFind(t *testing.T, s Stores) {
+	var patients []domain.Patient
+	for i, p := range []domain.Patient{
+		{Name: "José", Lastname: "Gómez", Residence: "Calle 123", DNI: 30123456},
+		{Name: "Josefina", Lastname: "Peña", Residence: "Córdoba 500", DNI: 28999111},
+		{Name: "Ana", Lastname: "Córdoba", Residence: "Mitre 10", DNI: 40555666},
+		{Name: "Jose", Lastname: "Gomez", Residence: "Calle 9", DNI: 31000000},
+	} {
+		p.DischargeDate = newPatient(0).DischargeDate
+		id, err := s.Patients.Create(ctx, p)
+		if err != nil {
+			t.Fatalf("creating patient %d: %v", i, err)
+		}
+		p.Id = id
+		patients = append(patients, p)
+	}
+	_, err := s.Patients.Delete(ctx, patients[3].Id)
+	if err != nil {
+		t.Fatalf("Patients.Delete: %v", err)
+	}
+
+	for _, tc := range []struct {
+		text  string
+		page  domain.Page
+		want  []int
+		total int
+	}{
+		{"gomez", domain.Page{}, []int{patients[0].Id}, 1},
+		{"GÓMEZ jose", domain.Page{}, []int{patients[0].Id}, 1},
+		{"gomes", domain.Page{}, []int{patients[0].Id}, 1},
+		{"homez", domain.Page{}, []int{}, 0},
+		{"cordova", domain.Page{}, []int{patients[2].Id, patients[1].Id}, 2},
+		{"pena", domain.Page{}, []int{patients[1].Id}, 1},
+		{"jos", domain.Page{}, []int{patients[0].Id, patients[1].Id}, 2},
+		{"cordoba", domain.Page{}, []int{patients[2].Id, patients[1].Id}, 2},
+		{"cordoba", domain.Page{Limit: 1, Offset: 1}, []int{patients[1].Id}, 2},
+		{"3012", domain.Page{}, []int{patients[0].Id}, 1},
+		{"999", domain.Page{}, []int{patients[1].Id}, 1},
+		{"ana 40555666", domain.Page{}, []int{patients[2].Id}, 1},
+		{"ana 1", domain.Page{}, []int{}, 0},
+		{"xyz", domain.Page{}, []int{}, 0},
+		{"", domain.Page{}, []int{}, 0},
+	} {
+		got, total, err := s.Patients.Find(ctx, tc.text, tc.page)
+		if err != nil || !slices.Equal(patientIds(got), tc.want) || total != tc.total {
+			t.Errorf("Patients.Find(%q, %+v): got %v, %d, %v, want %v, %d", tc.text, tc.page, patientIds(got), total, err, tc.want, tc.total)
+		}
+	}
+
+	// An update is found by its new text only.
+	updated := patients[2]
+	updated.Lastname = "Núñez"
+	err = s.Patients.Update(ctx, updated)
+	if err != nil {
+		t.Fatalf("Patients.Update: %v", err)
+	}
+	for text, want := range map[string][]int{"nunez": {updated.Id}, "cordoba": {patients[1].Id}} {
+		got, _, err := s.Patients.Find(ctx, text, domain.Page{})
+		if err != nil || !slices.Equal(patientIds(got), want) {
+			t.Errorf("Patients.Find(%q) after Update: got %v, %v, want %v", text, patientIds(got), err, want)
+		}
+	}
+}
+
+// testIdsPerTable checks that deleting a record does not hide the records of
+// other tables, which may have the same id.
+func testIdsPerTable(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	patient := createPatient(t, s, 1)
+	appointment := createAppointment(t, s, newAppointment(patient.Id, dentist.Id, start, 30))
+
+	err := s.Appointments.Delete(ctx, appointment.Id)
+	if err != nil {
+		t.Fatalf("Delete appointment: %v", err)
+	}
+	_, err = s.Dentists.Read(ctx, dentist.Id)
+	if err != nil {
+		t.Errorf("Read dentist %d after deleting appointment %d: %v", dentist.Id, appointment.Id, err)
+	}
+	_, err = s.Patients.Read(ctx, patient.Id)
+	if err != nil {
+		t.Errorf("Read patient %d after deleting appointment %d: %v", patient.Id, appointment.Id, err)
+	}
+	_, err = s.Dentists.Delete(ctx, dentist.Id)
+	if err != nil {
+		t.Fatalf("Delete dentist: %v", err)
+	}
+	_, err = s.Patients.Read(ctx, patient.Id)
+	if err != nil {
+		t.Errorf("Read patient %d after deleting dentist %d: %v", patient.Id, dentist.Id, err)
+	}
+}
+
+func testDelete(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	other := createDentist(t, s, "0002-2222")
+	patient := createPatient(t, s, 1111)
+	otherPatient := createPatient(t, s, 2222)
+	byDentist := createAppointment(t, s, newAppointment(otherPatient.Id, dentist.Id, start, 30))
+	byPatient := createAppointment(t, s, newAppointment(patient.Id, other.Id, start.Add(time.Hour), 30))
+	kept := createAppointment(t, s, newAppointment(otherPatient.Id, other.Id, start.Add(2*time.Hour), 30))
+	removed := createAppointment(t, s, newAppointment(otherPatient.Id, other.Id, start.Add(3*time.Hour), 30))
+
+	err := s.Appointments.Delete(ctx, removed.Id)
+	if err != nil {
+		t.Fatalf("Appointments.Delete: %v", err)
+	}
+	_, err = s.Appointments.Read(ctx, removed.Id)
+	expectNotFound(t, "Appointments.Read after Delete", err)
+	err = s.Appointments.Delete(ctx, removed.Id)
+	expectNotFound(t, "Appointments.Delete of a deleted appointment", err)
+
+	cancelled, err := s.Dentists.Delete(ctx, dentist.Id)
+	if err != nil {
+		t.Fatalf("Dentists.Delete: %v", err)
+	}
+	if !slices.Equal(appointmentIds(cancelled), []int{byDentist.Id}) {
+		t.Errorf("Dentists.Delete: got %v cancelled, want %d", appointmentIds(cancelled), byDentist.Id)
+	}
+	_, err = s.Dentists.Read(ctx, dentist.Id)
+	expectNotFound(t, "Dentists.Read after Delete", err)
+	_, err = s.Appointments.Read(ctx, byDentist.Id)
+	expectNotFound(t, "Appointments.Read of a deleted dentist", err)
+	if s.Dentists.Exists(ctx, dentist.License) {
+		t.Errorf("Dentists.Exists after Delete: got true")
+	}
+
+	cancelled, err = s.Patients.Delete(ctx, patient.Id)
+	if err != nil {
+		t.Fatalf("Patients.Delete: %v", err)
+	}
+	if !slices.Equal(appointmentIds(cancelled), []int{byPatient.Id}) || cancelled[0].Patient.DNI != patient.DNI {
+		t.Errorf("Patients.Delete: got %+v cancelled, want %d joined with its patient", cancelled, byPatient.Id)
+	}
+	_, err = s.Patients.Read(ctx, patient.Id)
+	expectNotFound(t, "Patients.Read after Delete", err)
+	_, err = s.Appointments.Read(ctx, byPatient.Id)
+	expectNotFound(t, "Appointments.Read of a deleted patient", err)
+	if s.Patients.Exists(ctx, patient.DNI) {
+		t.Errorf("Patients.Exists after Delete: got true")
+	}
+
+	_, err = s.Dentists.Delete(ctx, dentist.Id)
+	expectNotFound(t, "Dentists.Delete of a deleted dentist", err)
+	_, err = s.Patients.Delete(ctx, patient.Id)
+	expectNotFound(t, "Patients.Delete of a deleted patient", err)
+	_, err = s.Patients.ReadByDNI(ctx, patient.DNI)
+	expectNotFound(t, "Patients.ReadByDNI after Delete", err)
+	_, err = s.Dentists.ReadByLicense(ctx, dentist.License)
+	expectNotFound(t, "Dentists.ReadByLicense after Delete", err)
+	list, total, err := s.Appointments.Search(ctx, domain.AppointmentQuery{PatientDNI: patient.DNI})
+	if err != nil || len(list) != 0 || total != 0 {
+		t.Errorf("Appointments.Search by the dni of a deleted patient: got %+v, %d, %v, want no appointments", list, total, err)
+	}
+	err = s.Patients.Update(ctx, patient)
+	expectNotFound(t, "Patients.Update after Delete", err)
+	err = s.Dentists.Update(ctx, dentist)
+	expectNotFound(t, "Dentists.Update after Delete", err)
+	err = s.Dentists.UpdateSchedule(ctx, domain.Schedule{DentistId: dentist.Id})
+	expectNotFound(t, "Dentists.UpdateSchedule after Delete", err)
+	_, err = s.Appointments.Create(ctx, newAppointment(otherPatient.Id, dentist.Id, start.Add(5*time.Hour), 30))
+	expectNotFound(t, "Appointments.Create with a deleted dentist", err)
+
+	// Deleted DNIs and licenses stay taken until the record is purged.
+	_, err = s.Patients.Create(ctx, newPatient(patient.DNI))
+	if !errors.Is(err, store.ErrDuplicateDNI) {
+		t.Errorf("Patients.Create with the DNI of a deleted patient: got error %v, want store.ErrDuplicateDNI", err)
+	}
+	_, err = s.Dentists.Create(ctx, newDentist(dentist.License))
+	if !errors.Is(err, store.ErrDuplicateLicense) {
+		t.Errorf("Dentists.Create with the license of a deleted dentist: got error %v, want store.ErrDuplicateLicense", err)
+	}
+
+	list, err = s.Appointments.ReadAll(ctx)
+	if err != nil || len(list) != 1 || list[0].Id != kept.Id {
+		t.Errorf("Appointments.ReadAll after deleting: got %+v, %v, want only %d", list, err, kept.Id)
+	}
+	dentists, err := s.Dentists.ReadAll(ctx)
+	if err != nil || len(dentists) != 1 || dentists[0].Id != other.Id {
+		t.Errorf("Dentists.ReadAll after deleting: got %+v, %v, want only %d", dentists, err, other.Id)
+	}
+	patients, err := s.Patients.ReadAll(ctx)
+	if err != nil || len(patients) != 1 || patients[0].Id != otherPatient.Id {
+		t.Errorf("Patients.ReadAll after deleting: got %+v, %v, want only %d", patients, err, otherPatient.Id)
+	}
+}
+
+func testRestore(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	patient := createPatient(t, s, 1111)
+	otherPatient := createPatient(t, s, 2222)
+	first := createAppointment(t, s, newAppointment(patient.Id, dentist.Id, start, 30))
+	second := createAppointment(t, s, newAppointment(otherPatient.Id, dentist.Id, start.Add(time.Hour), 30))
+
+	_, err := s.Patients.Restore(ctx, patient.Id)
+	expectNotFound(t, "Patients.Restore of a patient not deleted", err)
+
+	// Restoring the patient restores the appointments deleted with it.
+	_, err = s.Patients.Delete(ctx, patient.Id)
+	if err != nil {
+		t.Fatalf("Patients.Delete: %v", err)
+	}
+	appointments, err := s.Patients.Restore(ctx, patient.Id)
+	if err != nil || !slices.Equal(appointmentIds(appointments), []int{first.Id}) {
+		t.Fatalf("Patients.Restore: got %v, %v, want %d restored", appointmentIds(appointments), err, first.Id)
+	}
+	if appointments[0].Patient.Name != patient.Name || appointments[0].Dentist.License != dentist.License {
+		t.Errorf("Patients.Restore: got appointment %+v, want it joined with its patient and dentist", appointments[0])
+	}
+	got, err := s.Patients.Read(ctx, patient.Id)
+	if err != nil || !samePatient(got, patient) {
+		t.Errorf("Patients.Read after Restore: got %+v, %v, want %+v", got, err, patient)
+	}
+	_, err = s.Appointments.Read(ctx, first.Id)
+	if err != nil {
+		t.Errorf("Appointments.Read after restoring the patient: %v", err)
+	}
+	_, err = s.Patients.Restore(ctx, patient.Id)
+	expectNotFound(t, "Patients.Restore of a restored patient", err)
+
+	// The slot of a deleted appointment can be booked again, and then the
+	// appointment is not restored with its patient nor on its own.
+	_, err = s.Patients.Delete(ctx, patient.Id)
+	if err != nil {
+		t.Fatalf("Patients.Delete: %v", err)
+	}
+	taken := createAppointment(t, s, newAppointment(otherPatient.Id, dentist.Id, start, 30))
+	appointments, err = s.Patients.Restore(ctx, patient.Id)
+	if err != nil || len(appointments) != 0 {
+		t.Errorf("Patients.Restore with a booked slot: got %v, %v, want none restored", appointmentIds(appointments), err)
+	}
+	_, err = s.Appointments.Read(ctx, first.Id)
+	expectNotFound(t, "Appointments.Read of an appointment whose slot was booked", err)
+	err = s.Appointments.Restore(ctx, first.Id)
+	var conflict *domain.ConflictError
+	if !errors.As(err, &conflict) || conflict.Appointment.Id != taken.Id {
+		t.Errorf("Appointments.Restore over a booked slot: got error %v, want a conflict with %d", err, taken.Id)
+	}
+	// Purged rather than only deleted, as the time a record is deleted is
+	// kept to the second and the dentist is deleted below.
+	err = s.Appointments.Delete(ctx, taken.Id)
+	if err != nil {
+		t.Fatalf("Appointments.Delete: %v", err)
+	}
+	err = s.Appointments.Purge(ctx, taken.Id)
+	if err != nil {
+		t.Fatalf("Appointments.Purge: %v", err)
+	}
+	err = s.Appointments.Restore(ctx, first.Id)
+	if err != nil {
+		t.Fatalf("Appointments.Restore: %v", err)
+	}
+	restored, err := s.Appointments.Read(ctx, first.Id)
+	if err != nil || !restored.Date.Equal(first.Date) || restored.Patient.Id != patient.Id || restored.Dentist.Id != dentist.Id {
+		t.Errorf("Appointments.Read after Restore: got %+v, %v, want %+v", restored, err, first)
+	}
+	err = s.Appointments.Restore(ctx, first.Id)
+	expectNotFound(t, "Appointments.Restore of a restored appointment", err)
+
+	// An appointment cannot be restored while its dentist is deleted, nor
+	// with its dentist while its patient is.
+	_, err = s.Dentists.Delete(ctx, dentist.Id)
+	if err != nil {
+		t.Fatalf("Dentists.Delete: %v", err)
+	}
+	err = s.Appointments.Restore(ctx, second.Id)
+	expectNotFound(t, "Appointments.Restore with a deleted dentist", err)
+	appointments, err = s.Patients.Delete(ctx, patient.Id)
+	if err != nil || len(appointments) != 0 {
+		t.Fatalf("Patients.Delete after deleting the dentist: got %v, %v, want none cancelled", appointmentIds(appointments), err)
+	}
+	appointments, err = s.Dentists.Restore(ctx, dentist.Id)
+	if err != nil || !slices.Equal(appointmentIds(appointments), []int{second.Id}) {
+		t.Fatalf("Dentists.Restore: got %v, %v, want %d restored", appointmentIds(appointments), err, second.Id)
+	}
+	gotDentist, err := s.Dentists.Read(ctx, dentist.Id)
+	if err != nil || gotDentist != dentist {
+		t.Errorf("Dentists.Read after Restore: got %+v, %v, want %+v", gotDentist, err, dentist)
+	}
+	_, err = s.Appointments.Read(ctx, first.Id)
+	expectNotFound(t, "Appointments.Read of a deleted patient", err)
+	list, err := s.Appointments.ReadAll(ctx)
+	if err != nil || !slices.Equal(appointmentIds(list), []int{second.Id}) {
+		t.Errorf("Appointments.ReadAll after restoring: got %v, %v, want only %d", appointmentIds(list), err, second.Id)
+	}
+}
+
+func testPurge(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	other := createDentist(t, s, "0002-2222")
+	patient := createPatient(t, s, 1111)
+	byPatient := createAppointment(t, s, newAppointment(patient.Id, other.Id, start, 30))
+	canceled := createAppointment(t, s, newAppointment(patient.Id, other.Id, start.Add(time.Hour), 30))
+
+	err := s.Patients.Purge(ctx, patient.Id)
+	expectNotFound(t, "Patients.Purge of a patient not deleted", err)
+	err = s.Appointments.Purge(ctx, canceled.Id)
+	expectNotFound(t, "Appointments.Purge of an appointment not deleted", err)
+
+	err = s.Appointments.Delete(ctx, canceled.Id)
+	if err != nil {
+		t.Fatalf("Appointments.Delete: %v", err)
+	}
+	err = s.Appointments.Purge(ctx, canceled.Id)
+	if err != nil {
+		t.Fatalf("Appointments.Purge: %v", err)
+	}
+	err = s.Appointments.Restore(ctx, canceled.Id)
+	expectNotFound(t, "Appointments.Restore after Purge", err)
+
+	// Purging the patient purges its appointments and frees its DNI.
+	_, err = s.Patients.Delete(ctx, patient.Id)
+	if err != nil {
+		t.Fatalf("Patients.Delete: %v", err)
+	}
+	err = s.Patients.Purge(ctx, patient.Id)
+	if err != nil {
+		t.Fatalf("Patients.Purge: %v", err)
+	}
+	_, err = s.Patients.Restore(ctx, patient.Id)
+	expectNotFound(t, "Patients.Restore after Purge", err)
+	expectNotFound(t, "Patients.Purge after Purge", s.Patients.Purge(ctx, patient.Id))
+	expectNotFound(t, "Appointments.Restore of a purged patient", s.Appointments.Restore(ctx, byPatient.Id))
+	createPatient(t, s, patient.DNI)
+
+	_, err = s.Dentists.Delete(ctx, dentist.Id)
+	if err != nil {
+		t.Fatalf("Dentists.Delete: %v", err)
+	}
+	err = s.Dentists.Purge(ctx, dentist.Id)
+	if err != nil {
+		t.Fatalf("Dentists.Purge: %v", err)
+	}
+	_, err = s.Dentists.Restore(ctx, dentist.Id)
+	expectNotFound(t, "Dentists.Restore after Purge", err)
+	createDentist(t, s, dentist.License)
+}
+
+func testCanceled(t *testing.T, s Stores) {
+	dentist := createDentist(t, s, "0001-1111")
+	patient := createPatient(t, s, 1111)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err := s.Dentists.Read(canceled, dentist.Id)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Dentists.Read: got error %v, want context.Canceled", err)
+	}
+	_, err = s.Patients.ReadAll(canceled)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Patients.ReadAll: got error %v, want context.Canceled", err)
+	}
+	_, err = s.Appointments.Create(canceled, newAppointment(patient.Id, dentist.Id, start, 30))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Appointments.Create: got error %v, want context.Canceled", err)
+	}
+	list, err := s.Appointments.ReadAll(ctx)
+	if err != nil || len(list) != 0 {
+		t.Errorf("Appointments.ReadAll after a canceled Create: got %+v, %v, want no appointments", list, err)
+	}
+}
+
+func testUsers(t *testing.T, s Stores) {
+	user := domain.User{Username: "ana", PasswordHash: "hash", Role: domain.RoleReceptionist}
+	id, err := s.Users.Create(ctx, user)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	user.Id = id
+
+	got, err := s.Users.Read(ctx, id)
+	if err != nil || got != user {
+		t.Errorf("Read: got %+v, %v, want %+v", got, err, user)
+	}
+	got, err = s.Users.ReadByUsername(ctx, "ana")
+	if err != nil || got != user {
+		t.Errorf("ReadByUsername: got %+v, %v, want %+v", got, err, user)
+	}
+	_, err = s.Users.ReadByUsername(ctx, "juan")
+	expectNotFound(t, "ReadByUsername of a missing user", err)
+	_, err = s.Users.Read(ctx, id+1000)
+	expectNotFound(t, "Read of a missing user", err)
+
+	_, err = s.Users.Create(ctx, domain.User{Username: "ana", PasswordHash: "other", Role: domain.RoleAdmin})
+	if !errors.Is(err, store.ErrDuplicateUsername) {
+		t.Errorf("Create with a taken username: got error %v, want store.ErrDuplicateUsername", err)
+	}
+
+	// Purging the dentist or the patient of a user unlinks it.
+	dentist := createDentist(t, s, "L-1")
+	patient := createPatient(t, s, 1)
+	for _, linked := range []domain.User{
+		{Username: "dentist", PasswordHash: "hash", Role: domain.RoleDentist, DentistId: dentist.Id},
+		{Username: "patient", PasswordHash: "hash", Role: domain.RolePatient, PatientId: patient.Id},
+	} {
+		id, err := s.Users.Create(ctx, linked)
+		if err != nil {
+			t.Fatalf("Create %s: %v", linked.Username, err)
+		}
+		linked.Id = id
+		got, err := s.Users.Read(ctx, id)
+		if err != nil || got != linked {
+			t.Errorf("Read %s: got %+v, %v, want %+v", linked.Username, got, err, linked)
+		}
+	}
+	for _, purge := range []func() error{
+		func() error { _, err := s.Dentists.Delete(ctx, dentist.Id); return err },
+		func() error { return s.Dentists.Purge(ctx, dentist.Id) },
+		func() error { _, err := s.Patients.Delete(ctx, patient.Id); return err },
+		func() error { return s.Patients.Purge(ctx, patient.Id) },
+	} {
+		if err := purge(); err != nil {
+			t.Fatalf("purging the linked records: %v", err)
+		}
+	}
+	for _, username := range []string{"dentist", "patient"} {
+		got, err := s.Users.ReadByUsername(ctx, username)
+		if err != nil || got.DentistId != 0 || got.PatientId != 0 {
+			t.Errorf("ReadByUsername %s after purging its record: got %+v, %v, want it unlinked", username, got, err)
+		}
+	}
+}
+
+func testTokens(t *testing.T, s Stores) {
+	expires := time.Now().Add(time.Hour)
+	revoked, err := s.Tokens.IsRevoked(ctx, "a")
+	if err != nil || revoked {
+		t.Errorf("IsRevoked before Revoke: got %v, %v, want false", revoked, err)
+	}
+	ok, err := s.Tokens.Revoke(ctx, "a", expires)
+	if err != nil || !ok {
+		t.Fatalf("Revoke: got %v, %v, want true", ok, err)
+	}
+	ok, err = s.Tokens.Revoke(ctx, "a", expires)
+	if err != nil || ok {
+		t.Errorf("Revoke twice: got %v, %v, want false", ok, err)
+	}
+	revoked, err = s.Tokens.IsRevoked(ctx, "a")
+	if err != nil || !revoked {
+		t.Errorf("IsRevoked after Revoke: got %v, %v, want true", revoked, err)
+	}
+	revoked, err = s.Tokens.IsRevoked(ctx, "b")
+	if err != nil || revoked {
+		t.Errorf("IsRevoked of another token: got %v, %v, want false", revoked, err)
+	}
+
+	// Expired tokens are forgotten once another one is revoked.
+	_, err = s.Tokens.Revoke(ctx, "c", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Revoke of an expired token: %v", err)
+	}
+	_, err = s.Tokens.Revoke(ctx, "d", expires)
+	if err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	revoked, err = s.Tokens.IsRevoked(ctx, "c")
+	if err != nil || revoked {
+		t.Errorf("IsRevoked of an expired token: got %v, %v, want false", revoked, err)
+	}
+
+	// Of concurrent revocations of a token only one revokes it.
+	var wg sync.WaitGroup
+	results := make(chan bool, 8)
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.Tokens.Revoke(ctx, "e", expires)
+			if err != nil {
+				t.Errorf("concurrent Revoke: %v", err)
+			}
+			results <- ok
+		}()
+	}
+	wg.Wait()
+	close(results)
+	succeeded := 0
+	for ok := range results {
+		if ok {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("concurrent Revoke of the same token: %d succeeded, want 1", succeeded)
+	}
+}
+
+// samePatient compares patients with time.Time.Equal, as databases may return
+// the discharge date in another location.
+func samePatient(a, b domain.Patient) bool {
+	discharge := a.DischargeDate.Equal(b.DischargeDate)
+	a.DischargeDate, b.DischargeDate = time.Time{}, time.Time{}
+	return discharge && a == b
+}
+
+func sameSchedule(a, b domain.Schedule) bool {
+	if a.DentistId != b.DentistId || len(a.Days) != len(b.Days) {
+		return false
+	}
+	for i := range a.Days {
+		x, y := a.Days[i], b.Days[i]
+		if x.Weekday != y.Weekday || x.Start != y.Start || x.End != y.End || x.SlotMinutes != y.SlotMinutes || len(x.Breaks) != len(y.Breaks) {
+			return false
+		}
+		for j := range x.Breaks {
+			if x.Breaks[j] != y.Breaks[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sameAPIKey compares keys by value, as times come back in the timezone of the
+// database.
+func sameAPIKey(got domain.APIKey, want domain.APIKey) bool {
+	return got.Id == want.Id && got.Name == want.Name && got.Prefix == want.Prefix && got.Hash == want.Hash &&
+		slices.Equal(got.Scopes, want.Scopes) && got.CreatedAt.Equal(want.CreatedAt) &&
+		got.ExpiresAt.Equal(want.ExpiresAt) && got.LastUsedAt.Equal(want.LastUsedAt)
+}
+
+func testAPIKeys(t *testing.T, s Stores) {
+	key := domain.APIKey{
+		Name:      "front",
+		Prefix:    "ck_0001",
+		Hash:      "hash-1",
+		Scopes:    []domain.Permission{domain.ReadAppointments, domain.WritePatients},
+		CreatedAt: start,
+	}
+	id, err := s.APIKeys.Create(ctx, key)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	key.Id = id
+
+	got, err := s.APIKeys.Read(ctx, id)
+	if err != nil || !sameAPIKey(got, key) {
+		t.Errorf("Read: got %+v, %v, want %+v", got, err, key)
+	}
+	got, err = s.APIKeys.ReadByHash(ctx, "hash-1")
+	if err != nil || !sameAPIKey(got, key) {
+		t.Errorf("ReadByHash: got %+v, %v, want %+v", got, err, key)
+	}
+	_, err = s.APIKeys.ReadByHash(ctx, "hash-2")
+	expectNotFound(t, "ReadByHash of a missing key", err)
+	_, err = s.APIKeys.Create(ctx, domain.APIKey{Name: "other", Prefix: "ck_0002", Hash: "hash-1", CreatedAt: start})
+	if !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("Create with a taken hash: got error %v, want store.ErrDuplicate", err)
+	}
+
+	err = s.APIKeys.Touch(ctx, id, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	key.LastUsedAt = start.Add(time.Hour)
+	key.Prefix = "ck_0003"
+	key.Hash = "hash-3"
+	key.ExpiresAt = start.Add(24 * time.Hour)
+	err = s.APIKeys.Update(ctx, key)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	keys, err := s.APIKeys.ReadAll(ctx)
+	if err != nil || len(keys) != 1 || !sameAPIKey(keys[0], key) {
+		t.Errorf("ReadAll after Update: got %+v, %v, want [%+v]", keys, err, key)
+	}
+	err = s.APIKeys.Update(ctx, domain.APIKey{Id: id + 1000, Hash: "hash-4"})
+	expectNotFound(t, "Update of a missing key", err)
+
+	err = s.APIKeys.Delete(ctx, id)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err = s.APIKeys.Read(ctx, id)
+	expectNotFound(t, "Read after Delete", err)
+	err = s.APIKeys.Delete(ctx, id)
+	expectNotFound(t, "Delete of a missing key", err)
+}
+
+func testAudit(t *testing.T, s Stores) {
+	entries := []domain.AuditEntry{
+		{Actor: "ana", Action: domain.AuditCreate, Entity: "patient", EntityId: 1, Changes: []byte(`{"name":{"before":null,"after":"Ana"}}`), RequestId: "r1", CreatedAt: start},
+		{Actor: "juan", Action: domain.AuditUpdate, Entity: "patient", EntityId: 1, Changes: []byte(`{}`), CreatedAt: start.Add(time.Hour)},
+		{Actor: "ana", Action: domain.AuditDelete, Entity: "dentist", EntityId: 2, Changes: []byte(`{}`), CreatedAt: start.Add(2 * time.Hour)},
+	}
+	for i := range entries {
+		id, err := s.Audit.Create(ctx, entries[i])
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		entries[i].Id = id
+	}
+
+	for _, tt := range []struct {
+		name  string
+		query domain.AuditQuery
+		want  []domain.AuditEntry
+	}{
+		{"all, newest first", domain.AuditQuery{}, []domain.AuditEntry{entries[2], entries[1], entries[0]}},
+		{"by entity and id", domain.AuditQuery{Entity: "patient", EntityId: 1}, []domain.AuditEntry{entries[1], entries[0]}},
+		{"by actor", domain.AuditQuery{Actor: "ana"}, []domain.AuditEntry{entries[2], entries[0]}},
+		{"by time", domain.AuditQuery{From: start.Add(time.Hour), To: start.Add(2 * time.Hour)}, []domain.AuditEntry{entries[1]}},
+		{"limited", domain.AuditQuery{Limit: 1}, []domain.AuditEntry{entries[2]}},
+		{"no match", domain.AuditQuery{Entity: "appointment"}, []domain.AuditEntry{}},
+	} {
+		got, err := s.Audit.Search(ctx, tt.query)
+		if err != nil {
+			t.Errorf("Search %s: %v", tt.name, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Search %s: got %d entries, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			want := tt.want[i]
+			if got[i].Id != want.Id || got[i].Actor != want.Actor || got[i].Action != want.Action || got[i].Entity != want.Entity ||
+				got[i].EntityId != want.EntityId || string(got[i].Changes) != string(want.Changes) || got[i].RequestId != want.RequestId ||
+				!got[i].CreatedAt.Equal(want.CreatedAt) {
+				t.Errorf("Search %s: entry %d is %+v, want %+v", tt.name, i, got[i], want)
+			}
+		}
+	}
+}
@@ -2,18 +2,56 @@ package web
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/JulietaAlfie/backendGo.git/internal/domain"
 	"github.com/gin-gonic/gin"
 )
 
+// problemContentType is the media type of the RFC 7807 problem details.
+const problemContentType = "application/problem+json"
+
+// errorResponse is the body of the failures: an RFC 7807 problem details
+// object with the stable code of the error and the fields that failed
+// validation, if any.
 type errorResponse struct {
-	Status  int    `json:"status"`
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail"`
+	Instance string              `json:"instance"`
+	Code     string              `json:"code"`
+	Errors   []domain.FieldError `json:"errors,omitempty"`
+}
+
+// statuses maps the codes of the domain errors to their HTTP status.
+var statuses = map[string]int{
+	domain.CodeNotFound:            http.StatusNotFound,
+	domain.CodeNotDeleted:          http.StatusNotFound,
+	domain.CodeDuplicate:           http.StatusConflict,
+	domain.CodeDuplicateDNI:        http.StatusConflict,
+	domain.CodeDuplicateLicense:    http.StatusConflict,
+	domain.CodeDuplicateUsername:   http.StatusConflict,
+	domain.CodeSlotConflict:        http.StatusConflict,
+	domain.CodeParticipantDeleted:  http.StatusConflict,
+	domain.CodeOutsideWorkingHours: http.StatusUnprocessableEntity,
+	domain.CodeValidation:          http.StatusBadRequest,
+	domain.CodeInternal:            http.StatusInternalServerError,
 }
 
 type response struct {
 	Data interface{} `json:"data"`
+	Page *page       `json:"page,omitempty"`
+}
+
+// page tells which part of a list a response holds. Next is the path of the
+// following page, if there is one.
+type page struct {
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Next   string `json:"next,omitempty"`
 }
 
 func Success(ctx *gin.Context, status int, data interface{}) {
@@ -22,10 +60,50 @@ func Success(ctx *gin.Context, status int, data interface{}) {
 	})
 }
 
+// Failure responds with status and the problem details of err. Its code is the
+// one of err or, for errors without one, the status text in snake case, such
+// as "bad_request".
 func Failure(ctx *gin.Context, status int, err error) {
+	code := domain.ErrorCode(err)
+	if code == "" {
+		code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+	ctx.Header("Content-Type", problemContentType)
 	ctx.JSON(status, errorResponse{
-		Message: err.Error(),
-		Status:  status,
-		Code:    http.StatusText(status),
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   err.Error(),
+		Instance: ctx.Request.URL.Path,
+		Code:     code,
+		Errors:   domain.InvalidFieldsOf(err),
+	})
+}
+
+// Error responds with the problem details of err and the status of its code,
+// 500 for errors without one.
+func Error(ctx *gin.Context, err error) {
+	status, ok := statuses[domain.ErrorCode(err)]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	Failure(ctx, status, err)
+}
+
+// Paginated responds 200 with a page of a list of total items, which starts at
+// offset and holds limit items at most, and links to the next page.
+func Paginated(ctx *gin.Context, data interface{}, total int, limit int, offset int) {
+	p := &page{Total: total, Limit: limit, Offset: offset}
+	if offset+limit < total {
+		next := *ctx.Request.URL
+		query := next.Query()
+		query.Set("limit", strconv.Itoa(limit))
+		query.Set("offset", strconv.Itoa(offset+limit))
+		next.RawQuery = query.Encode()
+		p.Next = next.RequestURI()
+	}
+	ctx.JSON(http.StatusOK, response{
+		Data: data,
+		Page: p,
 	})
 }